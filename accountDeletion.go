@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+const defaultDeletionGracePeriod = 30 * 24 * time.Hour
+const purgeInterval = time.Hour
+
+type deleteUserRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type deleteUserResponse struct {
+	DeletedAt   time.Time `json:"deleted_at"`
+	PurgesAfter time.Time `json:"purges_after"`
+}
+
+type sessionExport struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	ClientID  *uuid.UUID `json:"client_id"`
+	Scope     string     `json:"scope,omitempty"`
+}
+
+type userExport struct {
+	ExportedAt time.Time        `json:"exported_at"`
+	Profile    newUserResponse  `json:"profile"`
+	Chirps     []chirpResponse  `json:"chirps"`
+	Sessions   []sessionExport  `json:"sessions"`
+	APIKeys    []apiKeyResponse `json:"api_keys"`
+}
+
+func (cfg *apiConfig) deleteUserHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	deleteRequest := deleteUserRequest{}
+	if !decodeRequest(writer, request, &deleteRequest) {
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(request.Context(), userID)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	if err := auth.CheckPasswordHash(deleteRequest.Password, user.HashedPassword); err != nil {
+		handleError("incorrect password", err, 401, writer)
+		return
+	}
+
+	// All or nothing, so a deleted account never keeps working credentials.
+	err = cfg.store.WithTx(request.Context(), func(ctx context.Context, queries database.Querier) error {
+		if err := queries.SoftDeleteUser(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := queries.RevokeUserTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		return queries.RevokeUserAPIKeys(ctx, user.ID)
+	})
+	if err != nil {
+		handleError("Could not delete user", err, 500, writer)
+		return
+	}
+
+	deletedAt := time.Now()
+	response, err := json.Marshal(deleteUserResponse{
+		DeletedAt:   deletedAt,
+		PurgesAfter: deletedAt.Add(cfg.deletionGracePeriod),
+	})
+	if err != nil {
+		handleError("Could not make response - user deleted", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(202)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) exportUserHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(request.Context(), userID)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsByUser(request.Context(), user.ID)
+	if err != nil {
+		handleError("Could not get chirps", err, 500, writer)
+		return
+	}
+
+	tokens, err := cfg.db.GetTokensByUser(request.Context(), user.ID)
+	if err != nil {
+		handleError("Could not get sessions", err, 500, writer)
+		return
+	}
+
+	apiKeys, err := cfg.db.GetAPIKeysByUser(request.Context(), user.ID)
+	if err != nil {
+		handleError("Could not get API keys", err, 500, writer)
+		return
+	}
+
+	export := userExport{
+		ExportedAt: time.Now(),
+		Profile:    makeUserProfile(user),
+		Chirps:     []chirpResponse{},
+		Sessions:   []sessionExport{},
+		APIKeys:    []apiKeyResponse{},
+	}
+	for _, chirp := range chirps {
+		export.Chirps = append(export.Chirps, makeChirpResponse(chirp))
+	}
+	// Token values are credentials, so only their metadata is exported.
+	for _, token := range tokens {
+		session := sessionExport{
+			CreatedAt: token.CreatedAt,
+			ExpiresAt: token.ExpiresAt,
+			RevokedAt: nullTimePointer(token.RevokedAt),
+			Scope:     token.Scope.String,
+		}
+		if token.ClientID.Valid {
+			session.ClientID = &token.ClientID.UUID
+		}
+		export.Sessions = append(export.Sessions, session)
+	}
+	for _, apiKey := range apiKeys {
+		export.APIKeys = append(export.APIKeys, makeAPIKeyResponse(apiKey))
+	}
+
+	response, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		handleError("Could not make export", err, 500, writer)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Content-Disposition", `attachment; filename="chirpy-export.json"`)
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) purgeDeletedUsers(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		cfg.purgeOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Users are only purged once their media is gone, otherwise the rows would
+// cascade away and leave blobs nothing points to.
+func (cfg *apiConfig) purgeOnce(ctx context.Context) {
+	now := time.Now()
+	cutoff := sql.NullTime{Time: now.Add(-cfg.deletionGracePeriod), Valid: true}
+	collected, err := cfg.collectMedia(ctx, cutoff, now.Add(-unattachedMediaLifetime))
+	if err != nil {
+		log.Printf("Could not collect media: %s", err)
+		return
+	}
+	if collected > 0 {
+		log.Printf("Collected %d media files", collected)
+	}
+
+	purged, err := cfg.db.PurgeDeletedUsers(ctx, cutoff)
+	if err != nil {
+		log.Printf("Could not purge deleted users: %s", err)
+	} else if purged > 0 {
+		log.Printf("Purged %d deleted users", purged)
+	}
+}
@@ -0,0 +1,8 @@
+// Package api embeds the OpenAPI description of Chirpy's HTTP API, served at
+// /api/v1/openapi.json.
+package api
+
+import _ "embed"
+
+//go:embed openapi.json
+var Spec []byte
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+type apiKeyRequest struct {
+	Name          string `json:"name" validate:"required"`
+	Scope         string `json:"scope"`
+	ExpiresInDays int    `json:"expires_in_days" validate:"min=0"`
+}
+
+type apiKeyResponse struct {
+	Id         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scope      string     `json:"scope"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func (cfg *apiConfig) createAPIKeyHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	keyRequest := apiKeyRequest{}
+	if !decodeRequest(writer, request, &keyRequest) {
+		return
+	}
+
+	if err := auth.ValidateScopes(keyRequest.Scope); err != nil {
+		handleError("Bad scope", err, 400, writer)
+		return
+	}
+
+	key, prefix, err := auth.MakeAPIKey()
+	if err != nil {
+		handleError("Could not make API key", err, 500, writer)
+		return
+	}
+
+	keyParams := database.CreateAPIKeyParams{
+		UserID:  userID,
+		Name:    keyRequest.Name,
+		Prefix:  prefix,
+		KeyHash: auth.HashAPIKey(key),
+		Scope:   strings.Join(strings.Fields(keyRequest.Scope), " "),
+	}
+	if keyRequest.ExpiresInDays > 0 {
+		keyParams.ExpiresAt = sql.NullTime{
+			Time:  time.Now().AddDate(0, 0, keyRequest.ExpiresInDays),
+			Valid: true,
+		}
+	}
+
+	apiKey, err := cfg.db.CreateAPIKey(request.Context(), keyParams)
+	if err != nil {
+		handleError("Could not save API key", err, 500, writer)
+		return
+	}
+
+	response := makeAPIKeyResponse(apiKey)
+	response.Key = key
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Created API key but cannot respond", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(201)
+	writer.Write(responseJSON)
+}
+
+func (cfg *apiConfig) getAPIKeysHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	apiKeys, err := cfg.db.GetAPIKeysByUser(request.Context(), userID)
+	if err != nil {
+		handleError("Could not get API keys", err, 500, writer)
+		return
+	}
+
+	response := []apiKeyResponse{}
+	for _, apiKey := range apiKeys {
+		response = append(response, makeAPIKeyResponse(apiKey))
+	}
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+func (cfg *apiConfig) revokeAPIKeyHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	keyID, err := uuid.Parse(request.PathValue("keyID"))
+	if err != nil {
+		handleError("Could not parse key ID", err, 400, writer)
+		return
+	}
+
+	revokeParams := database.RevokeAPIKeyParams{
+		ID:     keyID,
+		UserID: userID,
+	}
+	revoked, err := cfg.db.RevokeAPIKey(request.Context(), revokeParams)
+	if err != nil {
+		handleError("Could not revoke API key", err, 500, writer)
+		return
+	}
+	if revoked == 0 {
+		handleError("Could not find API key", nil, 404, writer)
+		return
+	}
+
+	writer.WriteHeader(204)
+}
+
+func makeAPIKeyResponse(apiKey database.ApiKey) apiKeyResponse {
+	return apiKeyResponse{
+		Id:         apiKey.ID,
+		CreatedAt:  apiKey.CreatedAt,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scope:      apiKey.Scope,
+		ExpiresAt:  nullTimePointer(apiKey.ExpiresAt),
+		LastUsedAt: nullTimePointer(apiKey.LastUsedAt),
+		RevokedAt:  nullTimePointer(apiKey.RevokedAt),
+	}
+}
+
+func nullTimePointer(nullTime sql.NullTime) *time.Time {
+	if !nullTime.Valid {
+		return nil
+	}
+	return &nullTime.Time
+}
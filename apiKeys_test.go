@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAPIKeys(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+
+	server.do("POST", "/api/v1/keys", user.Token, apiKeyRequest{Name: "bot", Scope: "everything"}, 400, nil)
+	created := apiKeyResponse{}
+	server.do("POST", "/api/v1/keys", user.Token, apiKeyRequest{Name: "bot", Scope: "chirps:write", ExpiresInDays: 30}, 201, &created)
+	if created.Key == "" || created.ExpiresAt == nil {
+		t.Fatalf("created key = %+v", created)
+	}
+
+	withKey := "ApiKey " + created.Key
+	posted := server.postChirp(withKey, chirp{Body: "posted with a key"})
+	if posted.UserId != user.Id {
+		t.Fatalf("chirp posted as %s, want %s", posted.UserId, user.Id)
+	}
+	server.do("GET", "/api/v1/timeline", withKey, nil, 403, nil)
+	server.do("POST", "/api/v1/chirps", "ApiKey chirpy_nope_nope", chirp{Body: "hi"}, 401, nil)
+
+	keys := []apiKeyResponse{}
+	server.do("GET", "/api/v1/keys", user.Token, nil, 200, &keys)
+	if len(keys) != 1 || keys[0].Key != "" || keys[0].LastUsedAt == nil {
+		t.Fatalf("keys = %+v", keys)
+	}
+
+	server.do("DELETE", "/api/v1/keys/"+created.Id.String(), user.Token, nil, 204, nil)
+	server.do("POST", "/api/v1/chirps", withKey, chirp{Body: "hi"}, 401, nil)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/google/uuid"
+)
+
+// Tokens from our own login carry no scope and may do anything. OAuth tokens
+// and personal API keys must carry requiredScope; an empty requiredScope means
+// the endpoint is only available to our own login.
+//
+// Users who have deleted their account are refused until they log back in,
+// even with tokens issued before the deletion.
+func (cfg *apiConfig) authorizeRequest(request *http.Request, requiredScope string) (uuid.UUID, int, error) {
+	userID, code, err := cfg.authorizeCredentials(request, requiredScope)
+	if err != nil {
+		return uuid.Nil, code, err
+	}
+
+	user, err := cfg.db.GetUserByID(request.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, 401, fmt.Errorf("User no longer exists")
+	}
+	if err != nil {
+		return uuid.Nil, 500, err
+	}
+	if user.DeletedAt.Valid {
+		return uuid.Nil, 401, fmt.Errorf("Account deleted")
+	}
+	return userID, 0, nil
+}
+
+func (cfg *apiConfig) authorizeCredentials(request *http.Request, requiredScope string) (uuid.UUID, int, error) {
+	if strings.HasPrefix(request.Header.Get("Authorization"), "ApiKey ") {
+		return cfg.authorizeAPIKey(request, requiredScope)
+	}
+
+	token, err := auth.GetBearerToken(request.Header)
+	if err != nil {
+		return uuid.Nil, 401, err
+	}
+
+	userID, scope, err := auth.ValidateScopedJWT(token, cfg.secret)
+	if err != nil {
+		return uuid.Nil, 401, err
+	}
+
+	if scope == "" {
+		return userID, 0, nil
+	}
+	if err := checkScope(scope, requiredScope); err != nil {
+		return uuid.Nil, 403, err
+	}
+	return userID, 0, nil
+}
+
+func (cfg *apiConfig) authorizeAPIKey(request *http.Request, requiredScope string) (uuid.UUID, int, error) {
+	key, err := auth.GetAPIKey(request.Header)
+	if err != nil {
+		return uuid.Nil, 401, err
+	}
+
+	prefix, err := auth.APIKeyPrefix(key)
+	if err != nil {
+		return uuid.Nil, 401, err
+	}
+
+	apiKey, err := cfg.db.GetAPIKeyByPrefix(request.Context(), prefix)
+	if err != nil {
+		return uuid.Nil, 401, fmt.Errorf("Unknown API key")
+	}
+	if err := auth.CheckAPIKeyHash(key, apiKey.KeyHash); err != nil {
+		return uuid.Nil, 401, err
+	}
+	if apiKey.RevokedAt.Valid {
+		return uuid.Nil, 401, fmt.Errorf("API key revoked")
+	}
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		return uuid.Nil, 401, fmt.Errorf("API key expired")
+	}
+	if err := checkScope(apiKey.Scope, requiredScope); err != nil {
+		return uuid.Nil, 403, err
+	}
+
+	if err := cfg.db.TouchAPIKey(request.Context(), apiKey.ID); err != nil {
+		return uuid.Nil, 500, err
+	}
+	return apiKey.UserID, 0, nil
+}
+
+// Public endpoints personalise their response when a valid token is sent, but
+// never reject the request because of it.
+func (cfg *apiConfig) getViewer(request *http.Request) (uuid.UUID, bool) {
+	if request.Header.Get("Authorization") == "" {
+		return uuid.Nil, false
+	}
+	viewerID, _, err := cfg.authorizeRequest(request, auth.ScopeChirpsRead)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return viewerID, true
+}
+
+func checkScope(scope, requiredScope string) error {
+	if requiredScope == "" || !auth.HasScope(scope, requiredScope) {
+		return fmt.Errorf("Token missing scope %q", requiredScope)
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/FFB6C1/bootdev_webservers/internal/auth"
 	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/service"
 	"github.com/google/uuid"
 )
 
@@ -17,42 +20,42 @@ const badResponseError = `{
 }`
 
 type chirp struct {
-	Body   string    `json:"body"`
-	UserId uuid.UUID `json:"user_id"`
+	Body      string      `json:"body" validate:"required,max=140"`
+	UserId    uuid.UUID   `json:"user_id"`
+	ReplyToId *uuid.UUID  `json:"reply_to_id"`
+	QuoteOfId *uuid.UUID  `json:"quote_of_id"`
+	MediaIds  []uuid.UUID `json:"media_ids"`
 }
 
 type chirpResponse struct {
-	Id        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserId    uuid.UUID `json:"user_id"`
+	Id           uuid.UUID       `json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	Body         string          `json:"body"`
+	UserId       uuid.UUID       `json:"user_id"`
+	Author       *chirpAuthor    `json:"author,omitempty"`
+	LikeCount    int32           `json:"like_count"`
+	LikedByMe    bool            `json:"liked_by_me"`
+	ReplyToId    *uuid.UUID      `json:"reply_to_id"`
+	Entities     chirpEntities   `json:"entities"`
+	Media        []mediaResponse `json:"media"`
+	Deleted      bool            `json:"deleted,omitempty"`
+	RechirpCount int32           `json:"rechirp_count"`
+	RechirpOfId  *uuid.UUID      `json:"rechirp_of_id"`
+	RechirpOf    *chirpResponse  `json:"rechirp_of,omitempty"`
+	QuoteOfId    *uuid.UUID      `json:"quote_of_id"`
+	QuotedChirp  *chirpResponse  `json:"quoted_chirp,omitempty"`
 }
 
 func (cfg *apiConfig) postNewChirpHandler(writer http.ResponseWriter, request *http.Request) {
 	chirp := chirp{}
-	decoder := json.NewDecoder(request.Body)
-
-	if err := decoder.Decode(&chirp); err != nil {
-		log.Printf("Error decoding chirp: %s", err)
-		handleError("Error decoding chirp", err, 500, writer)
+	if !decodeRequest(writer, request, &chirp) {
 		return
 	}
 
-	token, err := auth.GetBearerToken(request.Header)
+	tokenUUID, code, err := cfg.authorizeRequest(request, auth.ScopeChirpsWrite)
 	if err != nil {
-		handleError("Couldn't get token from header", err, 401, writer)
-		return
-	}
-
-	tokenUUID, err := auth.ValidateJWT(token, cfg.secret)
-	if err != nil {
-		handleError("Unauthorized", err, 401, writer)
-		return
-	}
-
-	if !checkChirpLength(chirp.Body) {
-		handleError("Chirp too long", nil, 400, writer)
+		handleError("Unauthorized", err, code, writer)
 		return
 	}
 
@@ -63,13 +66,92 @@ func (cfg *apiConfig) postNewChirpHandler(writer http.ResponseWriter, request *h
 		UserID: tokenUUID,
 	}
 
-	addedChirp, err := cfg.db.CreateChirp(request.Context(), chirpToAdd)
+	var parent database.Chirp
+	if chirp.ReplyToId != nil {
+		parent, err = cfg.db.GetChirpById(request.Context(), *chirp.ReplyToId)
+		if err != nil {
+			handleError("Couldn't find chirp to reply to", err, 404, writer)
+			return
+		}
+		if parent.DeletedAt.Valid {
+			handleError("Cannot reply to a deleted chirp", nil, 400, writer)
+			return
+		}
+		chirpToAdd.ReplyToID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	if chirp.QuoteOfId != nil {
+		quoted, err := cfg.getShareableChirp(request.Context(), *chirp.QuoteOfId)
+		if err != nil {
+			handleError("Couldn't find chirp to quote", err, 404, writer)
+			return
+		}
+		chirpToAdd.QuoteOfID = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
+	mediaIDs := []uuid.UUID{}
+	if len(chirp.MediaIds) > 0 {
+		mediaIDs, err = cfg.getAttachableMedia(request.Context(), tokenUUID, chirp.MediaIds)
+		if err != nil {
+			handleError("Bad media", err, 400, writer)
+			return
+		}
+	}
+
+	var addedChirp database.Chirp
+	err = cfg.store.WithTx(request.Context(), func(ctx context.Context, queries database.Querier) error {
+		addedChirp, err = queries.CreateChirp(ctx, chirpToAdd)
+		if err != nil {
+			return err
+		}
+
+		for position, mediaID := range mediaIDs {
+			if err := queries.AttachMediaFile(ctx, database.AttachMediaFileParams{
+				ChirpID:  addedChirp.ID,
+				MediaID:  mediaID,
+				Position: int32(position),
+			}); err != nil {
+				return err
+			}
+		}
+
+		mentioned, err := cfg.saveChirpEntities(ctx, queries, addedChirp)
+		if err != nil {
+			return err
+		}
+		if err := cfg.notifier.notify(ctx, queries, mentionEvent{
+			chirp:     addedChirp,
+			mentioned: mentioned,
+		}); err != nil {
+			return err
+		}
+
+		if chirpToAdd.ReplyToID.Valid {
+			return cfg.notifier.notify(ctx, queries, replyEvent{
+				reply:  addedChirp,
+				parent: parent,
+			})
+		}
+		return nil
+	})
 	if err != nil {
 		handleError("Error creating chirp", err, 500, writer)
 		return
 	}
 
-	response, err := makeChirpJSON(addedChirp)
+	if err := cfg.fanOutChirp(request.Context(), addedChirp); err != nil {
+		log.Printf("Error fanning out chirp: %s", err)
+	}
+
+	responses, err := cfg.makeChirpResponses([]database.Chirp{addedChirp}, request)
+	if err != nil {
+		handleError("Error creating response", err, 500, writer)
+		return
+	}
+
+	cfg.publishChirp(request.Context(), responses[0])
+
+	response, err := json.Marshal(responses[0])
 	if err != nil {
 		handleError("Error creating response", err, 500, writer)
 		return
@@ -81,23 +163,37 @@ func (cfg *apiConfig) postNewChirpHandler(writer http.ResponseWriter, request *h
 }
 
 func (cfg *apiConfig) getChirpsHandler(writer http.ResponseWriter, request *http.Request) {
-	chirps, err := cfg.db.GetChirps(request.Context())
+	var chirps []database.Chirp
+	var err error
+	switch request.URL.Query().Get("sort") {
+	case "", "created":
+		chirps, err = cfg.db.GetChirps(request.Context())
+	case "popular":
+		chirps, err = cfg.db.GetChirpsByPopularity(request.Context())
+	default:
+		handleError("sort must be created or popular", nil, 400, writer)
+		return
+	}
 	if err != nil {
 		handleError("Failed to get chirps", err, 500, writer)
 		return
 	}
-	writer.WriteHeader(200)
-	jsonChirps := []string{}
-	for _, chirp := range chirps {
-		jsonChirp, err := makeChirpJSON(chirp)
-		if err != nil {
-			handleError("Failed to marshal chirp into json", err, 500, writer)
-			return
-		}
-		jsonChirps = append(jsonChirps, string(jsonChirp))
+	cfg.writeChirps(chirps, writer, request)
+}
+
+func (cfg *apiConfig) writeChirps(chirps []database.Chirp, writer http.ResponseWriter, request *http.Request) {
+	responses, err := cfg.makeChirpResponses(chirps, request)
+	if err != nil {
+		handleError("Failed to make chirp responses", err, 500, writer)
+		return
+	}
+	body, err := json.Marshal(responses)
+	if err != nil {
+		handleError("Failed to marshal chirps into json", err, 500, writer)
+		return
 	}
-	body := "[" + strings.Join(jsonChirps, ", ") + "]"
-	writer.Write([]byte(body))
+	writer.WriteHeader(200)
+	writer.Write(body)
 }
 
 func (cfg *apiConfig) getChirpByIdHandler(writer http.ResponseWriter, request *http.Request) {
@@ -112,8 +208,18 @@ func (cfg *apiConfig) getChirpByIdHandler(writer http.ResponseWriter, request *h
 		handleError("Couldn't find chirp", err, 404, writer)
 		return
 	}
+	if chirp.DeletedAt.Valid {
+		handleError("Couldn't find chirp", nil, 404, writer)
+		return
+	}
+
+	responses, err := cfg.makeChirpResponses([]database.Chirp{chirp}, request)
+	if err != nil {
+		handleError("Couldn't make chirp response", err, 500, writer)
+		return
+	}
 
-	chirpJSON, err := makeChirpJSON(chirp)
+	chirpJSON, err := json.Marshal(responses[0])
 	if err != nil {
 		handleError("Couldn't marshal chirp into JSON", err, 500, writer)
 		return
@@ -124,15 +230,9 @@ func (cfg *apiConfig) getChirpByIdHandler(writer http.ResponseWriter, request *h
 }
 
 func (cfg *apiConfig) deleteChirpByIdHandler(writer http.ResponseWriter, request *http.Request) {
-	token, err := auth.GetBearerToken(request.Header)
+	tokenUUID, code, err := cfg.authorizeRequest(request, auth.ScopeChirpsWrite)
 	if err != nil {
-		handleError("Couldn't get token from header", err, 401, writer)
-		return
-	}
-
-	tokenUUID, err := auth.ValidateJWT(token, cfg.secret)
-	if err != nil {
-		handleError("Unauthorized", err, 401, writer)
+		handleError("Unauthorized", err, code, writer)
 		return
 	}
 
@@ -142,27 +242,29 @@ func (cfg *apiConfig) deleteChirpByIdHandler(writer http.ResponseWriter, request
 		return
 	}
 
-	chirp, err := cfg.db.GetChirpById(request.Context(), chirpID)
-	if err != nil {
-		handleError("Could not find chirp", err, 404, writer)
-		return
-	}
-
-	if tokenUUID != chirp.UserID {
-		handleError("Unauthorized", nil, 403, writer)
-		return
-	}
-
-	if err := cfg.db.DeleteChirp(request.Context(), chirp.ID); err != nil {
-		handleError("Could not delete chirp", err, 500, writer)
+	if _, err := cfg.chirps.Delete(request.Context(), tokenUUID, chirpID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			handleError("Could not find chirp", err, 404, writer)
+		case errors.Is(err, service.ErrForbidden):
+			handleError("Unauthorized", nil, 403, writer)
+		default:
+			handleError("Could not delete chirp", err, 500, writer)
+		}
 		return
 	}
 
+	cfg.publishEvent(request.Context(), eventChirpDeleted, uuid.Nil, chirpDeletedEvent{Id: chirpID})
 	writer.WriteHeader(204)
 }
 
-func checkChirpLength(text string) bool {
-	return len(text) <= 140
+// Deletes any chirp, for the admin CLI.
+func (cfg *apiConfig) removeChirp(ctx context.Context, chirp database.Chirp) error {
+	if err := cfg.chirps.Remove(ctx, chirp); err != nil {
+		return err
+	}
+	cfg.publishEvent(ctx, eventChirpDeleted, uuid.Nil, chirpDeletedEvent{Id: chirp.ID})
+	return nil
 }
 
 func checkChirpProfanity(text string) string {
@@ -177,19 +279,98 @@ func checkChirpProfanity(text string) string {
 
 }
 
-func makeChirpJSON(chirp database.Chirp) ([]byte, error) {
-	chirpJson := chirpResponse{
+// Rechirped and quoted chirps are rendered inline, one level deep. Passing
+// ?expand=author embeds each chirp's author, looked up in one query.
+func (cfg *apiConfig) makeChirpResponses(chirps []database.Chirp, request *http.Request) ([]chirpResponse, error) {
+	referenced, err := cfg.getReferencedChirps(request.Context(), chirps)
+	if err != nil {
+		return nil, err
+	}
+	allChirps := append(append([]database.Chirp{}, chirps...), referenced...)
+
+	authors := map[uuid.UUID]chirpAuthor{}
+	if request.URL.Query().Get("expand") == "author" {
+		found, err := cfg.getChirpAuthors(request.Context(), allChirps)
+		if err != nil {
+			return nil, err
+		}
+		authors = found
+	}
+
+	entitiesByChirp, err := cfg.getChirpEntities(request.Context(), allChirps)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaByChirp, err := cfg.getChirpMedia(request.Context(), allChirps)
+	if err != nil {
+		return nil, err
+	}
+
+	liked := map[uuid.UUID]bool{}
+	if viewerID, ok := cfg.getViewer(request); ok && len(allChirps) > 0 {
+		found, err := cfg.getLikedChirps(request.Context(), viewerID, allChirps)
+		if err != nil {
+			return nil, err
+		}
+		liked = found
+	}
+
+	decorate := func(chirp database.Chirp) chirpResponse {
+		response := makeChirpResponse(chirp)
+		if author, ok := authors[chirp.UserID]; ok {
+			response.Author = &author
+		}
+		response.LikedByMe = liked[chirp.ID]
+		response.Entities = makeChirpEntities(entitiesByChirp[chirp.ID])
+		response.Media = mediaByChirp[chirp.ID]
+		if response.Media == nil {
+			response.Media = []mediaResponse{}
+		}
+		return response
+	}
+
+	referencedResponses := map[uuid.UUID]chirpResponse{}
+	for _, chirp := range referenced {
+		referencedResponses[chirp.ID] = decorate(chirp)
+	}
+
+	responses := []chirpResponse{}
+	for _, chirp := range chirps {
+		response := decorate(chirp)
+		if original, ok := referencedResponses[chirp.RechirpOfID.UUID]; ok && chirp.RechirpOfID.Valid {
+			response.RechirpOf = &original
+		}
+		if quoted, ok := referencedResponses[chirp.QuoteOfID.UUID]; ok && chirp.QuoteOfID.Valid {
+			response.QuotedChirp = &quoted
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+func makeChirpResponse(chirp database.Chirp) chirpResponse {
+	return chirpResponse{
 		Id:        chirp.ID,
 		CreatedAt: chirp.CreatedAt,
 		UpdatedAt: chirp.UpdatedAt,
 		Body:      chirp.Body,
 		UserId:    chirp.UserID,
+		LikeCount: chirp.LikeCount,
+		ReplyToId: nullUUIDPointer(chirp.ReplyToID),
+		Deleted:   chirp.DeletedAt.Valid,
+
+		RechirpCount: chirp.RechirpCount,
+		RechirpOfId:  nullUUIDPointer(chirp.RechirpOfID),
+		QuoteOfId:    nullUUIDPointer(chirp.QuoteOfID),
 	}
-	responseJson, err := json.Marshal(chirpJson)
-	if err != nil {
-		return []byte(""), err
+}
+
+func nullUUIDPointer(nullUUID uuid.NullUUID) *uuid.UUID {
+	if !nullUUID.Valid {
+		return nil
 	}
-	return responseJson, nil
+	return &nullUUID.UUID
 }
 
 func getBadWords() map[string]int {
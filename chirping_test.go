@@ -0,0 +1,213 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPostAndGetChirps(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "alice")
+
+	server.do("POST", "/api/v1/chirps", "", chirp{Body: "hello"}, 401, nil)
+	server.do("POST", "/api/v1/chirps", user.Token, chirp{Body: strings.Repeat("a", 141)}, 400, nil)
+	first := server.postChirp(user.Token, chirp{Body: "this is a kerfuffle"})
+	if first.Body != "this is a ****" || first.UserId != user.Id {
+		t.Fatalf("posted chirp = %+v", first)
+	}
+	second := server.postChirp(user.Token, chirp{Body: "second"})
+
+	chirps := []chirpResponse{}
+	server.do("GET", "/api/v1/chirps", "", nil, 200, &chirps)
+	if !slices.Equal(chirpIDs(chirps), []uuid.UUID{first.Id, second.Id}) {
+		t.Fatalf("chirps = %v, want oldest first", chirpIDs(chirps))
+	}
+	server.do("GET", "/api/v1/chirps?sort=newest", "", nil, 400, nil)
+
+	got := chirpResponse{}
+	server.do("GET", "/api/v1/chirps/"+first.Id.String()+"?expand=author", "", nil, 200, &got)
+	if got.Id != first.Id || got.Author == nil || got.Author.Handle != "alice" {
+		t.Fatalf("chirp = %+v", got)
+	}
+	server.do("GET", "/api/v1/chirps/not-a-uuid", "", nil, 400, nil)
+	server.do("GET", "/api/v1/chirps/"+uuid.NewString(), "", nil, 404, nil)
+}
+
+func TestDeleteChirp(t *testing.T) {
+	server := newTestServer(t)
+	author := server.signUp("author@example.com", "")
+	other := server.signUp("other@example.com", "")
+	posted := server.postChirp(author.Token, chirp{Body: "hello"})
+
+	server.do("DELETE", "/api/v1/chirps/"+posted.Id.String(), other.Token, nil, 403, nil)
+	server.do("DELETE", "/api/v1/chirps/"+posted.Id.String(), author.Token, nil, 204, nil)
+	server.do("GET", "/api/v1/chirps/"+posted.Id.String(), "", nil, 404, nil)
+	server.do("DELETE", "/api/v1/chirps/"+uuid.NewString(), author.Token, nil, 404, nil)
+}
+
+func TestLikes(t *testing.T) {
+	server := newTestServer(t)
+	author := server.signUp("author@example.com", "")
+	fan := server.signUp("fan@example.com", "")
+	posted := server.postChirp(author.Token, chirp{Body: "like me"})
+	path := "/api/v1/chirps/" + posted.Id.String() + "/like"
+
+	server.do("POST", path, "", nil, 401, nil)
+	liked := likeResponse{}
+	server.do("POST", path, fan.Token, nil, 200, &liked)
+	server.do("POST", path, fan.Token, nil, 200, &liked)
+	if liked.LikeCount != 1 || !liked.LikedByMe {
+		t.Fatalf("like = %+v", liked)
+	}
+
+	got := chirpResponse{}
+	server.do("GET", "/api/v1/chirps/"+posted.Id.String(), fan.Token, nil, 200, &got)
+	if got.LikeCount != 1 || !got.LikedByMe {
+		t.Fatalf("chirp seen by fan = %+v", got)
+	}
+	server.do("GET", "/api/v1/chirps/"+posted.Id.String(), "", nil, 200, &got)
+	if got.LikedByMe {
+		t.Fatal("anonymous viewer likes the chirp")
+	}
+
+	server.do("DELETE", path, fan.Token, nil, 200, &liked)
+	if liked.LikeCount != 0 || liked.LikedByMe {
+		t.Fatalf("unlike = %+v", liked)
+	}
+	server.do("POST", "/api/v1/chirps/"+uuid.NewString()+"/like", fan.Token, nil, 404, nil)
+
+	popular := server.postChirp(author.Token, chirp{Body: "popular"})
+	server.do("POST", "/api/v1/chirps/"+popular.Id.String()+"/like", fan.Token, nil, 200, nil)
+	chirps := []chirpResponse{}
+	server.do("GET", "/api/v1/chirps?sort=popular", "", nil, 200, &chirps)
+	if len(chirps) != 2 || chirps[0].Id != popular.Id {
+		t.Fatalf("popular chirps = %v", chirpIDs(chirps))
+	}
+}
+
+func TestRechirpsAndQuotes(t *testing.T) {
+	server := newTestServer(t)
+	author := server.signUp("author@example.com", "")
+	fan := server.signUp("fan@example.com", "")
+	posted := server.postChirp(author.Token, chirp{Body: "share me"})
+	path := "/api/v1/chirps/" + posted.Id.String() + "/rechirp"
+
+	rechirp := chirpResponse{}
+	server.do("POST", path, fan.Token, nil, 201, &rechirp)
+	if rechirp.RechirpOf == nil || rechirp.RechirpOf.Id != posted.Id || rechirp.RechirpOf.RechirpCount != 1 {
+		t.Fatalf("rechirp = %+v", rechirp)
+	}
+	server.do("POST", path, fan.Token, nil, 409, nil)
+	server.do("POST", "/api/v1/chirps/"+rechirp.Id.String()+"/rechirp", author.Token, nil, 201, nil)
+
+	quote := server.postChirp(fan.Token, chirp{Body: "look at this", QuoteOfId: &posted.Id})
+	if quote.QuotedChirp == nil || quote.QuotedChirp.Id != posted.Id {
+		t.Fatalf("quote = %+v", quote)
+	}
+
+	server.do("DELETE", path, fan.Token, nil, 204, nil)
+	server.do("DELETE", path, fan.Token, nil, 404, nil)
+	server.do("GET", "/api/v1/chirps/"+rechirp.Id.String(), "", nil, 404, nil)
+}
+
+func TestThread(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	root := server.postChirp(user.Token, chirp{Body: "root"})
+	reply := server.postChirp(user.Token, chirp{Body: "reply", ReplyToId: &root.Id})
+	nested := server.postChirp(user.Token, chirp{Body: "nested", ReplyToId: &reply.Id})
+	missing := uuid.New()
+	server.do("POST", "/api/v1/chirps", user.Token, chirp{Body: "orphan", ReplyToId: &missing}, 404, nil)
+
+	thread := threadResponse{}
+	server.do("GET", "/api/v1/chirps/"+reply.Id.String()+"/thread", "", nil, 200, &thread)
+	if thread.Chirp.Id != reply.Id || !slices.Equal(chirpIDs(thread.Ancestors), []uuid.UUID{root.Id}) {
+		t.Fatalf("thread = %+v", thread)
+	}
+	if len(thread.Replies) != 1 || thread.Replies[0].Id != nested.Id || thread.Replies[0].Depth != 1 {
+		t.Fatalf("replies = %+v", thread.Replies)
+	}
+
+	server.do("GET", "/api/v1/chirps/"+root.Id.String()+"/thread", "", nil, 200, &thread)
+	if len(thread.Ancestors) != 0 || len(thread.Replies) != 2 {
+		t.Fatalf("root thread = %+v", thread)
+	}
+	server.do("GET", "/api/v1/chirps/"+uuid.NewString()+"/thread", "", nil, 404, nil)
+}
+
+func TestHashtagsAndTrending(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	first := server.postChirp(user.Token, chirp{Body: "learning #golang today"})
+	second := server.postChirp(user.Token, chirp{Body: "more #GoLang and #sql"})
+	if len(first.Entities.Hashtags) != 1 || first.Entities.Hashtags[0].Tag != "golang" {
+		t.Fatalf("entities = %+v", first.Entities)
+	}
+
+	tagged := timelineResponse{}
+	server.do("GET", "/api/v1/hashtags/golang", "", nil, 200, &tagged)
+	if !slices.Equal(chirpIDs(tagged.Chirps), []uuid.UUID{second.Id, first.Id}) {
+		t.Fatalf("#golang = %v", chirpIDs(tagged.Chirps))
+	}
+	server.do("GET", "/api/v1/hashtags/no%20spaces", "", nil, 400, nil)
+
+	trending := trendingResponse{}
+	server.do("GET", "/api/v1/trending/hashtags?window=1h", "", nil, 200, &trending)
+	if trending.Window != "1h" || len(trending.Hashtags) != 2 || trending.Hashtags[0] != (trendingHashtag{Tag: "golang", Count: 2}) {
+		t.Fatalf("trending = %+v", trending)
+	}
+	server.do("GET", "/api/v1/trending/hashtags?window=1y", "", nil, 400, nil)
+}
+
+func TestSearch(t *testing.T) {
+	server := newTestServer(t)
+	alice := server.signUp("alice@example.com", "alice")
+	bob := server.signUp("bob@example.com", "bob")
+	server.postChirp(alice.Token, chirp{Body: "gophers love go"})
+	fromBob := server.postChirp(bob.Token, chirp{Body: "go go go"})
+	server.postChirp(bob.Token, chirp{Body: "nothing to see"})
+
+	results := chirpSearchResponse{}
+	server.do("GET", "/api/v1/search/chirps?q=go", "", nil, 200, &results)
+	if len(results.Chirps) != 2 || results.Chirps[0].Id != fromBob.Id || !strings.Contains(results.Chirps[0].Headline, "<mark>go</mark>") {
+		t.Fatalf("results = %+v", results)
+	}
+	server.do("GET", "/api/v1/search/chirps?q=go&author=alice", "", nil, 200, &results)
+	if len(results.Chirps) != 1 || results.Chirps[0].UserId != alice.Id {
+		t.Fatalf("results by alice = %+v", results)
+	}
+	server.do("GET", "/api/v1/search/chirps?q=", "", nil, 400, nil)
+
+	server.postChirp(alice.Token, chirp{Body: "xss <img src=x onerror=alert(1)> \ue000"})
+	server.do("GET", "/api/v1/search/chirps?q=xss", "", nil, 200, &results)
+	if len(results.Chirps) != 1 || !strings.HasPrefix(results.Chirps[0].Headline, "<mark>xss</mark> ") ||
+		strings.Contains(results.Chirps[0].Headline, "<img") || strings.Count(results.Chirps[0].Headline, "<mark>") != 1 {
+		t.Fatalf("results = %+v", results)
+	}
+
+	users := userSearchResponse{}
+	server.do("GET", "/api/v1/search/users?q=al", "", nil, 200, &users)
+	if len(users.Users) != 1 || users.Users[0].Handle != "alice" || users.Users[0].Email != "" {
+		t.Fatalf("users = %+v", users)
+	}
+}
+
+// _ and % in a search are the characters themselves, not LIKE wildcards.
+func TestSearchUsersMatchesWildcardsLiterally(t *testing.T) {
+	server := newTestServer(t)
+	server.signUp("a@example.com", "foo_bar")
+	server.signUp("b@example.com", "fooxbar")
+
+	users := userSearchResponse{}
+	server.do("GET", "/api/v1/search/users?q=foo_", "", nil, 200, &users)
+	if len(users.Users) != 1 || users.Users[0].Handle != "foo_bar" {
+		t.Fatalf("users matching foo_ = %+v", users)
+	}
+	server.do("GET", "/api/v1/search/users?q=foo%25", "", nil, 200, &users)
+	if len(users.Users) != 0 {
+		t.Fatalf("users matching foo%% = %+v", users)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/pubsub"
+	"github.com/FFB6C1/bootdev_webservers/internal/service"
+	"github.com/google/uuid"
+)
+
+const usage = `usage: chirpy <command> [arguments]
+
+Commands:
+  serve                                 run the server (the default)
+  migrate up|down|status|redo           manage the database schema
+  user create -email <email> [-password <password>] [-red]
+  user list [-limit <n>] [-offset <n>]
+  user set-red [-off] <user>
+  user reset-password [-password <password>] <user>
+  user delete [-purge] <user>
+  chirp delete <chirp id>
+  chirp purge-user <user>
+  tokens revoke-user [-api-keys] <user>
+  keys rotate [-env-file <path>]
+
+Users are given as an ID, an email address or an @handle. Passwords that
+aren't given are generated and printed. Commands other than serve and keys
+use DB_URL, a Postgres URL or sqlite:path/to/chirpy.db. user delete -purge
+also uses MEDIA_STORE to delete the user's uploads.`
+
+func runCommand(command string, args []string) error {
+	switch command {
+	case "help", "-h", "-help", "--help":
+		fmt.Println(usage)
+		return nil
+	case "keys":
+		return runKeysCommand(args)
+	case "migrate", "user", "chirp", "tokens":
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+
+	db, backend, err := openDatabase(os.Getenv("DB_URL"))
+	if err != nil {
+		return fmt.Errorf("Could not open database: %w", err)
+	}
+	defer db.Close()
+
+	if command == "migrate" {
+		return runMigrateCommand(db, backend, args)
+	}
+
+	ctx := context.Background()
+	if err := checkSchemaVersion(ctx, db, backend); err != nil {
+		return err
+	}
+	cfg := newCommandConfig(db, backend)
+
+	switch command {
+	case "user":
+		return cfg.runUserCommand(ctx, args)
+	case "chirp":
+		return cfg.runChirpCommand(ctx, args)
+	default:
+		return cfg.runTokensCommand(ctx, args)
+	}
+}
+
+// Commands publish through Postgres when servers do, so deletions made from
+// the command line reach live streams.
+func newCommandConfig(db *sql.DB, backend backend) *apiConfig {
+	store := backend.newStore(db)
+	cfg := &apiConfig{
+		db:      store,
+		store:   store,
+		dbConn:  db,
+		backend: backend,
+		hub:     pubsub.NewHub(eventHistorySize, eventBufferSize),
+		chirps:  service.NewChirpService(store),
+		users:   service.NewUserService(store, os.Getenv("SECRET")),
+	}
+	cfg.events = hubPublisher{hub: cfg.hub}
+	if os.Getenv("EVENT_BROKER") == eventBrokerPostgres && backend.name == postgresBackend.name {
+		cfg.events = postgresPublisher{db: store}
+	}
+	return cfg
+}
+
+func (cfg *apiConfig) runUserCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "create":
+		email := flags.String("email", "", "email address")
+		passwordFlag := flags.String("password", "", "password, generated if empty")
+		red := flags.Bool("red", false, "give the user Chirpy Red")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *email == "" {
+			return fmt.Errorf("-email is required")
+		}
+		password, generated, err := passwordOrGenerate(*passwordFlag)
+		if err != nil {
+			return err
+		}
+		hashed, err := auth.HashPassword(password)
+		if err != nil {
+			return err
+		}
+		user, err := cfg.db.CreateUser(ctx, database.CreateUserParams{
+			Email:          *email,
+			HashedPassword: hashed,
+		})
+		if err != nil {
+			return fmt.Errorf("Could not create user: %w", err)
+		}
+		if *red {
+			if err := cfg.db.SetChirpyRed(ctx, database.SetChirpyRedParams{ID: user.ID, IsChirpyRed: true}); err != nil {
+				return err
+			}
+		}
+		fmt.Println("Created user", user.ID)
+		if generated {
+			fmt.Println("Password:", password)
+		}
+
+	case "list":
+		limit := flags.Int("limit", 50, "number of users to show")
+		offset := flags.Int("offset", 0, "number of users to skip")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		users, err := cfg.db.ListUsers(ctx, database.ListUsersParams{
+			Limit:  int32(*limit),
+			Offset: int32(*offset),
+		})
+		if err != nil {
+			return err
+		}
+		table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tEMAIL\tHANDLE\tRED\t2FA\tCREATED\tDELETED")
+		for _, user := range users {
+			deleted := ""
+			if user.DeletedAt.Valid {
+				deleted = user.DeletedAt.Time.Format(time.DateTime)
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%t\t%t\t%s\t%s\n",
+				user.ID, user.Email, user.Handle.String, user.IsChirpyRed, user.TotpEnabled,
+				user.CreatedAt.Format(time.DateTime), deleted)
+		}
+		return table.Flush()
+
+	case "set-red":
+		off := flags.Bool("off", false, "take Chirpy Red away instead")
+		user, err := cfg.parseUserArgs(ctx, flags, args[1:])
+		if err != nil {
+			return err
+		}
+		if err := cfg.db.SetChirpyRed(ctx, database.SetChirpyRedParams{ID: user.ID, IsChirpyRed: !*off}); err != nil {
+			return err
+		}
+		fmt.Printf("Chirpy Red for %s: %t\n", user.Email, !*off)
+
+	case "reset-password":
+		password := flags.String("password", "", "new password, generated if empty")
+		user, err := cfg.parseUserArgs(ctx, flags, args[1:])
+		if err != nil {
+			return err
+		}
+		newPassword, generated, err := passwordOrGenerate(*password)
+		if err != nil {
+			return err
+		}
+		hashed, err := auth.HashPassword(newPassword)
+		if err != nil {
+			return err
+		}
+		if _, err := cfg.db.UpdateUserEmailAndPassword(ctx, database.UpdateUserEmailAndPasswordParams{
+			ID:             user.ID,
+			Email:          user.Email,
+			HashedPassword: hashed,
+		}); err != nil {
+			return err
+		}
+		// Whoever had the old password shouldn't keep a session.
+		if err := cfg.db.RevokeUserTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		fmt.Println("Reset password for", user.Email)
+		if generated {
+			fmt.Println("Password:", newPassword)
+		}
+
+	case "delete":
+		purge := flags.Bool("purge", false, "delete now instead of after the grace period")
+		user, err := cfg.parseUserArgs(ctx, flags, args[1:])
+		if err != nil {
+			return err
+		}
+		if *purge {
+			if cfg.blobs, err = makeBlobStore(os.Getenv("MEDIA_STORE")); err != nil {
+				return fmt.Errorf("Could not set up media storage: %w", err)
+			}
+			mediaFiles, err := cfg.db.GetMediaFilesByUser(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			if err := cfg.deleteMediaFiles(ctx, mediaFiles); err != nil {
+				return err
+			}
+			if _, err := cfg.db.DeleteUser(ctx, user.ID); err != nil {
+				return err
+			}
+			fmt.Println("Purged", user.Email)
+			return nil
+		}
+		if err := cfg.db.SoftDeleteUser(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := cfg.db.RevokeUserTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := cfg.db.RevokeUserAPIKeys(ctx, user.ID); err != nil {
+			return err
+		}
+		fmt.Println("Deleted", user.Email, "- purged once the grace period is over")
+
+	default:
+		return fmt.Errorf("unknown user command %q\n\n%s", args[0], usage)
+	}
+	return nil
+}
+
+func (cfg *apiConfig) runChirpCommand(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf(usage)
+	}
+
+	switch args[0] {
+	case "delete":
+		chirpID, err := uuid.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("Could not parse chirp ID: %w", err)
+		}
+		chirp, err := cfg.db.GetChirpById(ctx, chirpID)
+		if err != nil || chirp.DeletedAt.Valid {
+			return fmt.Errorf("Could not find chirp %s", chirpID)
+		}
+		if err := cfg.removeChirp(ctx, chirp); err != nil {
+			return err
+		}
+		fmt.Println("Deleted chirp", chirpID)
+
+	case "purge-user":
+		user, err := cfg.findUser(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		chirps, err := cfg.db.GetChirpsByUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		for _, chirp := range chirps {
+			if err := cfg.removeChirp(ctx, chirp); err != nil {
+				return fmt.Errorf("Could not delete chirp %s: %w", chirp.ID, err)
+			}
+		}
+		fmt.Printf("Deleted %d chirps by %s\n", len(chirps), user.Email)
+
+	default:
+		return fmt.Errorf("unknown chirp command %q\n\n%s", args[0], usage)
+	}
+	return nil
+}
+
+func (cfg *apiConfig) runTokensCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "revoke-user" {
+		return fmt.Errorf(usage)
+	}
+	flags := flag.NewFlagSet("tokens revoke-user", flag.ContinueOnError)
+	apiKeys := flags.Bool("api-keys", false, "revoke the user's API keys too")
+	user, err := cfg.parseUserArgs(ctx, flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.db.RevokeUserTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	if *apiKeys {
+		if err := cfg.db.RevokeUserAPIKeys(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	// Access tokens are stateless, so they only run out.
+	fmt.Println("Revoked refresh tokens for", user.Email, "- access tokens stay valid until they expire")
+	return nil
+}
+
+// JWTs are signed with SECRET from the environment, so rotating means making a
+// new one and restarting. Every access token stops working on restart; refresh
+// tokens are stored and carry on.
+func runKeysCommand(args []string) error {
+	if len(args) == 0 || args[0] != "rotate" {
+		return fmt.Errorf(usage)
+	}
+	flags := flag.NewFlagSet("keys rotate", flag.ContinueOnError)
+	envFile := flags.String("env-file", "", "write the new SECRET into this file")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	key := make([]byte, 64)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	secret := base64.StdEncoding.EncodeToString(key)
+
+	if *envFile == "" {
+		fmt.Printf("SECRET=%s\n", secret)
+		fmt.Println("Set this and restart every instance; existing access tokens stop working.")
+		return nil
+	}
+
+	contents, err := os.ReadFile(*envFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	lines := []string{}
+	replaced := false
+	for _, line := range strings.Split(strings.TrimRight(string(contents), "\n"), "\n") {
+		if strings.HasPrefix(line, "SECRET=") {
+			line = "SECRET=" + secret
+			replaced = true
+		}
+		if line != "" || len(lines) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	if !replaced {
+		lines = append(lines, "SECRET="+secret)
+	}
+	if err := os.WriteFile(*envFile, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	fmt.Println("Wrote a new SECRET to", *envFile, "- restart every instance; existing access tokens stop working.")
+	return nil
+}
+
+func (cfg *apiConfig) parseUserArgs(ctx context.Context, flags *flag.FlagSet, args []string) (database.User, error) {
+	if err := flags.Parse(args); err != nil {
+		return database.User{}, err
+	}
+	if flags.NArg() != 1 {
+		return database.User{}, fmt.Errorf("expected one user, got %d arguments", flags.NArg())
+	}
+	return cfg.findUser(ctx, flags.Arg(0))
+}
+
+func (cfg *apiConfig) findUser(ctx context.Context, ref string) (database.User, error) {
+	var user database.User
+	var err error
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		user, err = cfg.db.GetUserByID(ctx, id)
+	} else if handle, ok := strings.CutPrefix(ref, "@"); ok {
+		user, err = cfg.db.GetUserByHandle(ctx, sql.NullString{String: strings.ToLower(handle), Valid: true})
+	} else {
+		user, err = cfg.db.GetUserByEmail(ctx, ref)
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("Could not find user %s: %w", ref, err)
+	}
+	return user, nil
+}
+
+func passwordOrGenerate(password string) (string, bool, error) {
+	if password != "" {
+		return password, false, nil
+	}
+	generated, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", false, err
+	}
+	return generated[:20], true, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/database/sqlite"
+	"github.com/FFB6C1/bootdev_webservers/internal/migrate"
+	"github.com/FFB6C1/bootdev_webservers/sql/schema"
+	sqliteschema "github.com/FFB6C1/bootdev_webservers/sql/sqlite/schema"
+)
+
+const (
+	defaultMaxOpenConns    = 25
+	defaultMaxIdleConns    = 10
+	defaultConnMaxLifetime = 30 * time.Minute
+	defaultConnMaxIdleTime = 5 * time.Minute
+
+	defaultDBStartupTimeout = time.Minute
+	dbRetryInitialDelay     = 500 * time.Millisecond
+	dbRetryMaxDelay         = 10 * time.Second
+	dbPingTimeout           = 5 * time.Second
+)
+
+// A database Chirpy can run on: its migrations, the schema version its
+// queries were generated against, and its database.Store.
+type backend struct {
+	name          string
+	dialect       migrate.Dialect
+	migrations    fs.FS
+	schemaVersion int64
+	newStore      func(db *sql.DB) database.Store
+}
+
+var (
+	postgresBackend = backend{
+		name:          "postgres",
+		dialect:       migrate.Postgres,
+		migrations:    schema.FS,
+		schemaVersion: database.SchemaVersion,
+		newStore:      database.NewStore,
+	}
+	sqliteBackend = backend{
+		name:          "sqlite",
+		dialect:       migrate.SQLite,
+		migrations:    sqliteschema.FS,
+		schemaVersion: sqlite.SchemaVersion,
+		newStore:      sqlite.NewStore,
+	}
+)
+
+const sqliteScheme = "sqlite:"
+
+// Opens the database with the pool sized from DB_MAX_OPEN_CONNS,
+// DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME.
+// sqlite:path/to/chirpy.db and sqlite:///absolute/path.db open a SQLite file;
+// anything else goes to Postgres, which also takes key=value strings.
+func openDatabase(dbURL string) (*sql.DB, backend, error) {
+	maxOpen, err := getEnvInt("DB_MAX_OPEN_CONNS", defaultMaxOpenConns)
+	if err != nil {
+		return nil, backend{}, err
+	}
+	maxIdle, err := getEnvInt("DB_MAX_IDLE_CONNS", defaultMaxIdleConns)
+	if err != nil {
+		return nil, backend{}, err
+	}
+	maxLifetime, err := getEnvDuration("DB_CONN_MAX_LIFETIME", defaultConnMaxLifetime)
+	if err != nil {
+		return nil, backend{}, err
+	}
+	maxIdleTime, err := getEnvDuration("DB_CONN_MAX_IDLE_TIME", defaultConnMaxIdleTime)
+	if err != nil {
+		return nil, backend{}, err
+	}
+
+	var db *sql.DB
+	chosen := postgresBackend
+	if path, ok := strings.CutPrefix(dbURL, sqliteScheme); ok {
+		db, err = sqlite.Open(strings.TrimPrefix(path, "//"))
+		chosen = sqliteBackend
+	} else {
+		db, err = sql.Open("postgres", dbURL)
+	}
+	if err != nil {
+		return nil, backend{}, err
+	}
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(maxLifetime)
+	db.SetConnMaxIdleTime(maxIdleTime)
+	return db, chosen, nil
+}
+
+// Pings with exponential backoff so the server can start alongside a database
+// that's still coming up, giving up after timeout.
+func waitForDatabase(db *sql.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	delay := dbRetryInitialDelay
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), dbPingTimeout)
+		err := db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("database not ready after %s: %w", timeout, err)
+		}
+		log.Printf("Database not ready, retrying in %s: %s", delay, err)
+		time.Sleep(delay)
+		delay = min(delay*2, dbRetryMaxDelay)
+	}
+}
+
+func getEnvInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("Could not parse %s: %w", name, err)
+	}
+	return parsed, nil
+}
+
+func getEnvDuration(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("Could not parse %s: %w", name, err)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/entities"
+	"github.com/google/uuid"
+)
+
+const defaultTrendingWindow = "24h"
+
+// Offsets are in runes, end exclusive.
+type chirpEntities struct {
+	Hashtags []hashtagEntity `json:"hashtags"`
+	Mentions []mentionEntity `json:"mentions"`
+	URLs     []urlEntity     `json:"urls"`
+}
+
+type hashtagEntity struct {
+	Tag   string `json:"tag"`
+	Start int32  `json:"start"`
+	End   int32  `json:"end"`
+}
+
+type mentionEntity struct {
+	Handle string    `json:"handle"`
+	UserId uuid.UUID `json:"user_id"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+type urlEntity struct {
+	URL   string `json:"url"`
+	Start int32  `json:"start"`
+	End   int32  `json:"end"`
+}
+
+type trendingHashtag struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+type trendingResponse struct {
+	Window   string            `json:"window"`
+	Hashtags []trendingHashtag `json:"hashtags"`
+}
+
+func (cfg *apiConfig) getHashtagChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	tag, err := entities.NormaliseHashtag(request.PathValue("tag"))
+	if err != nil {
+		handleError("Bad hashtag", err, 400, writer)
+		return
+	}
+
+	pageSize, err := getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+	beforeCreatedAt, beforeID, err := getCursor(request)
+	if err != nil {
+		handleError("Bad cursor", err, 400, writer)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsByHashtag(request.Context(), database.GetChirpsByHashtagParams{
+		Tag:             tag,
+		BeforeCreatedAt: beforeCreatedAt,
+		BeforeID:        beforeID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		handleError("Failed to get chirps", err, 500, writer)
+		return
+	}
+
+	responses, err := cfg.makeChirpResponses(chirps, request)
+	if err != nil {
+		handleError("Failed to make chirp responses", err, 500, writer)
+		return
+	}
+
+	response := timelineResponse{Chirps: responses}
+	if len(chirps) == int(pageSize) {
+		last := chirps[len(chirps)-1]
+		response.NextCursor = makeCursor(last.CreatedAt, last.ID)
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Failed to marshal chirps into json", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+// Trending counts the chirps using each tag in the window ending now, so the
+// ranking slides forward as old chirps drop out.
+func (cfg *apiConfig) getTrendingHashtagsHandler(writer http.ResponseWriter, request *http.Request) {
+	window := request.URL.Query().Get("window")
+	if window == "" {
+		window = defaultTrendingWindow
+	}
+	windowLength, ok := getTrendingWindows()[window]
+	if !ok {
+		handleError("window must be 1h, 24h or 7d", nil, 400, writer)
+		return
+	}
+
+	pageSize, err := getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+
+	trending, err := cfg.db.GetTrendingHashtags(request.Context(), database.GetTrendingHashtagsParams{
+		Since:    time.Now().UTC().Add(-windowLength),
+		PageSize: pageSize,
+	})
+	if err != nil {
+		handleError("Failed to get trending hashtags", err, 500, writer)
+		return
+	}
+
+	response := trendingResponse{Window: window, Hashtags: []trendingHashtag{}}
+	for _, hashtag := range trending {
+		response.Hashtags = append(response.Hashtags, trendingHashtag{
+			Tag:   hashtag.Tag,
+			Count: hashtag.ChirpCount,
+		})
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Failed to marshal trending hashtags into json", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+// Mentions of handles that don't belong to anyone are left as plain text.
+// Returns the IDs of the users who were mentioned.
+func (cfg *apiConfig) saveChirpEntities(ctx context.Context, queries database.Querier, chirp database.Chirp) ([]uuid.UUID, error) {
+	parsed := entities.Parse(chirp.Body)
+
+	handles := []string{}
+	for _, entity := range parsed {
+		if entity.Kind == entities.KindMention {
+			handles = append(handles, entity.Value)
+		}
+	}
+	mentioned := map[string]uuid.UUID{}
+	if len(handles) > 0 {
+		users, err := queries.GetUsersByHandles(ctx, handles)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			mentioned[user.Handle.String] = user.ID
+		}
+	}
+
+	mentionedIDs := []uuid.UUID{}
+	for _, user := range mentioned {
+		mentionedIDs = append(mentionedIDs, user)
+	}
+
+	for _, entity := range parsed {
+		entityParams := database.CreateChirpEntityParams{
+			ChirpID:     chirp.ID,
+			Kind:        entity.Kind,
+			Value:       entity.Value,
+			StartOffset: int32(entity.Start),
+			EndOffset:   int32(entity.End),
+			CreatedAt:   chirp.CreatedAt,
+		}
+		if entity.Kind == entities.KindMention {
+			userID, ok := mentioned[entity.Value]
+			if !ok {
+				continue
+			}
+			entityParams.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+		}
+		if err := queries.CreateChirpEntity(ctx, entityParams); err != nil {
+			return nil, err
+		}
+	}
+	return mentionedIDs, nil
+}
+
+func (cfg *apiConfig) getChirpEntities(ctx context.Context, chirps []database.Chirp) (map[uuid.UUID]chirpEntities, error) {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+
+	rows, err := cfg.db.GetChirpEntities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[uuid.UUID]chirpEntities{}
+	for _, row := range rows {
+		current := found[row.ChirpID]
+		switch row.Kind {
+		case entities.KindHashtag:
+			current.Hashtags = append(current.Hashtags, hashtagEntity{
+				Tag:   row.Value,
+				Start: row.StartOffset,
+				End:   row.EndOffset,
+			})
+		case entities.KindMention:
+			current.Mentions = append(current.Mentions, mentionEntity{
+				Handle: row.Value,
+				UserId: row.UserID.UUID,
+				Start:  row.StartOffset,
+				End:    row.EndOffset,
+			})
+		case entities.KindURL:
+			current.URLs = append(current.URLs, urlEntity{
+				URL:   row.Value,
+				Start: row.StartOffset,
+				End:   row.EndOffset,
+			})
+		}
+		found[row.ChirpID] = current
+	}
+	return found, nil
+}
+
+func getTrendingWindows() map[string]time.Duration {
+	return map[string]time.Duration{
+		"1h":  time.Hour,
+		"24h": 24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+	}
+}
+
+// Clients get empty lists rather than nulls for chirps with no entities.
+func makeChirpEntities(found chirpEntities) chirpEntities {
+	if found.Hashtags == nil {
+		found.Hashtags = []hashtagEntity{}
+	}
+	if found.Mentions == nil {
+		found.Mentions = []mentionEntity{}
+	}
+	if found.URLs == nil {
+		found.URLs = []urlEntity{}
+	}
+	return found
+}
@@ -1,21 +1,46 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/lib/pq"
 )
 
 func handleError(text string, err error, code int, writer http.ResponseWriter) {
+	if err != nil {
+		text = fmt.Sprintf("%s: %v", text, err)
+	}
+	writeErrorResponse(writer, code, errorResponse{Error: text})
+}
+
+func writeErrorResponse(writer http.ResponseWriter, code int, response errorResponse) {
+	responseJSON, _ := json.Marshal(response)
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(code)
-	if err == nil {
-		response := fmt.Sprintf(` {
-		"error": %s
-		}`, text)
-		writer.Write([]byte(response))
-		return
+	writer.Write(responseJSON)
+}
+
+// OAuth clients expect the RFC 6749 error shape rather than ours.
+func handleOAuthError(code string, err error, status int, writer http.ResponseWriter) {
+	response := map[string]string{"error": code}
+	if err != nil {
+		response["error_description"] = err.Error()
+	}
+	responseJSON, _ := json.Marshal(response)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(status)
+	writer.Write(responseJSON)
+}
+
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, database.ErrUniqueViolation) {
+		return true
 	}
-	response := fmt.Sprintf(` {
-	"error": %s: %v
-	}`, text, err)
-	writer.Write([]byte(response))
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
 }
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/pubsub"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// With the local broker events only reach clients streaming from this
+// instance. The postgres broker sends them through LISTEN/NOTIFY so every
+// instance's hub sees every event, in the same order.
+const (
+	eventBrokerLocal    = "local"
+	eventBrokerPostgres = "postgres"
+)
+
+const (
+	eventChirp        = "chirp"
+	eventChirpDeleted = "chirp_deleted"
+	eventNotification = "notification"
+)
+
+const eventChannel = "chirpy_events"
+const eventHistorySize = 1000
+const eventBufferSize = 64
+const streamHeartbeat = 15 * time.Second
+
+type eventPublisher interface {
+	publish(ctx context.Context, event pubsub.Event) error
+}
+
+type hubPublisher struct {
+	hub *pubsub.Hub
+}
+
+func (publisher hubPublisher) publish(ctx context.Context, event pubsub.Event) error {
+	publisher.hub.Deliver(event)
+	return nil
+}
+
+// This instance's hub gets the event back through listenForEvents like
+// everyone else's.
+type postgresPublisher struct {
+	db database.Querier
+}
+
+func (publisher postgresPublisher) publish(ctx context.Context, event pubsub.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return publisher.db.PublishEvent(ctx, database.PublishEventParams{
+		Channel: eventChannel,
+		Payload: string(payload),
+	})
+}
+
+type chirpDeletedEvent struct {
+	Id uuid.UUID `json:"id"`
+}
+
+// Events that fail to publish are logged rather than failing the request; the
+// change they describe has already been made.
+func (cfg *apiConfig) publishEvent(ctx context.Context, eventType string, userID uuid.UUID, data any) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Could not marshal %s event: %s", eventType, err)
+		return
+	}
+	event := pubsub.Event{
+		ID:     pubsub.NewEventID(),
+		Type:   eventType,
+		UserID: userID,
+		Data:   dataJSON,
+	}
+	if err := cfg.events.publish(ctx, event); err != nil {
+		log.Printf("Could not publish %s event: %s", eventType, err)
+	}
+}
+
+// Chirps go to everyone, so nothing about the poster's own view of them is
+// sent along.
+func (cfg *apiConfig) publishChirp(ctx context.Context, response chirpResponse) {
+	response.Author = nil
+	response.LikedByMe = false
+	if response.RechirpOf != nil {
+		original := *response.RechirpOf
+		original.Author = nil
+		original.LikedByMe = false
+		response.RechirpOf = &original
+	}
+	if response.QuotedChirp != nil {
+		quoted := *response.QuotedChirp
+		quoted.Author = nil
+		quoted.LikedByMe = false
+		response.QuotedChirp = &quoted
+	}
+	cfg.publishEvent(ctx, eventChirp, uuid.Nil, response)
+}
+
+// Anyone can stream new and deleted chirps. Our own login also gets its
+// notifications. Clients that reconnect with Last-Event-ID get what they
+// missed, or a reset event if it's too long ago to replay.
+func (cfg *apiConfig) streamHandler(writer http.ResponseWriter, request *http.Request) {
+	userID := uuid.Nil
+	if request.Header.Get("Authorization") != "" {
+		authorizedID, code, err := cfg.authorizeRequest(request, "")
+		if err != nil {
+			handleError("Could not validate token", err, code, writer)
+			return
+		}
+		userID = authorizedID
+	}
+
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		handleError("Streaming not supported", nil, 500, writer)
+		return
+	}
+
+	subscription, replay, ok := cfg.hub.Subscribe(userID, request.Header.Get("Last-Event-ID"))
+	defer cfg.hub.Unsubscribe(subscription)
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.WriteHeader(200)
+	fmt.Fprintf(writer, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+
+	if !ok {
+		fmt.Fprint(writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range replay {
+		writeStreamEvent(writer, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-cfg.shuttingDown:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(writer, ": heartbeat\n\n")
+			flusher.Flush()
+		case event, open := <-subscription.Events():
+			if !open {
+				return
+			}
+			if event.Ephemeral {
+				continue
+			}
+			writeStreamEvent(writer, event)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(writer http.ResponseWriter, event pubsub.Event) {
+	fmt.Fprintf(writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+// Feeds every event published through postgresPublisher, by any instance, into
+// this instance's hub.
+func listenForEvents(ctx context.Context, dbURL string, hub *pubsub.Hub) {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Event listener: %s", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(eventChannel); err != nil {
+		log.Printf("Could not listen for events: %s", err)
+		return
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			go listener.Ping()
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established and
+			// anything sent in between is lost.
+			if notification == nil {
+				continue
+			}
+			event := pubsub.Event{}
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				log.Printf("Could not decode event: %s", err)
+				continue
+			}
+			hub.Deliver(event)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/gorilla/websocket"
+)
+
+func TestStream(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream Content-Type = %q", response.Header.Get("Content-Type"))
+	}
+	lines := bufio.NewScanner(response.Body)
+	if !lines.Scan() || !strings.HasPrefix(lines.Text(), "retry: ") {
+		t.Fatalf("stream started with %q", lines.Text())
+	}
+
+	posted := server.postChirp(user.Token, chirp{Body: "streamed"})
+	for lines.Scan() {
+		if lines.Text() != "event: "+eventChirp {
+			continue
+		}
+		lines.Scan()
+		streamed := chirpResponse{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines.Text(), "data: ")), &streamed); err != nil {
+			t.Fatal(err)
+		}
+		if streamed.Id != posted.Id {
+			t.Fatalf("streamed chirp %s, want %s", streamed.Id, posted.Id)
+		}
+		return
+	}
+	t.Fatal("stream ended without the chirp:", lines.Err())
+}
+
+func TestWebsocket(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws"
+
+	if _, response, err := websocket.DefaultDialer.Dial(url, nil); err == nil || response.StatusCode != 401 {
+		t.Fatalf("dial without a token = %v", err)
+	}
+	scoped, err := auth.MakeScopedJWT(user.Id, testSecret, time.Hour, auth.ScopeChirpsRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, response, err := websocket.DefaultDialer.Dial(url+"?access_token="+scoped, nil); err == nil || response.StatusCode != 403 {
+		t.Fatalf("dial with an OAuth token = %v", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?access_token="+user.Token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(websocketClientMessage{Type: "subscribe", Topic: topicFeed}); err != nil {
+		t.Fatal(err)
+	}
+	reply := websocketServerMessage{}
+	if err := conn.ReadJSON(&reply); err != nil || reply.Type != "subscribed" {
+		t.Fatalf("subscribe reply = %+v, %v", reply, err)
+	}
+
+	posted := server.postChirp(user.Token, chirp{Body: "over the socket"})
+	for {
+		message := struct {
+			Type string        `json:"type"`
+			Data chirpResponse `json:"data"`
+		}{}
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatal("socket closed without the chirp:", err)
+		}
+		if message.Type != eventChirp {
+			continue
+		}
+		if message.Data.Id != posted.Id {
+			t.Fatalf("got chirp %s, want %s", message.Data.Id, posted.Id)
+		}
+		return
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+)
+
+const timelineBackfillSize = 200
+
+type followListResponse struct {
+	Count      int64         `json:"count"`
+	Users      []chirpAuthor `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) followHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	followee, err := cfg.getUserFromHandle(request)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	if followee.ID == userID {
+		handleError("Cannot follow yourself", nil, 400, writer)
+		return
+	}
+
+	followParams := database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followee.ID,
+	}
+	var added int64
+	err = cfg.store.WithTx(request.Context(), func(ctx context.Context, queries database.Querier) error {
+		added, err = queries.FollowUser(ctx, followParams)
+		if err != nil || added == 0 {
+			return err
+		}
+		return cfg.notifier.notify(ctx, queries, followEvent{
+			followerID: userID,
+			followeeID: followee.ID,
+		})
+	})
+	if err != nil {
+		handleError("Could not follow user", err, 500, writer)
+		return
+	}
+
+	if added > 0 && cfg.timelineStrategy == timelineFanOut {
+		backfillParams := database.BackfillTimelineParams{
+			UserID:       userID,
+			FolloweeID:   followee.ID,
+			BackfillSize: timelineBackfillSize,
+		}
+		if err := cfg.db.BackfillTimeline(request.Context(), backfillParams); err != nil {
+			log.Printf("Could not backfill timeline: %s", err)
+		}
+	}
+
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) unfollowHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	followee, err := cfg.getUserFromHandle(request)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	unfollowParams := database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followee.ID,
+	}
+	removed, err := cfg.db.UnfollowUser(request.Context(), unfollowParams)
+	if err != nil {
+		handleError("Could not unfollow user", err, 500, writer)
+		return
+	}
+	if removed == 0 {
+		handleError("Not following user", nil, 404, writer)
+		return
+	}
+
+	if cfg.timelineStrategy == timelineFanOut {
+		removeParams := database.RemoveFromTimelineParams{
+			UserID:     userID,
+			FolloweeID: followee.ID,
+		}
+		if err := cfg.db.RemoveFromTimeline(request.Context(), removeParams); err != nil {
+			log.Printf("Could not prune timeline: %s", err)
+		}
+	}
+
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) getFollowersHandler(writer http.ResponseWriter, request *http.Request) {
+	user, err := cfg.getUserFromHandle(request)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	pageSize, err := getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+	beforeCreatedAt, beforeID, err := getCursor(request)
+	if err != nil {
+		handleError("Bad cursor", err, 400, writer)
+		return
+	}
+
+	count, err := cfg.db.CountFollowers(request.Context(), user.ID)
+	if err != nil {
+		handleError("Could not count followers", err, 500, writer)
+		return
+	}
+
+	followersParams := database.GetFollowersParams{
+		FolloweeID:      user.ID,
+		BeforeCreatedAt: beforeCreatedAt,
+		BeforeID:        beforeID,
+		PageSize:        pageSize,
+	}
+	followers, err := cfg.db.GetFollowers(request.Context(), followersParams)
+	if err != nil {
+		handleError("Could not get followers", err, 500, writer)
+		return
+	}
+
+	response := followListResponse{Count: count, Users: []chirpAuthor{}}
+	for _, follower := range followers {
+		response.Users = append(response.Users, chirpAuthor{
+			Id:          follower.ID,
+			Handle:      follower.Handle.String,
+			DisplayName: follower.DisplayName,
+			AvatarURL:   follower.AvatarUrl,
+		})
+	}
+	if len(followers) == int(pageSize) {
+		last := followers[len(followers)-1]
+		response.NextCursor = makeCursor(last.CreatedAt, last.ID)
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+func (cfg *apiConfig) getFollowingHandler(writer http.ResponseWriter, request *http.Request) {
+	user, err := cfg.getUserFromHandle(request)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	pageSize, err := getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+	beforeCreatedAt, beforeID, err := getCursor(request)
+	if err != nil {
+		handleError("Bad cursor", err, 400, writer)
+		return
+	}
+
+	count, err := cfg.db.CountFollowing(request.Context(), user.ID)
+	if err != nil {
+		handleError("Could not count following", err, 500, writer)
+		return
+	}
+
+	followingParams := database.GetFollowingParams{
+		FollowerID:      user.ID,
+		BeforeCreatedAt: beforeCreatedAt,
+		BeforeID:        beforeID,
+		PageSize:        pageSize,
+	}
+	following, err := cfg.db.GetFollowing(request.Context(), followingParams)
+	if err != nil {
+		handleError("Could not get following", err, 500, writer)
+		return
+	}
+
+	response := followListResponse{Count: count, Users: []chirpAuthor{}}
+	for _, followee := range following {
+		response.Users = append(response.Users, chirpAuthor{
+			Id:          followee.ID,
+			Handle:      followee.Handle.String,
+			DisplayName: followee.DisplayName,
+			AvatarURL:   followee.AvatarUrl,
+		})
+	}
+	if len(following) == int(pageSize) {
+		last := following[len(following)-1]
+		response.NextCursor = makeCursor(last.CreatedAt, last.ID)
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const apiKeyTag = "chirpy"
+
+// Keys look like chirpy_<prefix>_<secret>. The prefix is stored in the clear
+// so keys can be found and recognised; only a hash of the whole key is kept.
+func MakeAPIKey() (string, string, error) {
+	prefixBytes := make([]byte, 4)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+	secretBytes := make([]byte, 24)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+	prefix := apiKeyTag + "_" + hex.EncodeToString(prefixBytes)
+	return prefix + "_" + hex.EncodeToString(secretBytes), prefix, nil
+}
+
+func APIKeyPrefix(key string) (string, error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("Malformed API key")
+	}
+	return parts[0] + "_" + parts[1], nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func CheckAPIKeyHash(key, hash string) error {
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) != 1 {
+		return fmt.Errorf("API key does not match")
+	}
+	return nil
+}
@@ -25,18 +25,30 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
+type chirpyClaims struct {
+	Scope string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return MakeScopedJWT(userID, tokenSecret, expiresIn, "")
+}
+
+func MakeScopedJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration, scope string) (string, error) {
 	issuedTime := jwt.NumericDate{
 		Time: time.Now(),
 	}
 	expiresTime := jwt.NumericDate{
 		Time: issuedTime.Time.Add(expiresIn),
 	}
-	claims := jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  &issuedTime,
-		ExpiresAt: &expiresTime,
-		Subject:   userID.String(),
+	claims := chirpyClaims{
+		Scope: scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			IssuedAt:  &issuedTime,
+			ExpiresAt: &expiresTime,
+			Subject:   userID.String(),
+		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(tokenSecret))
@@ -47,22 +59,28 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+	userUuid, _, err := ValidateScopedJWT(tokenString, tokenSecret)
+	return userUuid, err
+}
+
+// An empty scope means the token was issued to Chirpy itself and is unrestricted.
+func ValidateScopedJWT(tokenString, tokenSecret string) (uuid.UUID, string, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &chirpyClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(tokenSecret), nil
 	})
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
 	}
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*chirpyClaims)
 	if !ok {
-		return uuid.Nil, fmt.Errorf("unexpected claims type")
+		return uuid.Nil, "", fmt.Errorf("unexpected claims type")
 	}
 	userString := claims.Subject
 	userUuid, err := uuid.Parse(userString)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
 	}
-	return userUuid, nil
+	return userUuid, claims.Scope, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -2,7 +2,11 @@ package auth
 
 import (
 	"net/http"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 func TestGetPassword(t *testing.T) {
@@ -43,3 +47,80 @@ func TestMakeRefreshToken(t *testing.T) {
 		t.Fatal("Token wrong length")
 	}
 }
+
+func TestValidateTOTP(t *testing.T) {
+	// RFC 6238 test secret "12345678901234567890", truncated to 6 digits.
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	if step, err := ValidateTOTP("287082", secret, time.Unix(59, 0)); err != nil || step != 1 {
+		t.Fatal("Valid code rejected:", step, err)
+	}
+	if step, err := ValidateTOTP("287082", secret, time.Unix(59+totpPeriod, 0)); err != nil || step != 1 {
+		t.Fatal("Code from the last step rejected:", step, err)
+	}
+	if _, err := ValidateTOTP("287082", secret, time.Unix(59+totpPeriod*3, 0)); err == nil {
+		t.Fatal("Stale code accepted")
+	}
+	if _, err := ValidateTOTP("000000", secret, time.Unix(59, 0)); err == nil {
+		t.Fatal("Wrong code accepted")
+	}
+}
+
+func TestMakeRecoveryCodes(t *testing.T) {
+	codes, err := MakeRecoveryCodes(10)
+	if err != nil || len(codes) != 10 {
+		t.Fatal("Could not generate recovery codes")
+	}
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(" "+strings.ToUpper(codes[0])) {
+		t.Fatal("Recovery code hash not normalised")
+	}
+}
+
+func TestScopedJWT(t *testing.T) {
+	userID := uuid.New()
+	token, err := MakeScopedJWT(userID, "secret", time.Hour, "chirps:read profile")
+	if err != nil {
+		t.Fatal("Could not make token:", err)
+	}
+	gotID, scope, err := ValidateScopedJWT(token, "secret")
+	if err != nil || gotID != userID {
+		t.Fatal("Could not validate token:", err)
+	}
+	if !HasScope(scope, ScopeProfile) || HasScope(scope, ScopeChirpsWrite) {
+		t.Fatal("Wrong scope in token:", scope)
+	}
+	if _, err := ValidateJWT(token, "wrong"); err == nil {
+		t.Fatal("Token accepted with wrong secret")
+	}
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	// Example from RFC 7636 appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if err := VerifyPKCE(verifier, challenge, "S256"); err != nil {
+		t.Fatal("Valid verifier rejected:", err)
+	}
+	if err := VerifyPKCE(verifier, challenge, "plain"); err == nil {
+		t.Fatal("Plain method accepted")
+	}
+	if err := VerifyPKCE(verifier+"x", challenge, "S256"); err == nil {
+		t.Fatal("Wrong verifier accepted")
+	}
+}
+
+func TestMakeAPIKey(t *testing.T) {
+	key, prefix, err := MakeAPIKey()
+	if err != nil {
+		t.Fatal("Could not make API key:", err)
+	}
+	gotPrefix, err := APIKeyPrefix(key)
+	if err != nil || gotPrefix != prefix {
+		t.Fatal("Wrong prefix for key:", gotPrefix)
+	}
+	if err := CheckAPIKeyHash(key, HashAPIKey(key)); err != nil {
+		t.Fatal("Key does not match its hash")
+	}
+	if _, err := APIKeyPrefix("f271c81ff7084ee5b99a5091b42d486e"); err == nil {
+		t.Fatal("Malformed key accepted")
+	}
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	ScopeChirpsRead  = "chirps:read"
+	ScopeChirpsWrite = "chirps:write"
+	ScopeProfile     = "profile"
+)
+
+func ValidateScopes(scope string) error {
+	known := map[string]bool{
+		ScopeChirpsRead:  true,
+		ScopeChirpsWrite: true,
+		ScopeProfile:     true,
+	}
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return fmt.Errorf("No scope requested")
+	}
+	for _, s := range scopes {
+		if !known[s] {
+			return fmt.Errorf("Unknown scope: %s", s)
+		}
+	}
+	return nil
+}
+
+func HasScope(scope, required string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == required {
+			return true
+		}
+	}
+	return false
+}
+
+// Only S256 is supported; the plain method offers no protection if the
+// challenge leaks.
+func VerifyPKCE(verifier, challenge, method string) error {
+	if method != "S256" {
+		return fmt.Errorf("Unsupported code challenge method")
+	}
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return fmt.Errorf("Bad code verifier length")
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) != 1 {
+		return fmt.Errorf("Code verifier does not match")
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
@@ -17,25 +18,89 @@ INSERT INTO chirps (
     created_at,
     updated_at,
     body,
-    user_id
+    user_id,
+    reply_to_id,
+    quote_of_id
 )
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, like_count, reply_to_id, deleted_at, rechirp_of_id, quote_of_id, rechirp_count, search_vector
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	ReplyToID uuid.NullUUID
+	QuoteOfID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ReplyToID,
+		arg.QuoteOfID,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.LikeCount,
+		&i.ReplyToID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+WITH inserted AS (
+    INSERT INTO chirps (
+        id,
+        created_at,
+        updated_at,
+        body,
+        user_id,
+        rechirp_of_id
+    )
+    VALUES (
+        gen_random_uuid(),
+        NOW(),
+        NOW(),
+        '',
+        $1,
+        $2
+    )
+    ON CONFLICT DO NOTHING
+    RETURNING id, created_at, updated_at, body, user_id, like_count, reply_to_id, deleted_at, rechirp_of_id, quote_of_id, rechirp_count, search_vector
+), counted AS (
+    UPDATE chirps
+    SET rechirp_count = rechirp_count + (SELECT COUNT(*) FROM inserted)
+    WHERE chirps.id = $2
+)
+SELECT id, created_at, updated_at, body, user_id, like_count, reply_to_id, deleted_at, rechirp_of_id, quote_of_id, rechirp_count, search_vector FROM inserted
+`
+
+type CreateRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RechirpOfID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -43,6 +108,13 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.LikeCount,
+		&i.ReplyToID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.SearchVector,
 	)
 	return i, err
 }
@@ -57,8 +129,42 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :execrows
+WITH deleted AS (
+    DELETE FROM chirps
+    WHERE chirps.user_id = $1 AND chirps.rechirp_of_id = $2
+    RETURNING chirps.id
+)
+UPDATE chirps
+SET rechirp_count = rechirp_count - (SELECT COUNT(*) FROM deleted)
+WHERE chirps.id = $2 AND EXISTS (SELECT 1 FROM deleted)
+`
+
+type DeleteRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOfID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRechirpsOf = `-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of_id = $1
+`
+
+func (q *Queries) DeleteRechirpsOf(ctx context.Context, rechirpOfID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirpsOf, rechirpOfID)
+	return err
+}
+
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, like_count, reply_to_id, deleted_at, rechirp_of_id, quote_of_id, rechirp_count, search_vector FROM chirps
 WHERE id = $1
 `
 
@@ -71,12 +177,20 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.LikeCount,
+		&i.ReplyToID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.RechirpCount,
+		&i.SearchVector,
 	)
 	return i, err
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, like_count, reply_to_id, deleted_at, rechirp_of_id, quote_of_id, rechirp_count, search_vector FROM chirps
+WHERE deleted_at IS NULL
 ORDER BY created_at
 `
 
@@ -95,6 +209,96 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByPopularity = `-- name: GetChirpsByPopularity :many
+SELECT id, created_at, updated_at, body, user_id, like_count, reply_to_id, deleted_at, rechirp_of_id, quote_of_id, rechirp_count, search_vector FROM chirps
+WHERE deleted_at IS NULL
+ORDER BY like_count DESC, created_at DESC
+`
+
+func (q *Queries) GetChirpsByPopularity(ctx context.Context) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByPopularity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, like_count, reply_to_id, deleted_at, rechirp_of_id, quote_of_id, rechirp_count, search_vector FROM chirps
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.SearchVector,
 		); err != nil {
 			return nil, err
 		}
@@ -110,8 +314,9 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByUser = `-- name: GetChirpsByUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
+SELECT id, created_at, updated_at, body, user_id, like_count, reply_to_id, deleted_at, rechirp_of_id, quote_of_id, rechirp_count, search_vector FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL
+ORDER BY created_at
 `
 
 func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
@@ -129,6 +334,13 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chir
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.RechirpCount,
+			&i.SearchVector,
 		); err != nil {
 			return nil, err
 		}
@@ -143,6 +355,20 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chir
 	return items, nil
 }
 
+const hasReferences = `-- name: HasReferences :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE reply_to_id = $1 OR quote_of_id = $1
+)
+`
+
+func (q *Queries) HasReferences(ctx context.Context, chirpID uuid.NullUUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasReferences, chirpID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const resetChirps = `-- name: ResetChirps :exec
 DELETE FROM chirps
 `
@@ -151,3 +377,14 @@ func (q *Queries) ResetChirps(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetChirps)
 	return err
 }
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
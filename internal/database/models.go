@@ -11,12 +11,120 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scope      string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
 type Chirp struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Body         string
+	UserID       uuid.UUID
+	LikeCount    int32
+	ReplyToID    uuid.NullUUID
+	DeletedAt    sql.NullTime
+	RechirpOfID  uuid.NullUUID
+	QuoteOfID    uuid.NullUUID
+	RechirpCount int32
+	SearchVector interface{}
+}
+
+type ChirpAttachment struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type ChirpEntity struct {
+	ChirpID     uuid.UUID
+	Kind        string
+	Value       string
+	UserID      uuid.NullUUID
+	StartOffset int32
+	EndOffset   int32
+	CreatedAt   time.Time
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type MediaFile struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	BlobKey     string
+	ContentType string
+	SizeBytes   int32
+	Width       int32
+	Height      int32
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Type      string
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+}
+
+type NotificationPreference struct {
+	UserID  uuid.UUID
+	Type    string
+	Enabled bool
+}
+
+type OauthAuthorizationCode struct {
+	Code                string
+	CreatedAt           time.Time
+	ClientID            uuid.UUID
+	UserID              uuid.UUID
+	RedirectUri         string
+	Scope               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+	UsedAt              sql.NullTime
+}
+
+type OauthClient struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	OwnerID      uuid.UUID
+	Name         string
+	SecretHash   sql.NullString
+	RedirectUris string
+	Scope        string
+}
+
+type RecoveryCode struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
 	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
 }
 
 type RefreshToken struct {
@@ -26,6 +134,23 @@ type RefreshToken struct {
 	UserID    uuid.UUID
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
+	ClientID  uuid.NullUUID
+	Scope     sql.NullString
+}
+
+type TimelineEntry struct {
+	UserID         uuid.UUID
+	ChirpID        uuid.UUID
+	ChirpCreatedAt time.Time
+}
+
+type TwoFactorChallenge struct {
+	Token     string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	Attempts  int32
 }
 
 type User struct {
@@ -35,4 +160,12 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	TotpSecret     sql.NullString
+	TotpEnabled    bool
+	DeletedAt      sql.NullTime
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
+	TotpLastStep   sql.NullInt64
 }
@@ -7,11 +7,52 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const addOAuthRefreshToken = `-- name: AddOAuthRefreshToken :exec
+INSERT INTO refresh_tokens (
+    token,
+    created_at,
+    updated_at,
+    user_id,
+    expires_at,
+    client_id,
+    scope
+)
+VALUES (
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5
+)
+`
+
+type AddOAuthRefreshTokenParams struct {
+	Token     string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	ClientID  uuid.NullUUID
+	Scope     sql.NullString
+}
+
+func (q *Queries) AddOAuthRefreshToken(ctx context.Context, arg AddOAuthRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, addOAuthRefreshToken,
+		arg.Token,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.ClientID,
+		arg.Scope,
+	)
+	return err
+}
+
 const addRefreshToken = `-- name: AddRefreshToken :exec
 INSERT INTO refresh_tokens (
     token,
@@ -41,7 +82,7 @@ func (q *Queries) AddRefreshToken(ctx context.Context, arg AddRefreshTokenParams
 }
 
 const getToken = `-- name: GetToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at FROM refresh_tokens
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, client_id, scope FROM refresh_tokens
 WHERE token = $1
 `
 
@@ -55,10 +96,50 @@ func (q *Queries) GetToken(ctx context.Context, token string) (RefreshToken, err
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.ClientID,
+		&i.Scope,
 	)
 	return i, err
 }
 
+const getTokensByUser = `-- name: GetTokensByUser :many
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, client_id, scope FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) GetTokensByUser(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error) {
+	rows, err := q.db.QueryContext(ctx, getTokensByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefreshToken
+	for rows.Next() {
+		var i RefreshToken
+		if err := rows.Scan(
+			&i.Token,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.ClientID,
+			&i.Scope,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const revokeToken = `-- name: RevokeToken :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
@@ -69,3 +150,14 @@ func (q *Queries) RevokeToken(ctx context.Context, token string) error {
 	_, err := q.db.ExecContext(ctx, revokeToken, token)
 	return err
 }
+
+const revokeUserTokens = `-- name: RevokeUserTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserTokens, userID)
+	return err
+}
@@ -0,0 +1,83 @@
+// Package sqlite is the SQLite counterpart of package database, for
+// development and small self-hosted deployments that don't run Postgres. sqlc
+// generates it from the schema and queries in sql/sqlite, and NewStore wraps
+// it as a database.Store.
+//
+// Full-text search uses FTS5 with the porter stemmer, so it matches much the
+// same chirps as Postgres's english configuration but ranks them with bm25.
+// Events can't be published through the database; PublishEvent always fails.
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+	modernc "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// The migration in sql/sqlite/schema that the queries in this package were
+// generated against. Bump it alongside every new migration.
+const SchemaVersion int64 = 2
+
+// How timestamps are stored: the format the driver writes time.Time
+// parameters in with _time_format=sqlite. Every time is converted to UTC
+// first, so comparing the text compares the times.
+const timeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// The queries keep Postgres's gen_random_uuid() and NOW().
+func init() {
+	modernc.MustRegisterScalarFunction("gen_random_uuid", 0, func(*modernc.FunctionContext, []driver.Value) (driver.Value, error) {
+		return uuid.NewString(), nil
+	})
+	modernc.MustRegisterScalarFunction("now", 0, func(*modernc.FunctionContext, []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Truncate(time.Microsecond).Format(timeFormat), nil
+	})
+}
+
+// Open opens the database file at path, which may carry the driver's own
+// query parameters such as ?_pragma=cache_size(-20000). Foreign keys are
+// turned on, the journal is switched to WAL so reads don't wait on writes,
+// and transactions take the write lock when they begin so that two of them
+// never deadlock upgrading to it.
+func Open(path string) (*sql.DB, error) {
+	name, query, _ := strings.Cut(path, "?")
+	if name == "" {
+		return nil, fmt.Errorf("no SQLite database file given")
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse SQLite parameters: %w", err)
+	}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(wal)")
+	params.Set("_time_format", "sqlite")
+	params.Set("_txlock", "immediate")
+	return sql.Open("sqlite", name+"?"+params.Encode())
+}
+
+// Turns SQLite's constraint errors into the ones database.Store documents,
+// keeping SQLite's message.
+func translateError(err error) error {
+	var sqliteErr *modernc.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return fmt.Errorf("%w: %w", database.ErrUniqueViolation, err)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return fmt.Errorf("%w: %w", database.ErrForeignKeyViolation, err)
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return fmt.Errorf("%w: %w", database.ErrCheckViolation, err)
+	}
+	return err
+}
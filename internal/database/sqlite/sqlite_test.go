@@ -0,0 +1,201 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/migrate"
+	"github.com/FFB6C1/bootdev_webservers/sql/sqlite/schema"
+	"github.com/google/uuid"
+)
+
+func newTestStore(t *testing.T) database.Store {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, migrate.SQLite, schema.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal("Could not migrate:", err)
+	}
+	return NewStore(db)
+}
+
+func newTestUser(t *testing.T, store database.Store, email string) database.User {
+	t.Helper()
+	user, err := store.CreateUser(context.Background(), database.CreateUserParams{Email: email, HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser(%q): %v", email, err)
+	}
+	return user
+}
+
+func TestConstraintErrors(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	newTestUser(t, store, "a@example.com")
+
+	if _, err := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"}); !errors.Is(err, database.ErrUniqueViolation) {
+		t.Fatalf("duplicate CreateUser = %v, want a unique violation", err)
+	}
+	if _, err := store.CreateChirp(ctx, database.CreateChirpParams{Body: "hi", UserID: uuid.New()}); !errors.Is(err, database.ErrForeignKeyViolation) {
+		t.Fatalf("CreateChirp for a missing user = %v, want a foreign key violation", err)
+	}
+}
+
+func TestDefaultsAndCounts(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	author := newTestUser(t, store, "author@example.com")
+	reader := newTestUser(t, store, "reader@example.com")
+	if author.ID == uuid.Nil || time.Since(author.CreatedAt) > time.Minute {
+		t.Fatalf("CreateUser = %+v, want a generated ID and the current time", author)
+	}
+
+	chirp, err := store.CreateChirp(ctx, database.CreateChirpParams{Body: "hello", UserID: author.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := store.LikeChirp(ctx, database.LikeChirpParams{UserID: reader.ID, ChirpID: chirp.ID})
+	if err != nil || count != 1 {
+		t.Fatalf("LikeChirp = %d, %v", count, err)
+	}
+	rechirp := uuid.NullUUID{UUID: chirp.ID, Valid: true}
+	if _, err := store.CreateRechirp(ctx, database.CreateRechirpParams{UserID: reader.ID, RechirpOfID: rechirp}); err != nil {
+		t.Fatal(err)
+	}
+	found, err := store.GetChirpById(ctx, chirp.ID)
+	if err != nil || found.LikeCount != 1 || found.RechirpCount != 1 {
+		t.Fatalf("GetChirpById = %+v, %v", found, err)
+	}
+	if count, err := store.UnlikeChirp(ctx, database.UnlikeChirpParams{UserID: reader.ID, ChirpID: chirp.ID}); err != nil || count != 0 {
+		t.Fatalf("UnlikeChirp = %d, %v", count, err)
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	failed := errors.New("failed")
+	ran := false
+
+	err := store.WithTx(ctx, func(ctx context.Context, queries database.Querier) error {
+		database.AfterCommit(ctx, func() { ran = true })
+		if _, err := queries.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) || ran {
+		t.Fatalf("WithTx = %v, hook ran = %t", err, ran)
+	}
+	if _, err := store.GetUserByEmail(ctx, "a@example.com"); err == nil {
+		t.Fatal("user created in a rolled back transaction exists")
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, queries database.Querier) error {
+		database.AfterCommit(ctx, func() { ran = true })
+		_, err := queries.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+		return err
+	})
+	if err != nil || !ran {
+		t.Fatalf("WithTx = %v, hook ran = %t", err, ran)
+	}
+}
+
+// Follows made together can share created_at, and paging mustn't skip or
+// repeat any of them.
+func TestFollowersPageThroughTies(t *testing.T) {
+	ctx := context.Background()
+	testStore := newTestStore(t)
+	followee := newTestUser(t, testStore, "followee@example.com")
+	want := map[uuid.UUID]bool{}
+	for i := range 3 {
+		follower := newTestUser(t, testStore, fmt.Sprintf("follower%d@example.com", i))
+		if _, err := testStore.FollowUser(ctx, database.FollowUserParams{FollowerID: follower.ID, FolloweeID: followee.ID}); err != nil {
+			t.Fatal("Could not follow:", err)
+		}
+		want[follower.ID] = true
+	}
+	if _, err := testStore.(store).db.ExecContext(ctx, "UPDATE follows SET created_at = (SELECT MIN(created_at) FROM follows)"); err != nil {
+		t.Fatal(err)
+	}
+
+	params := database.GetFollowersParams{
+		FolloweeID:      followee.ID,
+		BeforeCreatedAt: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC),
+		BeforeID:        uuid.Max,
+		PageSize:        1,
+	}
+	got := map[uuid.UUID]bool{}
+	for range len(want) + 1 {
+		page, err := testStore.GetFollowers(ctx, params)
+		if err != nil {
+			t.Fatal("Could not get followers:", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		if got[page[0].ID] {
+			t.Fatalf("follower %s returned twice", page[0].ID)
+		}
+		got[page[0].ID] = true
+		params.BeforeCreatedAt, params.BeforeID = page[0].CreatedAt, page[0].ID
+	}
+	if len(got) != len(want) {
+		t.Fatalf("paged through %d followers, want %d", len(got), len(want))
+	}
+}
+
+func TestSearchChirps(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	author := newTestUser(t, store, "author@example.com")
+	for _, body := range []string{"running with scissors", "scissors are sharp", "a quiet day"} {
+		if _, err := store.CreateChirp(ctx, database.CreateChirpParams{Body: body, UserID: author.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for query, want := range map[string]int{
+		"scissors":               2,
+		"run":                    1,
+		"scissors & !sharp":      1,
+		"(with <-> scissors)":    1,
+		"qui:*":                  1,
+		"(scissors <-> running)": 0,
+	} {
+		rows, err := store.SearchChirps(ctx, database.SearchChirpsParams{
+			Query:    query,
+			Until:    time.Now().Add(time.Hour),
+			PageSize: 10,
+		})
+		if err != nil || len(rows) != want {
+			t.Errorf("SearchChirps(%q) = %d rows, %v; want %d", query, len(rows), err, want)
+		}
+	}
+}
+
+func TestFTSQuery(t *testing.T) {
+	for tsquery, want := range map[string]string{
+		"cat":                      `"cat"`,
+		"cat & !dog":               `"cat" NOT "dog"`,
+		"(big <-> cat) & hous:*":   `"big cat" AND "hous"*`,
+		`say & "hi"`:               `"say" AND """hi"""`,
+		"!dog & cat & (no <-> go)": `"cat" AND "no go" NOT "dog"`,
+	} {
+		if got := ftsQuery(tsquery); got != want {
+			t.Errorf("ftsQuery(%q) = %s, want %s", tsquery, got, want)
+		}
+	}
+}
@@ -0,0 +1,690 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+var errNoEvents = errors.New("publishing events needs Postgres, set EVENT_BROKER=local")
+
+type store struct {
+	queries
+	db *sql.DB
+}
+
+// queries is a database.Querier over the generated Queries, converting
+// between the two packages' types.
+type queries struct {
+	q *Queries
+}
+
+var _ database.Store = store{}
+
+func NewStore(db *sql.DB) database.Store {
+	return store{queries: queries{q: New(utcDB{db})}, db: db}
+}
+
+func (s store) WithTx(ctx context.Context, fn func(ctx context.Context, queries database.Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ctx, runHooks := database.TrackAfterCommit(ctx)
+	if err := fn(ctx, queries{q: New(utcDB{tx})}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	runHooks()
+	return nil
+}
+
+// utcDB converts time parameters to UTC before the driver formats them, so
+// they compare correctly with the stored text.
+type utcDB struct {
+	DBTX
+}
+
+func (db utcDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DBTX.ExecContext(ctx, query, inUTC(args)...)
+}
+
+func (db utcDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DBTX.QueryContext(ctx, query, inUTC(args)...)
+}
+
+func (db utcDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.DBTX.QueryRowContext(ctx, query, inUTC(args)...)
+}
+
+func inUTC(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case time.Time:
+			converted[i] = arg.UTC()
+		case sql.NullTime:
+			converted[i] = sql.NullTime{Time: arg.Time.UTC(), Valid: arg.Valid}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
+
+// ftsQuery rewrites the to_tsquery expression that search.ParseQuery writes
+// in FTS5's syntax. Each clause becomes a quoted phrase, and the negated ones
+// go after NOT since FTS5 has no unary not.
+func ftsQuery(tsquery string) string {
+	var included, excluded []string
+	for _, clause := range strings.Split(tsquery, "&") {
+		clause = strings.TrimSpace(clause)
+		negated := strings.HasPrefix(clause, "!")
+		clause = strings.Trim(strings.TrimPrefix(clause, "!"), "()")
+
+		words := []string{}
+		prefix := false
+		for _, word := range strings.Split(clause, "<->") {
+			word, prefix = strings.CutSuffix(strings.TrimSpace(word), ":*")
+			if word != "" {
+				words = append(words, word)
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+		phrase := `"` + strings.ReplaceAll(strings.Join(words, " "), `"`, `""`) + `"`
+		if prefix {
+			phrase += "*"
+		}
+		if negated {
+			excluded = append(excluded, phrase)
+		} else {
+			included = append(included, phrase)
+		}
+	}
+
+	query := strings.Join(included, " AND ")
+	for _, phrase := range excluded {
+		query += " NOT " + phrase
+	}
+	return query
+}
+
+func convertAll[T, R any](items []T, convert func(T) R) []R {
+	var converted []R
+	for _, item := range items {
+		converted = append(converted, convert(item))
+	}
+	return converted
+}
+
+func chirp(c Chirp) database.Chirp {
+	return database.Chirp{
+		ID:           c.ID,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+		Body:         c.Body,
+		UserID:       c.UserID,
+		LikeCount:    c.LikeCount,
+		ReplyToID:    c.ReplyToID,
+		DeletedAt:    c.DeletedAt,
+		RechirpOfID:  c.RechirpOfID,
+		QuoteOfID:    c.QuoteOfID,
+		RechirpCount: c.RechirpCount,
+	}
+}
+
+func chirps(items []Chirp, err error) ([]database.Chirp, error) {
+	return convertAll(items, chirp), translateError(err)
+}
+
+func user(u User) database.User {
+	return database.User(u)
+}
+
+func users(items []User, err error) ([]database.User, error) {
+	return convertAll(items, user), translateError(err)
+}
+
+func mediaFile(m MediaFile) database.MediaFile {
+	return database.MediaFile(m)
+}
+
+func (s queries) AddOAuthCode(ctx context.Context, arg database.AddOAuthCodeParams) error {
+	return translateError(s.q.AddOAuthCode(ctx, AddOAuthCodeParams(arg)))
+}
+
+func (s queries) AddOAuthRefreshToken(ctx context.Context, arg database.AddOAuthRefreshTokenParams) error {
+	return translateError(s.q.AddOAuthRefreshToken(ctx, AddOAuthRefreshTokenParams(arg)))
+}
+
+func (s queries) AddRecoveryCode(ctx context.Context, arg database.AddRecoveryCodeParams) error {
+	return translateError(s.q.AddRecoveryCode(ctx, AddRecoveryCodeParams(arg)))
+}
+
+func (s queries) AddRefreshToken(ctx context.Context, arg database.AddRefreshTokenParams) error {
+	return translateError(s.q.AddRefreshToken(ctx, AddRefreshTokenParams(arg)))
+}
+
+func (s queries) AddTwoFactorChallenge(ctx context.Context, arg database.AddTwoFactorChallengeParams) error {
+	return translateError(s.q.AddTwoFactorChallenge(ctx, AddTwoFactorChallengeParams(arg)))
+}
+
+func (s queries) AttachMediaFile(ctx context.Context, arg database.AttachMediaFileParams) error {
+	return translateError(s.q.AttachMediaFile(ctx, AttachMediaFileParams(arg)))
+}
+
+func (s queries) AttemptTwoFactorChallenge(ctx context.Context, arg database.AttemptTwoFactorChallengeParams) (int64, error) {
+	return s.q.AttemptTwoFactorChallenge(ctx, AttemptTwoFactorChallengeParams(arg))
+}
+
+func (s queries) BackfillTimeline(ctx context.Context, arg database.BackfillTimelineParams) error {
+	return translateError(s.q.BackfillTimeline(ctx, BackfillTimelineParams(arg)))
+}
+
+func (s queries) CountFollowers(ctx context.Context, followeeID uuid.UUID) (int64, error) {
+	count, err := s.q.CountFollowers(ctx, followeeID)
+	return int64(count), err
+}
+
+func (s queries) CountFollowing(ctx context.Context, followerID uuid.UUID) (int64, error) {
+	count, err := s.q.CountFollowing(ctx, followerID)
+	return int64(count), err
+}
+
+func (s queries) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := s.q.CountUnreadNotifications(ctx, userID)
+	return int64(count), err
+}
+
+func (s queries) CreateAPIKey(ctx context.Context, arg database.CreateAPIKeyParams) (database.ApiKey, error) {
+	key, err := s.q.CreateAPIKey(ctx, CreateAPIKeyParams(arg))
+	return database.ApiKey(key), translateError(err)
+}
+
+func (s queries) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	created, err := s.q.CreateChirp(ctx, CreateChirpParams(arg))
+	return chirp(created), translateError(err)
+}
+
+func (s queries) CreateChirpEntity(ctx context.Context, arg database.CreateChirpEntityParams) error {
+	return translateError(s.q.CreateChirpEntity(ctx, CreateChirpEntityParams(arg)))
+}
+
+func (s queries) CreateMediaFile(ctx context.Context, arg database.CreateMediaFileParams) (database.MediaFile, error) {
+	created, err := s.q.CreateMediaFile(ctx, CreateMediaFileParams(arg))
+	return mediaFile(created), translateError(err)
+}
+
+func (s queries) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) ([]database.Notification, error) {
+	created, err := s.q.CreateNotification(ctx, CreateNotificationParams(arg))
+	return convertAll(created, func(n Notification) database.Notification {
+		return database.Notification(n)
+	}), translateError(err)
+}
+
+func (s queries) CreateOAuthClient(ctx context.Context, arg database.CreateOAuthClientParams) (database.OauthClient, error) {
+	client, err := s.q.CreateOAuthClient(ctx, CreateOAuthClientParams(arg))
+	return database.OauthClient(client), translateError(err)
+}
+
+func (s queries) CreateRechirp(ctx context.Context, arg database.CreateRechirpParams) (database.Chirp, error) {
+	created, err := s.q.CreateRechirp(ctx, CreateRechirpParams(arg))
+	return chirp(created), translateError(err)
+}
+
+func (s queries) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	created, err := s.q.CreateUser(ctx, CreateUserParams(arg))
+	return user(created), translateError(err)
+}
+
+func (s queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteChirp(ctx, id)
+}
+
+func (s queries) DeleteChirpEntities(ctx context.Context, chirpID uuid.UUID) error {
+	return s.q.DeleteChirpEntities(ctx, chirpID)
+}
+
+func (s queries) DeleteMediaFile(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteMediaFile(ctx, id)
+}
+
+func (s queries) DeleteOAuthClient(ctx context.Context, arg database.DeleteOAuthClientParams) (int64, error) {
+	return s.q.DeleteOAuthClient(ctx, DeleteOAuthClientParams(arg))
+}
+
+func (s queries) DeleteRechirp(ctx context.Context, arg database.DeleteRechirpParams) (int64, error) {
+	return s.q.DeleteRechirp(ctx, DeleteRechirpParams(arg))
+}
+
+func (s queries) DeleteRechirpsOf(ctx context.Context, rechirpOfID uuid.NullUUID) error {
+	return s.q.DeleteRechirpsOf(ctx, rechirpOfID)
+}
+
+func (s queries) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	return s.q.DeleteRecoveryCodes(ctx, userID)
+}
+
+func (s queries) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	return s.q.DeleteUser(ctx, id)
+}
+
+func (s queries) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	return s.q.DisableTOTP(ctx, id)
+}
+
+func (s queries) EnableTOTP(ctx context.Context, id uuid.UUID) error {
+	return s.q.EnableTOTP(ctx, id)
+}
+
+func (s queries) FanOutChirp(ctx context.Context, arg database.FanOutChirpParams) error {
+	return translateError(s.q.FanOutChirp(ctx, FanOutChirpParams(arg)))
+}
+
+func (s queries) FollowUser(ctx context.Context, arg database.FollowUserParams) (int64, error) {
+	followed, err := s.q.FollowUser(ctx, FollowUserParams(arg))
+	return followed, translateError(err)
+}
+
+func (s queries) GetAPIKeyByPrefix(ctx context.Context, prefix string) (database.ApiKey, error) {
+	key, err := s.q.GetAPIKeyByPrefix(ctx, prefix)
+	return database.ApiKey(key), err
+}
+
+func (s queries) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]database.ApiKey, error) {
+	keys, err := s.q.GetAPIKeysByUser(ctx, userID)
+	return convertAll(keys, func(key ApiKey) database.ApiKey {
+		return database.ApiKey(key)
+	}), err
+}
+
+func (s queries) GetChirpAttachments(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetChirpAttachmentsRow, error) {
+	rows, err := s.q.GetChirpAttachments(ctx, chirpIds)
+	return convertAll(rows, func(row GetChirpAttachmentsRow) database.GetChirpAttachmentsRow {
+		return database.GetChirpAttachmentsRow{ChirpID: row.ChirpID, MediaFile: mediaFile(row.MediaFile)}
+	}), err
+}
+
+func (s queries) GetChirpById(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	found, err := s.q.GetChirpById(ctx, id)
+	return chirp(found), err
+}
+
+func (s queries) GetChirpEntities(ctx context.Context, chirpIds []uuid.UUID) ([]database.ChirpEntity, error) {
+	entities, err := s.q.GetChirpEntities(ctx, chirpIds)
+	return convertAll(entities, func(entity ChirpEntity) database.ChirpEntity {
+		return database.ChirpEntity(entity)
+	}), err
+}
+
+func (s queries) GetChirps(ctx context.Context) ([]database.Chirp, error) {
+	return chirps(s.q.GetChirps(ctx))
+}
+
+func (s queries) GetChirpsByHashtag(ctx context.Context, arg database.GetChirpsByHashtagParams) ([]database.Chirp, error) {
+	return chirps(s.q.GetChirpsByHashtag(ctx, GetChirpsByHashtagParams{
+		Tag:             arg.Tag,
+		BeforeCreatedAt: arg.BeforeCreatedAt,
+		BeforeID:        arg.BeforeID.String(),
+		PageSize:        arg.PageSize,
+	}))
+}
+
+func (s queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.Chirp, error) {
+	return chirps(s.q.GetChirpsByIDs(ctx, ids))
+}
+
+func (s queries) GetChirpsByPopularity(ctx context.Context) ([]database.Chirp, error) {
+	return chirps(s.q.GetChirpsByPopularity(ctx))
+}
+
+func (s queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	return chirps(s.q.GetChirpsByUser(ctx, userID))
+}
+
+func (s queries) GetCollectableMediaFiles(ctx context.Context, arg database.GetCollectableMediaFilesParams) ([]database.MediaFile, error) {
+	found, err := s.q.GetCollectableMediaFiles(ctx, GetCollectableMediaFilesParams(arg))
+	return convertAll(found, mediaFile), err
+}
+
+func (s queries) GetFollowers(ctx context.Context, arg database.GetFollowersParams) ([]database.GetFollowersRow, error) {
+	rows, err := s.q.GetFollowers(ctx, GetFollowersParams{
+		FolloweeID:      arg.FolloweeID,
+		BeforeCreatedAt: arg.BeforeCreatedAt,
+		BeforeID:        arg.BeforeID.String(),
+		PageSize:        arg.PageSize,
+	})
+	return convertAll(rows, func(row GetFollowersRow) database.GetFollowersRow {
+		return database.GetFollowersRow(row)
+	}), err
+}
+
+func (s queries) GetFollowing(ctx context.Context, arg database.GetFollowingParams) ([]database.GetFollowingRow, error) {
+	rows, err := s.q.GetFollowing(ctx, GetFollowingParams{
+		FollowerID:      arg.FollowerID,
+		BeforeCreatedAt: arg.BeforeCreatedAt,
+		BeforeID:        arg.BeforeID.String(),
+		PageSize:        arg.PageSize,
+	})
+	return convertAll(rows, func(row GetFollowingRow) database.GetFollowingRow {
+		return database.GetFollowingRow(row)
+	}), err
+}
+
+func (s queries) GetLikedChirpIDs(ctx context.Context, arg database.GetLikedChirpIDsParams) ([]uuid.UUID, error) {
+	return s.q.GetLikedChirpIDs(ctx, GetLikedChirpIDsParams(arg))
+}
+
+func (s queries) GetMediaFileByID(ctx context.Context, id uuid.UUID) (database.MediaFile, error) {
+	found, err := s.q.GetMediaFileByID(ctx, id)
+	return mediaFile(found), err
+}
+
+func (s queries) GetMediaFilesByIDs(ctx context.Context, ids []uuid.UUID) ([]database.MediaFile, error) {
+	found, err := s.q.GetMediaFilesByIDs(ctx, ids)
+	return convertAll(found, mediaFile), err
+}
+
+func (s queries) GetMediaFilesByUser(ctx context.Context, userID uuid.UUID) ([]database.MediaFile, error) {
+	found, err := s.q.GetMediaFilesByUser(ctx, userID)
+	return convertAll(found, mediaFile), err
+}
+
+func (s queries) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]database.NotificationPreference, error) {
+	preferences, err := s.q.GetNotificationPreferences(ctx, userID)
+	return convertAll(preferences, func(preference NotificationPreference) database.NotificationPreference {
+		return database.NotificationPreference(preference)
+	}), err
+}
+
+func (s queries) GetNotifications(ctx context.Context, arg database.GetNotificationsParams) ([]database.GetNotificationsRow, error) {
+	rows, err := s.q.GetNotifications(ctx, GetNotificationsParams{
+		UserID:          arg.UserID,
+		UnreadOnly:      arg.UnreadOnly,
+		BeforeCreatedAt: arg.BeforeCreatedAt,
+		BeforeID:        arg.BeforeID.String(),
+		PageSize:        arg.PageSize,
+	})
+	return convertAll(rows, func(row GetNotificationsRow) database.GetNotificationsRow {
+		return database.GetNotificationsRow(row)
+	}), err
+}
+
+func (s queries) GetOAuthClient(ctx context.Context, id uuid.UUID) (database.OauthClient, error) {
+	client, err := s.q.GetOAuthClient(ctx, id)
+	return database.OauthClient(client), err
+}
+
+func (s queries) GetOAuthClientsByOwner(ctx context.Context, ownerID uuid.UUID) ([]database.OauthClient, error) {
+	clients, err := s.q.GetOAuthClientsByOwner(ctx, ownerID)
+	return convertAll(clients, func(client OauthClient) database.OauthClient {
+		return database.OauthClient(client)
+	}), err
+}
+
+func (s queries) GetOAuthCode(ctx context.Context, code string) (database.OauthAuthorizationCode, error) {
+	found, err := s.q.GetOAuthCode(ctx, code)
+	return database.OauthAuthorizationCode(found), err
+}
+
+func (s queries) GetThreadAncestorIDs(ctx context.Context, arg database.GetThreadAncestorIDsParams) ([]database.GetThreadAncestorIDsRow, error) {
+	rows, err := s.q.GetThreadAncestorIDs(ctx, GetThreadAncestorIDsParams(arg))
+	return convertAll(rows, func(row GetThreadAncestorIDsRow) database.GetThreadAncestorIDsRow {
+		return database.GetThreadAncestorIDsRow(row)
+	}), err
+}
+
+func (s queries) GetThreadDescendantIDs(ctx context.Context, arg database.GetThreadDescendantIDsParams) ([]database.GetThreadDescendantIDsRow, error) {
+	rows, err := s.q.GetThreadDescendantIDs(ctx, GetThreadDescendantIDsParams{
+		AfterCreatedAt: arg.AfterCreatedAt,
+		AfterID:        arg.AfterID.String(),
+		PageSize:       arg.PageSize,
+		ChirpID:        uuid.NullUUID{UUID: arg.ChirpID, Valid: true},
+		MaxDepth:       arg.MaxDepth,
+	})
+	return convertAll(rows, func(row GetThreadDescendantIDsRow) database.GetThreadDescendantIDsRow {
+		return database.GetThreadDescendantIDsRow(row)
+	}), err
+}
+
+func (s queries) GetTimelineByFanOut(ctx context.Context, arg database.GetTimelineByFanOutParams) ([]database.Chirp, error) {
+	return chirps(s.q.GetTimelineByFanOut(ctx, GetTimelineByFanOutParams{
+		UserID:          arg.UserID,
+		BeforeCreatedAt: arg.BeforeCreatedAt,
+		BeforeID:        arg.BeforeID.String(),
+		PageSize:        arg.PageSize,
+	}))
+}
+
+func (s queries) GetTimelineByJoin(ctx context.Context, arg database.GetTimelineByJoinParams) ([]database.Chirp, error) {
+	return chirps(s.q.GetTimelineByJoin(ctx, GetTimelineByJoinParams{
+		UserID:          arg.UserID,
+		BeforeCreatedAt: arg.BeforeCreatedAt,
+		BeforeID:        arg.BeforeID.String(),
+		PageSize:        arg.PageSize,
+	}))
+}
+
+func (s queries) GetToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	found, err := s.q.GetToken(ctx, token)
+	return database.RefreshToken(found), err
+}
+
+func (s queries) GetTokensByUser(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	tokens, err := s.q.GetTokensByUser(ctx, userID)
+	return convertAll(tokens, func(token RefreshToken) database.RefreshToken {
+		return database.RefreshToken(token)
+	}), err
+}
+
+func (s queries) GetTrendingHashtags(ctx context.Context, arg database.GetTrendingHashtagsParams) ([]database.GetTrendingHashtagsRow, error) {
+	rows, err := s.q.GetTrendingHashtags(ctx, GetTrendingHashtagsParams(arg))
+	return convertAll(rows, func(row GetTrendingHashtagsRow) database.GetTrendingHashtagsRow {
+		return database.GetTrendingHashtagsRow{Tag: row.Tag, ChirpCount: int64(row.ChirpCount)}
+	}), err
+}
+
+func (s queries) GetTwoFactorChallenge(ctx context.Context, token string) (database.TwoFactorChallenge, error) {
+	challenge, err := s.q.GetTwoFactorChallenge(ctx, token)
+	return database.TwoFactorChallenge(challenge), err
+}
+
+func (s queries) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	found, err := s.q.GetUserByEmail(ctx, email)
+	return user(found), err
+}
+
+func (s queries) GetUserByHandle(ctx context.Context, handle sql.NullString) (database.User, error) {
+	found, err := s.q.GetUserByHandle(ctx, handle)
+	return user(found), err
+}
+
+func (s queries) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	found, err := s.q.GetUserByID(ctx, id)
+	return user(found), err
+}
+
+func (s queries) GetUsersByHandles(ctx context.Context, handles []string) ([]database.User, error) {
+	return users(s.q.GetUsersByHandles(ctx, convertAll(handles, func(handle string) sql.NullString {
+		return sql.NullString{String: handle, Valid: true}
+	})))
+}
+
+func (s queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]database.User, error) {
+	return users(s.q.GetUsersByIDs(ctx, ids))
+}
+
+func (s queries) HasReferences(ctx context.Context, chirpID uuid.NullUUID) (bool, error) {
+	exists, err := s.q.HasReferences(ctx, chirpID)
+	return exists != 0, err
+}
+
+// Unlike Postgres's version, LikeChirp and UnlikeChirp read the count back
+// in a second statement, so under concurrent likes it may already include
+// someone else's.
+func (s queries) LikeChirp(ctx context.Context, arg database.LikeChirpParams) (int32, error) {
+	if err := s.q.LikeChirp(ctx, LikeChirpParams(arg)); err != nil {
+		return 0, translateError(err)
+	}
+	return s.q.GetLikeCount(ctx, arg.ChirpID)
+}
+
+func (s queries) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	return users(s.q.ListUsers(ctx, ListUsersParams(arg)))
+}
+
+func (s queries) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.q.MarkAllNotificationsRead(ctx, userID)
+}
+
+func (s queries) MarkNotificationsRead(ctx context.Context, arg database.MarkNotificationsReadParams) (int64, error) {
+	return s.q.MarkNotificationsRead(ctx, MarkNotificationsReadParams(arg))
+}
+
+func (s queries) PublishEvent(ctx context.Context, arg database.PublishEventParams) error {
+	return errNoEvents
+}
+
+func (s queries) PurgeDeletedUsers(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	return s.q.PurgeDeletedUsers(ctx, deletedAt)
+}
+
+func (s queries) RemoveFromTimeline(ctx context.Context, arg database.RemoveFromTimelineParams) error {
+	return s.q.RemoveFromTimeline(ctx, RemoveFromTimelineParams(arg))
+}
+
+func (s queries) ResetChirps(ctx context.Context) error {
+	return s.q.ResetChirps(ctx)
+}
+
+func (s queries) ResetUsers(ctx context.Context) error {
+	return s.q.ResetUsers(ctx)
+}
+
+func (s queries) RestoreUser(ctx context.Context, id uuid.UUID) error {
+	return s.q.RestoreUser(ctx, id)
+}
+
+func (s queries) RevokeAPIKey(ctx context.Context, arg database.RevokeAPIKeyParams) (int64, error) {
+	return s.q.RevokeAPIKey(ctx, RevokeAPIKeyParams(arg))
+}
+
+func (s queries) RevokeToken(ctx context.Context, token string) error {
+	return s.q.RevokeToken(ctx, token)
+}
+
+func (s queries) RevokeUserAPIKeys(ctx context.Context, userID uuid.UUID) error {
+	return s.q.RevokeUserAPIKeys(ctx, userID)
+}
+
+func (s queries) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	return s.q.RevokeUserTokens(ctx, userID)
+}
+
+func (s queries) SearchChirps(ctx context.Context, arg database.SearchChirpsParams) ([]database.SearchChirpsRow, error) {
+	rows, err := s.q.SearchChirps(ctx, SearchChirpsParams{
+		Query:      ftsQuery(arg.Query),
+		AuthorID:   arg.AuthorID,
+		Since:      arg.Since,
+		Until:      arg.Until,
+		PageOffset: arg.PageOffset,
+		PageSize:   arg.PageSize,
+	})
+	return convertAll(rows, func(row SearchChirpsRow) database.SearchChirpsRow {
+		return database.SearchChirpsRow{Chirp: chirp(row.Chirp), Rank: float32(row.Rank), Headline: row.Headline}
+	}), err
+}
+
+func (s queries) SearchUsersByEmail(ctx context.Context, arg database.SearchUsersByEmailParams) ([]database.User, error) {
+	return users(s.q.SearchUsersByEmail(ctx, SearchUsersByEmailParams(arg)))
+}
+
+func (s queries) SearchUsersByHandle(ctx context.Context, arg database.SearchUsersByHandleParams) ([]database.User, error) {
+	return users(s.q.SearchUsersByHandle(ctx, SearchUsersByHandleParams(arg)))
+}
+
+func (s queries) SetChirpyRed(ctx context.Context, arg database.SetChirpyRedParams) error {
+	return s.q.SetChirpyRed(ctx, SetChirpyRedParams{IsChirpyRed: arg.IsChirpyRed, ID: arg.ID})
+}
+
+func (s queries) SetNotificationPreference(ctx context.Context, arg database.SetNotificationPreferenceParams) error {
+	return translateError(s.q.SetNotificationPreference(ctx, SetNotificationPreferenceParams(arg)))
+}
+
+func (s queries) SetTOTPSecret(ctx context.Context, arg database.SetTOTPSecretParams) error {
+	return s.q.SetTOTPSecret(ctx, SetTOTPSecretParams{TotpSecret: arg.TotpSecret, ID: arg.ID})
+}
+
+func (s queries) SoftDeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.q.SoftDeleteUser(ctx, id)
+}
+
+func (s queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	return s.q.TombstoneChirp(ctx, id)
+}
+
+func (s queries) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	return s.q.TouchAPIKey(ctx, id)
+}
+
+func (s queries) UnfollowUser(ctx context.Context, arg database.UnfollowUserParams) (int64, error) {
+	return s.q.UnfollowUser(ctx, UnfollowUserParams(arg))
+}
+
+func (s queries) UnlikeChirp(ctx context.Context, arg database.UnlikeChirpParams) (int32, error) {
+	if err := s.q.UnlikeChirp(ctx, UnlikeChirpParams(arg)); err != nil {
+		return 0, err
+	}
+	return s.q.GetLikeCount(ctx, arg.ChirpID)
+}
+
+func (s queries) UpdateUserEmailAndPassword(ctx context.Context, arg database.UpdateUserEmailAndPasswordParams) (database.User, error) {
+	updated, err := s.q.UpdateUserEmailAndPassword(ctx, UpdateUserEmailAndPasswordParams{
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		ID:             arg.ID,
+	})
+	return user(updated), translateError(err)
+}
+
+func (s queries) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) (database.User, error) {
+	updated, err := s.q.UpdateUserProfile(ctx, UpdateUserProfileParams{
+		Handle:      arg.Handle,
+		DisplayName: arg.DisplayName,
+		Bio:         arg.Bio,
+		AvatarUrl:   arg.AvatarUrl,
+		ID:          arg.ID,
+	})
+	return user(updated), translateError(err)
+}
+
+func (s queries) UpgradeByID(ctx context.Context, id uuid.UUID) error {
+	return s.q.UpgradeByID(ctx, id)
+}
+
+func (s queries) UseOAuthCode(ctx context.Context, code string) (int64, error) {
+	return s.q.UseOAuthCode(ctx, code)
+}
+
+func (s queries) UseRecoveryCode(ctx context.Context, arg database.UseRecoveryCodeParams) (int64, error) {
+	return s.q.UseRecoveryCode(ctx, UseRecoveryCodeParams(arg))
+}
+
+func (s queries) UseTOTPStep(ctx context.Context, arg database.UseTOTPStepParams) (int64, error) {
+	return s.q.UseTOTPStep(ctx, UseTOTPStepParams{TotpLastStep: arg.TotpLastStep, ID: arg.ID})
+}
+
+func (s queries) UseTwoFactorChallenge(ctx context.Context, token string) (int64, error) {
+	return s.q.UseTwoFactorChallenge(ctx, token)
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Returned by stores other than Postgres in place of its constraint errors.
+var (
+	ErrUniqueViolation     = errors.New("duplicate key value violates unique constraint")
+	ErrForeignKeyViolation = errors.New("violates foreign key constraint")
+	ErrCheckViolation      = errors.New("violates check constraint")
+)
+
+// SearchChirpsRow.Headline is the chirp body as written, with each hit
+// between HeadlineStart and HeadlineStop. It isn't escaped, so those need
+// replacing with markup only after escaping the rest.
+const (
+	HeadlineStart = "\uE000"
+	HeadlineStop  = "\uE001"
+)
+
+// Store is a Querier that can also run several queries in one transaction.
+type Store interface {
+	Querier
+	WithTx(ctx context.Context, fn func(ctx context.Context, queries Querier) error) error
+}
+
+type sqlStore struct {
+	*Queries
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) Store {
+	return sqlStore{Queries: New(db), db: db}
+}
+
+func (s sqlStore) WithTx(ctx context.Context, fn func(ctx context.Context, queries Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ctx, runHooks := TrackAfterCommit(ctx)
+	if err := fn(ctx, s.Queries.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	runHooks()
+	return nil
+}
+
+type afterCommitKey struct{}
+
+// AfterCommit runs hook once the transaction in ctx commits, and never if it
+// rolls back. Outside a transaction it runs hook straight away.
+func AfterCommit(ctx context.Context, hook func()) {
+	hooks, ok := ctx.Value(afterCommitKey{}).(*[]func())
+	if !ok {
+		hook()
+		return
+	}
+	*hooks = append(*hooks, hook)
+}
+
+// For Store implementations: hooks added to the returned context through
+// AfterCommit are held until runHooks is called after the commit.
+func TrackAfterCommit(ctx context.Context) (context.Context, func()) {
+	hooks := []func(){}
+	return context.WithValue(ctx, afterCommitKey{}, &hooks), func() {
+		for _, hook := range hooks {
+			hook()
+		}
+	}
+}
@@ -7,8 +7,10 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -20,7 +22,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -38,12 +40,75 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DeletedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :execrows
+WITH deleted AS (
+    SELECT id FROM users
+    WHERE id = $1
+), counted AS (
+    UPDATE chirps
+    SET
+        like_count = like_count - (
+            SELECT COUNT(*) FROM chirp_likes
+            WHERE chirp_likes.chirp_id = chirps.id AND chirp_likes.user_id IN (SELECT id FROM deleted)
+        ),
+        rechirp_count = rechirp_count - (
+            SELECT COUNT(*) FROM chirps AS rechirps
+            WHERE rechirps.rechirp_of_id = chirps.id AND rechirps.user_id IN (SELECT id FROM deleted)
+        )
+    WHERE chirps.id IN (
+        SELECT chirp_id FROM chirp_likes WHERE user_id IN (SELECT id FROM deleted)
+        UNION
+        SELECT rechirp_of_id FROM chirps WHERE user_id IN (SELECT id FROM deleted)
+    )
+)
+DELETE FROM users
+WHERE id IN (SELECT id FROM deleted)
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableTOTP = `-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled = false, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableTOTP, id)
+	return err
+}
+
+const enableTOTP = `-- name: EnableTOTP :exec
+UPDATE users
+SET totp_enabled = true, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) EnableTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, enableTOTP, id)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step
 FROM users
 WHERE email = $1
 `
@@ -58,10 +123,243 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DeletedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step
+FROM users
+WHERE handle = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DeletedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DeletedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step FROM users
+WHERE handle = ANY($1::text[]) AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+			&i.DeletedAt,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.TotpLastStep,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step
+FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+			&i.DeletedAt,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.TotpLastStep,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step FROM users
+ORDER BY created_at
+LIMIT $1 OFFSET $2
+`
+
+type ListUsersParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+			&i.DeletedAt,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.TotpLastStep,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+WITH deleted AS (
+    SELECT id FROM users
+    WHERE deleted_at IS NOT NULL AND deleted_at < $1
+), counted AS (
+    UPDATE chirps
+    SET
+        like_count = like_count - (
+            SELECT COUNT(*) FROM chirp_likes
+            WHERE chirp_likes.chirp_id = chirps.id AND chirp_likes.user_id IN (SELECT id FROM deleted)
+        ),
+        rechirp_count = rechirp_count - (
+            SELECT COUNT(*) FROM chirps AS rechirps
+            WHERE rechirps.rechirp_of_id = chirps.id AND rechirps.user_id IN (SELECT id FROM deleted)
+        )
+    WHERE chirps.id IN (
+        SELECT chirp_id FROM chirp_likes WHERE user_id IN (SELECT id FROM deleted)
+        UNION
+        SELECT rechirp_of_id FROM chirps WHERE user_id IN (SELECT id FROM deleted)
+    )
+)
+DELETE FROM users
+WHERE id IN (SELECT id FROM deleted)
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedUsers, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const resetUsers = `-- name: ResetUsers :exec
 DELETE FROM users
 `
@@ -71,11 +369,65 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	return err
 }
 
+const restoreUser = `-- name: RestoreUser :exec
+UPDATE users
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreUser, id)
+	return err
+}
+
+const setChirpyRed = `-- name: SetChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetChirpyRedParams struct {
+	ID          uuid.UUID
+	IsChirpyRed bool
+}
+
+func (q *Queries) SetChirpyRed(ctx context.Context, arg SetChirpyRedParams) error {
+	_, err := q.db.ExecContext(ctx, setChirpyRed, arg.ID, arg.IsChirpyRed)
+	return err
+}
+
+const setTOTPSecret = `-- name: SetTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_enabled = false, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetTOTPSecretParams struct {
+	ID         uuid.UUID
+	TotpSecret sql.NullString
+}
+
+func (q *Queries) SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) error {
+	_, err := q.db.ExecContext(ctx, setTOTPSecret, arg.ID, arg.TotpSecret)
+	return err
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :exec
+UPDATE users
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) SoftDeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteUser, id)
+	return err
+}
+
 const updateUserEmailAndPassword = `-- name: UpdateUserEmailAndPassword :one
 UPDATE users
 SET email = $2, hashed_password = $3
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step
 `
 
 type UpdateUserEmailAndPasswordParams struct {
@@ -94,6 +446,57 @@ func (q *Queries) UpdateUserEmailAndPassword(ctx context.Context, arg UpdateUser
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DeletedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2, display_name = $3, bio = $4, avatar_url = $5, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, totp_secret, totp_enabled, deleted_at, handle, display_name, bio, avatar_url, totp_last_step
+`
+
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID
+	Handle      sql.NullString
+	DisplayName string
+	Bio         string
+	AvatarUrl   string
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.ID,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DeletedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -108,3 +511,22 @@ func (q *Queries) UpgradeByID(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, upgradeByID, id)
 	return err
 }
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)
+`
+
+type UseTOTPStepParams struct {
+	ID           uuid.UUID
+	TotpLastStep sql.NullInt64
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,5 @@
+package database
+
+// The migration in sql/schema that the queries in this package were generated
+// against. Bump it alongside every new migration.
+const SchemaVersion int64 = 19
@@ -0,0 +1,120 @@
+package entities
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	KindHashtag = "hashtag"
+	KindMention = "mention"
+	KindURL     = "url"
+)
+
+const maxHashtagLength = 50
+
+// Start and End are rune offsets into the body, End exclusive. Value is the
+// normalised form: lowercased for hashtags and mentions, as written for URLs.
+type Entity struct {
+	Kind  string
+	Value string
+	Start int
+	End   int
+}
+
+// Go's regexp has no lookbehind, so the hashtag and mention patterns consume
+// the character before the sigil and the offsets come from the submatch.
+var (
+	urlPattern     = regexp.MustCompile(`https?://[^\s]+`)
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_&/#])#([\p{L}\p{M}\p{N}_]+)`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_@])@([A-Za-z0-9_]+)`)
+	handlePattern  = regexp.MustCompile(`^[a-z0-9_]{3,20}$`)
+)
+
+const urlTrailingPunctuation = `.,:;!?'")]}`
+
+func Parse(body string) []Entity {
+	entities := []Entity{}
+
+	urlRanges := [][2]int{}
+	for _, match := range urlPattern.FindAllStringIndex(body, -1) {
+		url := strings.TrimRight(body[match[0]:match[1]], urlTrailingPunctuation)
+		end := match[0] + len(url)
+		urlRanges = append(urlRanges, [2]int{match[0], end})
+		entities = append(entities, makeEntity(body, KindURL, url, match[0], end))
+	}
+
+	insideURL := func(start int) bool {
+		for _, urlRange := range urlRanges {
+			if start >= urlRange[0] && start < urlRange[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, match := range hashtagPattern.FindAllStringSubmatchIndex(body, -1) {
+		start, end := match[2]-1, match[3]
+		if insideURL(start) {
+			continue
+		}
+		tag, err := NormaliseHashtag(body[match[2]:match[3]])
+		if err != nil {
+			continue
+		}
+		entities = append(entities, makeEntity(body, KindHashtag, tag, start, end))
+	}
+
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(body, -1) {
+		start, end := match[2]-1, match[3]
+		if insideURL(start) {
+			continue
+		}
+		handle := strings.ToLower(body[match[2]:match[3]])
+		if !handlePattern.MatchString(handle) {
+			continue
+		}
+		entities = append(entities, makeEntity(body, KindMention, handle, start, end))
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Start < entities[j].Start
+	})
+	return entities
+}
+
+// Tags are matched case-insensitively and need at least one letter, so #2024
+// is not a tag.
+func NormaliseHashtag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if tag == "" || utf8.RuneCountInString(tag) > maxHashtagLength {
+		return "", fmt.Errorf("Hashtags are 1-%d characters", maxHashtagLength)
+	}
+	hasLetter := false
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r) && r != '_' {
+			return "", fmt.Errorf("Hashtags are letters, digits or underscores")
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	if !hasLetter {
+		return "", fmt.Errorf("Hashtags need at least one letter")
+	}
+	return tag, nil
+}
+
+func makeEntity(body, kind, value string, start, end int) Entity {
+	runeStart := utf8.RuneCountInString(body[:start])
+	return Entity{
+		Kind:  kind,
+		Value: value,
+		Start: runeStart,
+		End:   runeStart + utf8.RuneCountInString(body[start:end]),
+	}
+}
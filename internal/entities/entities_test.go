@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	body := "héllo #Go and @Bob_1 see https://example.com/a#frag. me@example.com #2024 ##x"
+	want := []Entity{
+		{Kind: KindHashtag, Value: "go", Start: 6, End: 9},
+		{Kind: KindMention, Value: "bob_1", Start: 14, End: 20},
+		{Kind: KindURL, Value: "https://example.com/a#frag", Start: 25, End: 51},
+	}
+	got := Parse(body)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Wrong entities:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseOffsetsAreRunes(t *testing.T) {
+	body := "日本 #東京"
+	got := Parse(body)
+	if len(got) != 1 || got[0].Start != 3 || got[0].End != 6 || got[0].Value != "東京" {
+		t.Fatalf("Wrong entities: %+v", got)
+	}
+	runes := []rune(body)
+	if string(runes[got[0].Start:got[0].End]) != "#東京" {
+		t.Fatal("Offsets do not slice the tag out of the body")
+	}
+}
+
+func TestNormaliseHashtag(t *testing.T) {
+	if tag, err := NormaliseHashtag("#GoLang"); err != nil || tag != "golang" {
+		t.Fatal("Could not normalise tag:", tag, err)
+	}
+	if _, err := NormaliseHashtag("2024"); err == nil {
+		t.Fatal("Numeric tag accepted")
+	}
+	if _, err := NormaliseHashtag("go-lang"); err == nil {
+		t.Fatal("Tag with punctuation accepted")
+	}
+}
@@ -0,0 +1,133 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+const maxDimension = 8192
+
+type Info struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// Process works out what an upload really is from its bytes, ignoring whatever
+// the client claimed, and returns a copy with EXIF and text metadata removed.
+// Only JPEG, PNG and GIF images are accepted.
+func Process(data []byte) (Info, []byte, error) {
+	contentType := http.DetectContentType(data)
+	extension, ok := getAllowedTypes()[contentType]
+	if !ok {
+		return Info{}, nil, fmt.Errorf("Unsupported media type %s", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, nil, fmt.Errorf("Could not read image: %w", err)
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return Info{}, nil, fmt.Errorf("Images can be at most %dx%d", maxDimension, maxDimension)
+	}
+
+	cleaned := data
+	switch contentType {
+	case "image/jpeg":
+		cleaned, err = stripJPEG(data)
+	case "image/png":
+		cleaned, err = stripPNG(data)
+	}
+	if err != nil {
+		return Info{}, nil, err
+	}
+
+	return Info{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+	}, cleaned, nil
+}
+
+// stripJPEG drops APP1 (EXIF, XMP) and APP13 (IPTC) segments. Everything from
+// the start of scan on is image data and is copied as is.
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("Not a JPEG")
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	for i := 2; i < len(data); {
+		if data[i] != 0xFF || i+4 > len(data) {
+			return nil, fmt.Errorf("Malformed JPEG")
+		}
+		marker := data[i+1]
+		if marker == 0xDA {
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("Malformed JPEG")
+		}
+		if marker != 0xE1 && marker != 0xED {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return nil, fmt.Errorf("Malformed JPEG")
+}
+
+// stripPNG drops eXIf and text chunks.
+func stripPNG(data []byte) ([]byte, error) {
+	const signatureLength = 8
+	if len(data) < signatureLength {
+		return nil, fmt.Errorf("Not a PNG")
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:signatureLength])
+
+	for i := signatureLength; i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("Malformed PNG")
+		}
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		chunkType := string(data[i+4 : i+8])
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("Malformed PNG")
+		}
+		if _, strip := getStrippedPNGChunks()[chunkType]; !strip {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+func getAllowedTypes() map[string]string {
+	return map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+	}
+}
+
+func getStrippedPNGChunks() map[string]int {
+	return map[string]int{
+		"eXIf": 0,
+		"tEXt": 0,
+		"zTXt": 0,
+		"iTXt": 0,
+	}
+}
@@ -0,0 +1,72 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestProcessJPEGStripsEXIF(t *testing.T) {
+	encoded := bytes.Buffer{}
+	if err := jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 30, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	exif := append([]byte("Exif\x00\x00"), []byte("GPS secrets")...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	data := append(append(append([]byte{}, encoded.Bytes()[:2]...), append(segment, exif...)...), encoded.Bytes()[2:]...)
+
+	info, cleaned, err := Process(data)
+	if err != nil {
+		t.Fatal("Could not process JPEG:", err)
+	}
+	if info.ContentType != "image/jpeg" || info.Width != 30 || info.Height != 20 {
+		t.Fatalf("Wrong info: %+v", info)
+	}
+	if bytes.Contains(cleaned, []byte("GPS secrets")) {
+		t.Fatal("EXIF not stripped")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(cleaned)); err != nil {
+		t.Fatal("Stripped JPEG does not decode:", err)
+	}
+}
+
+func TestProcessPNGStripsText(t *testing.T) {
+	encoded := bytes.Buffer{}
+	if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 7, 9))); err != nil {
+		t.Fatal(err)
+	}
+	text := []byte("Comment\x00taken at home")
+	chunk := make([]byte, 8, 12+len(text))
+	binary.BigEndian.PutUint32(chunk, uint32(len(text)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	// Insert after the signature and IHDR chunk.
+	ihdrEnd := 8 + 12 + 13
+	data := append(append(append([]byte{}, encoded.Bytes()[:ihdrEnd]...), chunk...), encoded.Bytes()[ihdrEnd:]...)
+
+	info, cleaned, err := Process(data)
+	if err != nil {
+		t.Fatal("Could not process PNG:", err)
+	}
+	if info.Width != 7 || info.Height != 9 || info.Extension != ".png" {
+		t.Fatalf("Wrong info: %+v", info)
+	}
+	if bytes.Contains(cleaned, []byte("taken at home")) {
+		t.Fatal("Text chunk not stripped")
+	}
+	if _, err := png.Decode(bytes.NewReader(cleaned)); err != nil {
+		t.Fatal("Stripped PNG does not decode:", err)
+	}
+}
+
+func TestProcessRejectsNonImages(t *testing.T) {
+	if _, _, err := Process([]byte("<html><script>alert(1)</script></html>")); err == nil {
+		t.Fatal("HTML accepted as media")
+	}
+}
@@ -0,0 +1,101 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateAPIKey(ctx context.Context, arg database.CreateAPIKeyParams) (database.ApiKey, error) {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("api_keys", arg.UserID); err != nil {
+		return database.ApiKey{}, err
+	}
+	for _, key := range t.apiKeys {
+		if key.Prefix == arg.Prefix {
+			return database.ApiKey{}, uniqueViolation("api_keys_prefix_key")
+		}
+	}
+	now := s.now()
+	key := database.ApiKey{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		Prefix:    arg.Prefix,
+		KeyHash:   arg.KeyHash,
+		Scope:     arg.Scope,
+		ExpiresAt: arg.ExpiresAt,
+	}
+	t.apiKeys[key.ID] = key
+	return key, nil
+}
+
+func (s *Store) GetAPIKeyByPrefix(ctx context.Context, prefix string) (database.ApiKey, error) {
+	t, done := s.begin()
+	defer done()
+	for _, key := range t.apiKeys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return notFound[database.ApiKey]()
+}
+
+func (s *Store) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]database.ApiKey, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.ApiKey
+	for _, key := range t.apiKeys {
+		if key.UserID == userID {
+			items = append(items, key)
+		}
+	}
+	sortByCreatedAt(items, func(key database.ApiKey) time.Time { return key.CreatedAt })
+	return items, nil
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, arg database.RevokeAPIKeyParams) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	key, ok := t.apiKeys[arg.ID]
+	if !ok || key.UserID != arg.UserID || key.RevokedAt.Valid {
+		return 0, nil
+	}
+	now := s.now()
+	key.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	key.UpdatedAt = now
+	t.apiKeys[key.ID] = key
+	return 1, nil
+}
+
+func (s *Store) RevokeUserAPIKeys(ctx context.Context, userID uuid.UUID) error {
+	t, done := s.begin()
+	defer done()
+	now := s.now()
+	for id, key := range t.apiKeys {
+		if key.UserID == userID && !key.RevokedAt.Valid {
+			key.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			key.UpdatedAt = now
+			t.apiKeys[id] = key
+		}
+	}
+	return nil
+}
+
+func (s *Store) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	t, done := s.begin()
+	defer done()
+	key, ok := t.apiKeys[id]
+	if !ok {
+		return nil
+	}
+	key.LastUsedAt = sql.NullTime{Time: s.now(), Valid: true}
+	t.apiKeys[id] = key
+	return nil
+}
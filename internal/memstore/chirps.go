@@ -0,0 +1,205 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("chirps", arg.UserID); err != nil {
+		return database.Chirp{}, err
+	}
+	if _, ok := t.chirps[arg.ReplyToID.UUID]; arg.ReplyToID.Valid && !ok {
+		return database.Chirp{}, foreignKeyViolation("chirps", "fk_reply_to")
+	}
+	if _, ok := t.chirps[arg.QuoteOfID.UUID]; arg.QuoteOfID.Valid && !ok {
+		return database.Chirp{}, foreignKeyViolation("chirps", "fk_quote_of")
+	}
+	now := s.now()
+	chirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+		ReplyToID: arg.ReplyToID,
+		QuoteOfID: arg.QuoteOfID,
+	}
+	t.chirps[chirp.ID] = chirp
+	return chirp, nil
+}
+
+// CreateRechirp returns sql.ErrNoRows if the user has already rechirped it.
+func (s *Store) CreateRechirp(ctx context.Context, arg database.CreateRechirpParams) (database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("chirps", arg.UserID); err != nil {
+		return database.Chirp{}, err
+	}
+	original, ok := t.chirps[arg.RechirpOfID.UUID]
+	if arg.RechirpOfID.Valid && !ok {
+		return database.Chirp{}, foreignKeyViolation("chirps", "fk_rechirp_of")
+	}
+	for _, chirp := range t.chirps {
+		if arg.RechirpOfID.Valid && chirp.UserID == arg.UserID && chirp.RechirpOfID == arg.RechirpOfID {
+			return notFound[database.Chirp]()
+		}
+	}
+	now := s.now()
+	rechirp := database.Chirp{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		UserID:      arg.UserID,
+		RechirpOfID: arg.RechirpOfID,
+	}
+	t.chirps[rechirp.ID] = rechirp
+	if ok {
+		original.RechirpCount++
+		t.chirps[original.ID] = original
+	}
+	return rechirp, nil
+}
+
+func (s *Store) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	t, done := s.begin()
+	defer done()
+	t.deleteChirp(id)
+	return nil
+}
+
+// DeleteRechirp reports the rows updated on the original chirp, so it's 1 if
+// a rechirp was removed and 0 otherwise.
+func (s *Store) DeleteRechirp(ctx context.Context, arg database.DeleteRechirpParams) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	var deleted int32
+	for id, chirp := range t.chirps {
+		if arg.RechirpOfID.Valid && chirp.UserID == arg.UserID && chirp.RechirpOfID == arg.RechirpOfID {
+			t.deleteChirp(id)
+			deleted++
+		}
+	}
+	original, ok := t.chirps[arg.RechirpOfID.UUID]
+	if !arg.RechirpOfID.Valid || !ok || deleted == 0 {
+		return 0, nil
+	}
+	original.RechirpCount -= deleted
+	t.chirps[original.ID] = original
+	return 1, nil
+}
+
+func (s *Store) DeleteRechirpsOf(ctx context.Context, rechirpOfID uuid.NullUUID) error {
+	t, done := s.begin()
+	defer done()
+	for id, chirp := range t.chirps {
+		if rechirpOfID.Valid && chirp.RechirpOfID == rechirpOfID {
+			t.deleteChirp(id)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetChirpById(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	chirp, ok := t.chirps[id]
+	if !ok {
+		return notFound[database.Chirp]()
+	}
+	return chirp, nil
+}
+
+func (s *Store) GetChirps(ctx context.Context) ([]database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	return sortedChirps(t, func(chirp database.Chirp) bool {
+		return !chirp.DeletedAt.Valid
+	}, oldestFirst), nil
+}
+
+func (s *Store) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	return sortedChirps(t, func(chirp database.Chirp) bool {
+		return slices.Contains(ids, chirp.ID)
+	}, oldestFirst), nil
+}
+
+func (s *Store) GetChirpsByPopularity(ctx context.Context) ([]database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	return sortedChirps(t, func(chirp database.Chirp) bool {
+		return !chirp.DeletedAt.Valid
+	}, func(a, b database.Chirp) int {
+		return cmp.Or(cmp.Compare(b.LikeCount, a.LikeCount), newestFirst(a, b))
+	}), nil
+}
+
+func (s *Store) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	return sortedChirps(t, func(chirp database.Chirp) bool {
+		return chirp.UserID == userID && !chirp.DeletedAt.Valid
+	}, oldestFirst), nil
+}
+
+func (s *Store) HasReferences(ctx context.Context, chirpID uuid.NullUUID) (bool, error) {
+	t, done := s.begin()
+	defer done()
+	for _, chirp := range t.chirps {
+		if chirpID.Valid && (chirp.ReplyToID == chirpID || chirp.QuoteOfID == chirpID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Store) ResetChirps(ctx context.Context) error {
+	t, done := s.begin()
+	defer done()
+	for id := range t.chirps {
+		t.deleteChirp(id)
+	}
+	return nil
+}
+
+func (s *Store) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	t, done := s.begin()
+	defer done()
+	chirp, ok := t.chirps[id]
+	if !ok {
+		return nil
+	}
+	now := s.now()
+	chirp.Body = ""
+	chirp.DeletedAt.Time, chirp.DeletedAt.Valid = now, true
+	chirp.UpdatedAt = now
+	t.chirps[id] = chirp
+	return nil
+}
+
+func sortedChirps(t *tables, keep func(chirp database.Chirp) bool, compare func(a, b database.Chirp) int) []database.Chirp {
+	var items []database.Chirp
+	for _, chirp := range t.chirps {
+		if keep(chirp) {
+			items = append(items, chirp)
+		}
+	}
+	slices.SortFunc(items, compare)
+	return items
+}
+
+func oldestFirst(a, b database.Chirp) int {
+	return compareKey(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+func newestFirst(a, b database.Chirp) int {
+	return compareKey(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+}
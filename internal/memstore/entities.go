@@ -0,0 +1,95 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateChirpEntity(ctx context.Context, arg database.CreateChirpEntityParams) error {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkChirp("chirp_entities", arg.ChirpID); err != nil {
+		return err
+	}
+	if arg.UserID.Valid {
+		if err := t.checkUser("chirp_entities", arg.UserID.UUID); err != nil {
+			return err
+		}
+	}
+	key := entityKey{chirpID: arg.ChirpID, startOffset: arg.StartOffset}
+	if _, ok := t.chirpEntities[key]; ok {
+		return uniqueViolation("chirp_entities_pkey")
+	}
+	t.chirpEntities[key] = database.ChirpEntity(arg)
+	return nil
+}
+
+func (s *Store) DeleteChirpEntities(ctx context.Context, chirpID uuid.UUID) error {
+	t, done := s.begin()
+	defer done()
+	for key := range t.chirpEntities {
+		if key.chirpID == chirpID {
+			delete(t.chirpEntities, key)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetChirpEntities(ctx context.Context, chirpIds []uuid.UUID) ([]database.ChirpEntity, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.ChirpEntity
+	for key, entity := range t.chirpEntities {
+		if slices.Contains(chirpIds, key.chirpID) {
+			items = append(items, entity)
+		}
+	}
+	slices.SortFunc(items, func(a, b database.ChirpEntity) int {
+		return cmp.Or(compareUUID(a.ChirpID, b.ChirpID), cmp.Compare(a.StartOffset, b.StartOffset))
+	})
+	return items, nil
+}
+
+func (s *Store) GetChirpsByHashtag(ctx context.Context, arg database.GetChirpsByHashtagParams) ([]database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	tagged := map[uuid.UUID]bool{}
+	for key, entity := range t.chirpEntities {
+		if entity.Kind == "hashtag" && entity.Value == arg.Tag {
+			tagged[key.chirpID] = true
+		}
+	}
+	items := sortedChirps(t, func(chirp database.Chirp) bool {
+		return tagged[chirp.ID] && !chirp.DeletedAt.Valid &&
+			compareKey(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt, arg.BeforeID) < 0
+	}, newestFirst)
+	return limit(items, arg.PageSize), nil
+}
+
+func (s *Store) GetTrendingHashtags(ctx context.Context, arg database.GetTrendingHashtagsParams) ([]database.GetTrendingHashtagsRow, error) {
+	t, done := s.begin()
+	defer done()
+	chirpsByTag := map[string]map[uuid.UUID]bool{}
+	for key, entity := range t.chirpEntities {
+		if entity.Kind != "hashtag" || !entity.CreatedAt.After(arg.Since) {
+			continue
+		}
+		if chirpsByTag[entity.Value] == nil {
+			chirpsByTag[entity.Value] = map[uuid.UUID]bool{}
+		}
+		chirpsByTag[entity.Value][key.chirpID] = true
+	}
+	var items []database.GetTrendingHashtagsRow
+	for tag, chirps := range chirpsByTag {
+		items = append(items, database.GetTrendingHashtagsRow{Tag: tag, ChirpCount: int64(len(chirps))})
+	}
+	slices.SortFunc(items, func(a, b database.GetTrendingHashtagsRow) int {
+		return cmp.Or(cmp.Compare(b.ChirpCount, a.ChirpCount), strings.Compare(a.Tag, b.Tag))
+	})
+	return limit(items, arg.PageSize), nil
+}
@@ -0,0 +1,123 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CountFollowers(ctx context.Context, followeeID uuid.UUID) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	var count int64
+	for key := range t.follows {
+		if key.followeeID == followeeID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) CountFollowing(ctx context.Context, followerID uuid.UUID) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	var count int64
+	for key := range t.follows {
+		if key.followerID == followerID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) FollowUser(ctx context.Context, arg database.FollowUserParams) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	if arg.FollowerID == arg.FolloweeID {
+		return 0, checkViolation("follows", "no_self_follow")
+	}
+	if _, ok := t.users[arg.FollowerID]; !ok {
+		return 0, foreignKeyViolation("follows", "fk_follower")
+	}
+	if _, ok := t.users[arg.FolloweeID]; !ok {
+		return 0, foreignKeyViolation("follows", "fk_followee")
+	}
+	key := followKey{followerID: arg.FollowerID, followeeID: arg.FolloweeID}
+	if _, ok := t.follows[key]; ok {
+		return 0, nil
+	}
+	t.follows[key] = database.Follow{FollowerID: arg.FollowerID, FolloweeID: arg.FolloweeID, CreatedAt: s.now()}
+	return 1, nil
+}
+
+func (s *Store) GetFollowers(ctx context.Context, arg database.GetFollowersParams) ([]database.GetFollowersRow, error) {
+	t, done := s.begin()
+	defer done()
+	follows := sortedFollows(t, func(follow database.Follow) uuid.UUID { return follow.FollowerID },
+		func(follow database.Follow) bool {
+			return follow.FolloweeID == arg.FolloweeID &&
+				compareKey(follow.CreatedAt, follow.FollowerID, arg.BeforeCreatedAt, arg.BeforeID) < 0
+		})
+	var items []database.GetFollowersRow
+	for _, follow := range limit(follows, arg.PageSize) {
+		user := t.users[follow.FollowerID]
+		items = append(items, database.GetFollowersRow{
+			ID:          user.ID,
+			Handle:      user.Handle,
+			DisplayName: user.DisplayName,
+			AvatarUrl:   user.AvatarUrl,
+			CreatedAt:   follow.CreatedAt,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) GetFollowing(ctx context.Context, arg database.GetFollowingParams) ([]database.GetFollowingRow, error) {
+	t, done := s.begin()
+	defer done()
+	follows := sortedFollows(t, func(follow database.Follow) uuid.UUID { return follow.FolloweeID },
+		func(follow database.Follow) bool {
+			return follow.FollowerID == arg.FollowerID &&
+				compareKey(follow.CreatedAt, follow.FolloweeID, arg.BeforeCreatedAt, arg.BeforeID) < 0
+		})
+	var items []database.GetFollowingRow
+	for _, follow := range limit(follows, arg.PageSize) {
+		user := t.users[follow.FolloweeID]
+		items = append(items, database.GetFollowingRow{
+			ID:          user.ID,
+			Handle:      user.Handle,
+			DisplayName: user.DisplayName,
+			AvatarUrl:   user.AvatarUrl,
+			CreatedAt:   follow.CreatedAt,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) UnfollowUser(ctx context.Context, arg database.UnfollowUserParams) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	key := followKey{followerID: arg.FollowerID, followeeID: arg.FolloweeID}
+	if _, ok := t.follows[key]; !ok {
+		return 0, nil
+	}
+	delete(t.follows, key)
+	return 1, nil
+}
+
+// sortedFollows returns the matching follows newest first, then by the id of
+// the user listed, as the queries do.
+func sortedFollows(t *tables, listed func(follow database.Follow) uuid.UUID, keep func(follow database.Follow) bool) []database.Follow {
+	var follows []database.Follow
+	for _, follow := range t.follows {
+		if keep(follow) {
+			follows = append(follows, follow)
+		}
+	}
+	slices.SortFunc(follows, func(a, b database.Follow) int {
+		return compareKey(b.CreatedAt, listed(b), a.CreatedAt, listed(a))
+	})
+	return follows
+}
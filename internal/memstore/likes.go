@@ -0,0 +1,58 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) GetLikedChirpIDs(ctx context.Context, arg database.GetLikedChirpIDsParams) ([]uuid.UUID, error) {
+	t, done := s.begin()
+	defer done()
+	var items []uuid.UUID
+	for key := range t.chirpLikes {
+		if key.userID == arg.UserID && slices.Contains(arg.ChirpIds, key.chirpID) {
+			items = append(items, key.chirpID)
+		}
+	}
+	return items, nil
+}
+
+// LikeChirp and UnlikeChirp return the chirp's like count afterwards, which
+// only changes if a like was added or removed.
+func (s *Store) LikeChirp(ctx context.Context, arg database.LikeChirpParams) (int32, error) {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("chirp_likes", arg.UserID); err != nil {
+		return 0, err
+	}
+	chirp, ok := t.chirps[arg.ChirpID]
+	if !ok {
+		return 0, foreignKeyViolation("chirp_likes", "fk_chirps")
+	}
+	key := userChirpKey{userID: arg.UserID, chirpID: arg.ChirpID}
+	if _, liked := t.chirpLikes[key]; !liked {
+		t.chirpLikes[key] = database.ChirpLike{UserID: arg.UserID, ChirpID: arg.ChirpID, CreatedAt: s.now()}
+		chirp.LikeCount++
+		t.chirps[chirp.ID] = chirp
+	}
+	return chirp.LikeCount, nil
+}
+
+func (s *Store) UnlikeChirp(ctx context.Context, arg database.UnlikeChirpParams) (int32, error) {
+	t, done := s.begin()
+	defer done()
+	chirp, ok := t.chirps[arg.ChirpID]
+	if !ok {
+		return notFound[int32]()
+	}
+	key := userChirpKey{userID: arg.UserID, chirpID: arg.ChirpID}
+	if _, liked := t.chirpLikes[key]; liked {
+		delete(t.chirpLikes, key)
+		chirp.LikeCount--
+		t.chirps[chirp.ID] = chirp
+	}
+	return chirp.LikeCount, nil
+}
@@ -0,0 +1,139 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) AttachMediaFile(ctx context.Context, arg database.AttachMediaFileParams) error {
+	t, done := s.begin()
+	defer done()
+	if arg.Position < 0 || arg.Position > 3 {
+		return checkViolation("chirp_attachments", "max_four_attachments")
+	}
+	if err := t.checkChirp("chirp_attachments", arg.ChirpID); err != nil {
+		return err
+	}
+	if _, ok := t.mediaFiles[arg.MediaID]; !ok {
+		return foreignKeyViolation("chirp_attachments", "fk_media_files")
+	}
+	key := attachmentKey{chirpID: arg.ChirpID, position: arg.Position}
+	if _, ok := t.chirpAttachments[key]; ok {
+		return uniqueViolation("chirp_attachments_pkey")
+	}
+	t.chirpAttachments[key] = database.ChirpAttachment(arg)
+	return nil
+}
+
+func (s *Store) CreateMediaFile(ctx context.Context, arg database.CreateMediaFileParams) (database.MediaFile, error) {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("media_files", arg.UserID); err != nil {
+		return database.MediaFile{}, err
+	}
+	if _, ok := t.mediaFiles[arg.ID]; ok {
+		return database.MediaFile{}, uniqueViolation("media_files_pkey")
+	}
+	for _, media := range t.mediaFiles {
+		if media.BlobKey == arg.BlobKey {
+			return database.MediaFile{}, uniqueViolation("media_files_blob_key_key")
+		}
+	}
+	media := database.MediaFile{
+		ID:          arg.ID,
+		CreatedAt:   s.now(),
+		UserID:      arg.UserID,
+		BlobKey:     arg.BlobKey,
+		ContentType: arg.ContentType,
+		SizeBytes:   arg.SizeBytes,
+		Width:       arg.Width,
+		Height:      arg.Height,
+	}
+	t.mediaFiles[media.ID] = media
+	return media, nil
+}
+
+func (s *Store) DeleteMediaFile(ctx context.Context, id uuid.UUID) error {
+	t, done := s.begin()
+	defer done()
+	t.deleteMediaFile(id)
+	return nil
+}
+
+func (s *Store) GetChirpAttachments(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetChirpAttachmentsRow, error) {
+	t, done := s.begin()
+	defer done()
+	attachments := []database.ChirpAttachment{}
+	for key, attachment := range t.chirpAttachments {
+		if slices.Contains(chirpIds, key.chirpID) {
+			attachments = append(attachments, attachment)
+		}
+	}
+	slices.SortFunc(attachments, func(a, b database.ChirpAttachment) int {
+		return cmp.Or(compareUUID(a.ChirpID, b.ChirpID), cmp.Compare(a.Position, b.Position))
+	})
+	var items []database.GetChirpAttachmentsRow
+	for _, attachment := range attachments {
+		items = append(items, database.GetChirpAttachmentsRow{
+			ChirpID:   attachment.ChirpID,
+			MediaFile: t.mediaFiles[attachment.MediaID],
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) GetCollectableMediaFiles(ctx context.Context, arg database.GetCollectableMediaFilesParams) ([]database.MediaFile, error) {
+	t, done := s.begin()
+	defer done()
+	attached := map[uuid.UUID]bool{}
+	for _, attachment := range t.chirpAttachments {
+		attached[attachment.MediaID] = true
+	}
+	var items []database.MediaFile
+	for _, media := range t.mediaFiles {
+		owner := t.users[media.UserID]
+		purgeable := arg.DeletedBefore.Valid && owner.DeletedAt.Valid && owner.DeletedAt.Time.Before(arg.DeletedBefore.Time)
+		if purgeable || (media.CreatedAt.Before(arg.UploadedBefore) && !attached[media.ID]) {
+			items = append(items, media)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) GetMediaFileByID(ctx context.Context, id uuid.UUID) (database.MediaFile, error) {
+	t, done := s.begin()
+	defer done()
+	media, ok := t.mediaFiles[id]
+	if !ok {
+		return notFound[database.MediaFile]()
+	}
+	return media, nil
+}
+
+func (s *Store) GetMediaFilesByIDs(ctx context.Context, ids []uuid.UUID) ([]database.MediaFile, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.MediaFile
+	for id, media := range t.mediaFiles {
+		if slices.Contains(ids, id) {
+			items = append(items, media)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) GetMediaFilesByUser(ctx context.Context, userID uuid.UUID) ([]database.MediaFile, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.MediaFile
+	for _, media := range t.mediaFiles {
+		if media.UserID == userID {
+			items = append(items, media)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,361 @@
+// Package memstore is a database.Store that keeps everything in memory, for
+// tests and for running the server without Postgres. It enforces the same
+// keys, foreign keys and cascades as the schema and orders results the same
+// way, but nothing survives a restart.
+//
+// Full-text search matches whole lowercased words with no stemming or stop
+// words, so it finds less than Postgres's english configuration does.
+package memstore
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+// Store is safe for concurrent use. Each query runs atomically, and WithTx
+// holds every other caller off until fn returns, so fn must only use the
+// Querier it's given.
+type Store struct {
+	db *db
+	// The transaction's copy of the tables; nil outside a transaction.
+	tx *tables
+}
+
+type db struct {
+	mu     sync.Mutex
+	tables *tables
+	// NOW() for whichever query or transaction holds mu.
+	now time.Time
+}
+
+type tables struct {
+	users               map[uuid.UUID]database.User
+	chirps              map[uuid.UUID]database.Chirp
+	refreshTokens       map[string]database.RefreshToken
+	recoveryCodes       map[uuid.UUID]database.RecoveryCode
+	twoFactorChallenges map[string]database.TwoFactorChallenge
+	oauthClients        map[uuid.UUID]database.OauthClient
+	oauthCodes          map[string]database.OauthAuthorizationCode
+	apiKeys             map[uuid.UUID]database.ApiKey
+	follows             map[followKey]database.Follow
+	timelineEntries     map[userChirpKey]database.TimelineEntry
+	chirpLikes          map[userChirpKey]database.ChirpLike
+	chirpEntities       map[entityKey]database.ChirpEntity
+	notifications       map[uuid.UUID]database.Notification
+	preferences         map[preferenceKey]database.NotificationPreference
+	mediaFiles          map[uuid.UUID]database.MediaFile
+	chirpAttachments    map[attachmentKey]database.ChirpAttachment
+}
+
+type followKey struct {
+	followerID uuid.UUID
+	followeeID uuid.UUID
+}
+
+type userChirpKey struct {
+	userID  uuid.UUID
+	chirpID uuid.UUID
+}
+
+type entityKey struct {
+	chirpID     uuid.UUID
+	startOffset int32
+}
+
+type preferenceKey struct {
+	userID uuid.UUID
+	kind   string
+}
+
+type attachmentKey struct {
+	chirpID  uuid.UUID
+	position int32
+}
+
+var _ database.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{db: &db{tables: &tables{
+		users:               map[uuid.UUID]database.User{},
+		chirps:              map[uuid.UUID]database.Chirp{},
+		refreshTokens:       map[string]database.RefreshToken{},
+		recoveryCodes:       map[uuid.UUID]database.RecoveryCode{},
+		twoFactorChallenges: map[string]database.TwoFactorChallenge{},
+		oauthClients:        map[uuid.UUID]database.OauthClient{},
+		oauthCodes:          map[string]database.OauthAuthorizationCode{},
+		apiKeys:             map[uuid.UUID]database.ApiKey{},
+		follows:             map[followKey]database.Follow{},
+		timelineEntries:     map[userChirpKey]database.TimelineEntry{},
+		chirpLikes:          map[userChirpKey]database.ChirpLike{},
+		chirpEntities:       map[entityKey]database.ChirpEntity{},
+		notifications:       map[uuid.UUID]database.Notification{},
+		preferences:         map[preferenceKey]database.NotificationPreference{},
+		mediaFiles:          map[uuid.UUID]database.MediaFile{},
+		chirpAttachments:    map[attachmentKey]database.ChirpAttachment{},
+	}}}
+}
+
+// WithTx runs fn against a copy of the tables and keeps the copy only if fn
+// succeeds, so a failed transaction leaves nothing behind.
+func (s *Store) WithTx(ctx context.Context, fn func(ctx context.Context, queries database.Querier) error) error {
+	if s.tx != nil {
+		return fn(ctx, s)
+	}
+
+	ctx, runHooks := database.TrackAfterCommit(ctx)
+	err := func() error {
+		s.db.mu.Lock()
+		defer s.db.mu.Unlock()
+		s.db.now = postgresNow()
+		tx := &Store{db: s.db, tx: s.db.tables.clone()}
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		s.db.tables = tx.tx
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	runHooks()
+	return nil
+}
+
+// begin returns the tables a query should use and a func to call when it's
+// done with them.
+func (s *Store) begin() (*tables, func()) {
+	if s.tx != nil {
+		return s.tx, func() {}
+	}
+	s.db.mu.Lock()
+	s.db.now = postgresNow()
+	return s.db.tables, s.db.mu.Unlock
+}
+
+// now stands in for NOW(). As in Postgres it's the time the transaction
+// started, so every row a transaction makes has the same created_at, and rows
+// made one after another can tie too. Callers must hold the lock.
+func (s *Store) now() time.Time {
+	return s.db.now
+}
+
+func postgresNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func (t *tables) clone() *tables {
+	return &tables{
+		users:               maps.Clone(t.users),
+		chirps:              maps.Clone(t.chirps),
+		refreshTokens:       maps.Clone(t.refreshTokens),
+		recoveryCodes:       maps.Clone(t.recoveryCodes),
+		twoFactorChallenges: maps.Clone(t.twoFactorChallenges),
+		oauthClients:        maps.Clone(t.oauthClients),
+		oauthCodes:          maps.Clone(t.oauthCodes),
+		apiKeys:             maps.Clone(t.apiKeys),
+		follows:             maps.Clone(t.follows),
+		timelineEntries:     maps.Clone(t.timelineEntries),
+		chirpLikes:          maps.Clone(t.chirpLikes),
+		chirpEntities:       maps.Clone(t.chirpEntities),
+		notifications:       maps.Clone(t.notifications),
+		preferences:         maps.Clone(t.preferences),
+		mediaFiles:          maps.Clone(t.mediaFiles),
+		chirpAttachments:    maps.Clone(t.chirpAttachments),
+	}
+}
+
+// deleteUser removes a user and, like the ON DELETE CASCADE foreign keys,
+// everything that points at them. As in the queries that delete users, the
+// chirps they liked or rechirped lose those from their counts.
+func (t *tables) deleteUser(id uuid.UUID) {
+	delete(t.users, id)
+	for key := range t.chirpLikes {
+		if chirp, ok := t.chirps[key.chirpID]; ok && key.userID == id {
+			chirp.LikeCount--
+			t.chirps[key.chirpID] = chirp
+		}
+	}
+	for _, rechirp := range t.chirps {
+		if original, ok := t.chirps[rechirp.RechirpOfID.UUID]; ok && rechirp.UserID == id && rechirp.RechirpOfID.Valid {
+			original.RechirpCount--
+			t.chirps[original.ID] = original
+		}
+	}
+	for chirpID, chirp := range t.chirps {
+		if chirp.UserID == id {
+			t.deleteChirp(chirpID)
+		}
+	}
+	for clientID, client := range t.oauthClients {
+		if client.OwnerID == id {
+			t.deleteOAuthClient(clientID)
+		}
+	}
+	for mediaID, media := range t.mediaFiles {
+		if media.UserID == id {
+			t.deleteMediaFile(mediaID)
+		}
+	}
+	maps.DeleteFunc(t.refreshTokens, func(_ string, token database.RefreshToken) bool {
+		return token.UserID == id
+	})
+	maps.DeleteFunc(t.recoveryCodes, func(_ uuid.UUID, code database.RecoveryCode) bool {
+		return code.UserID == id
+	})
+	maps.DeleteFunc(t.twoFactorChallenges, func(_ string, challenge database.TwoFactorChallenge) bool {
+		return challenge.UserID == id
+	})
+	maps.DeleteFunc(t.oauthCodes, func(_ string, code database.OauthAuthorizationCode) bool {
+		return code.UserID == id
+	})
+	maps.DeleteFunc(t.apiKeys, func(_ uuid.UUID, key database.ApiKey) bool {
+		return key.UserID == id
+	})
+	maps.DeleteFunc(t.follows, func(key followKey, _ database.Follow) bool {
+		return key.followerID == id || key.followeeID == id
+	})
+	maps.DeleteFunc(t.timelineEntries, func(key userChirpKey, _ database.TimelineEntry) bool {
+		return key.userID == id
+	})
+	maps.DeleteFunc(t.chirpLikes, func(key userChirpKey, _ database.ChirpLike) bool {
+		return key.userID == id
+	})
+	maps.DeleteFunc(t.chirpEntities, func(_ entityKey, entity database.ChirpEntity) bool {
+		return entity.UserID.Valid && entity.UserID.UUID == id
+	})
+	maps.DeleteFunc(t.notifications, func(_ uuid.UUID, notification database.Notification) bool {
+		return notification.UserID == id || notification.ActorID == id
+	})
+	maps.DeleteFunc(t.preferences, func(key preferenceKey, _ database.NotificationPreference) bool {
+		return key.userID == id
+	})
+}
+
+// deleteChirp cascades to rechirps and anything keyed on the chirp, and
+// clears replies' and quotes' links to it.
+func (t *tables) deleteChirp(id uuid.UUID) {
+	if _, ok := t.chirps[id]; !ok {
+		return
+	}
+	delete(t.chirps, id)
+	for otherID, other := range t.chirps {
+		if other.RechirpOfID.Valid && other.RechirpOfID.UUID == id {
+			t.deleteChirp(otherID)
+			continue
+		}
+		if other.ReplyToID.Valid && other.ReplyToID.UUID == id {
+			other.ReplyToID = uuid.NullUUID{}
+		}
+		if other.QuoteOfID.Valid && other.QuoteOfID.UUID == id {
+			other.QuoteOfID = uuid.NullUUID{}
+		}
+		if _, ok := t.chirps[otherID]; ok {
+			t.chirps[otherID] = other
+		}
+	}
+	maps.DeleteFunc(t.timelineEntries, func(key userChirpKey, _ database.TimelineEntry) bool {
+		return key.chirpID == id
+	})
+	maps.DeleteFunc(t.chirpLikes, func(key userChirpKey, _ database.ChirpLike) bool {
+		return key.chirpID == id
+	})
+	maps.DeleteFunc(t.chirpEntities, func(key entityKey, _ database.ChirpEntity) bool {
+		return key.chirpID == id
+	})
+	maps.DeleteFunc(t.notifications, func(_ uuid.UUID, notification database.Notification) bool {
+		return notification.ChirpID.Valid && notification.ChirpID.UUID == id
+	})
+	maps.DeleteFunc(t.chirpAttachments, func(key attachmentKey, _ database.ChirpAttachment) bool {
+		return key.chirpID == id
+	})
+}
+
+func (t *tables) deleteOAuthClient(id uuid.UUID) {
+	delete(t.oauthClients, id)
+	maps.DeleteFunc(t.oauthCodes, func(_ string, code database.OauthAuthorizationCode) bool {
+		return code.ClientID == id
+	})
+	maps.DeleteFunc(t.refreshTokens, func(_ string, token database.RefreshToken) bool {
+		return token.ClientID.Valid && token.ClientID.UUID == id
+	})
+}
+
+func (t *tables) deleteMediaFile(id uuid.UUID) {
+	delete(t.mediaFiles, id)
+	maps.DeleteFunc(t.chirpAttachments, func(_ attachmentKey, attachment database.ChirpAttachment) bool {
+		return attachment.MediaID == id
+	})
+}
+
+func (t *tables) checkUser(table string, id uuid.UUID) error {
+	if _, ok := t.users[id]; !ok {
+		return foreignKeyViolation(table, "fk_users")
+	}
+	return nil
+}
+
+func (t *tables) checkChirp(table string, id uuid.UUID) error {
+	if _, ok := t.chirps[id]; !ok {
+		return foreignKeyViolation(table, "fk_chirps")
+	}
+	return nil
+}
+
+func uniqueViolation(constraint string) error {
+	return fmt.Errorf("%w %q", database.ErrUniqueViolation, constraint)
+}
+
+func foreignKeyViolation(table, constraint string) error {
+	return fmt.Errorf("insert or update on table %q %w %q", table, database.ErrForeignKeyViolation, constraint)
+}
+
+func checkViolation(table, constraint string) error {
+	return fmt.Errorf("new row for relation %q %w %q", table, database.ErrCheckViolation, constraint)
+}
+
+// Postgres compares UUIDs byte by byte.
+func compareUUID(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// compareKey orders rows by (created_at, id), the keyset the paginated
+// queries sort and seek on.
+func compareKey(createdAt time.Time, id uuid.UUID, otherCreatedAt time.Time, otherID uuid.UUID) int {
+	return cmp.Or(createdAt.Compare(otherCreatedAt), compareUUID(id, otherID))
+}
+
+// Rows never tie on created_at, because now never repeats itself.
+func sortByCreatedAt[T any](rows []T, createdAt func(row T) time.Time) {
+	slices.SortFunc(rows, func(a, b T) int {
+		return createdAt(a).Compare(createdAt(b))
+	})
+}
+
+func limit[T any](rows []T, size int32) []T {
+	if int(size) < len(rows) {
+		return rows[:max(size, 0)]
+	}
+	return rows
+}
+
+func offset[T any](rows []T, skip int32) []T {
+	if int(skip) > len(rows) {
+		return nil
+	}
+	return rows[max(skip, 0):]
+}
+
+func notFound[T any]() (T, error) {
+	var zero T
+	return zero, sql.ErrNoRows
+}
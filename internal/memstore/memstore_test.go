@@ -0,0 +1,290 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func createUser(t *testing.T, store *Store, email string) database.User {
+	t.Helper()
+	user, err := store.CreateUser(context.Background(), database.CreateUserParams{Email: email, HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser(%q): %v", email, err)
+	}
+	return user
+}
+
+func createChirp(t *testing.T, store *Store, params database.CreateChirpParams) database.Chirp {
+	t.Helper()
+	chirp, err := store.CreateChirp(context.Background(), params)
+	if err != nil {
+		t.Fatalf("CreateChirp: %v", err)
+	}
+	return chirp
+}
+
+func TestEmailsAreUnique(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	createUser(t, store, "a@example.com")
+	other := createUser(t, store, "b@example.com")
+
+	if _, err := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"}); !errors.Is(err, database.ErrUniqueViolation) {
+		t.Fatalf("duplicate CreateUser = %v, want a unique violation", err)
+	}
+	_, err := store.UpdateUserEmailAndPassword(ctx, database.UpdateUserEmailAndPasswordParams{ID: other.ID, Email: "a@example.com"})
+	if !errors.Is(err, database.ErrUniqueViolation) {
+		t.Fatalf("UpdateUserEmailAndPassword to a taken email = %v, want a unique violation", err)
+	}
+	if _, err := store.UpdateUserEmailAndPassword(ctx, database.UpdateUserEmailAndPasswordParams{ID: other.ID, Email: "b@example.com"}); err != nil {
+		t.Fatalf("keeping your own email: %v", err)
+	}
+}
+
+func TestMissingRowsAreErrNoRows(t *testing.T) {
+	store := New()
+	if _, err := store.GetUserByID(context.Background(), uuid.New()); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetUserByID = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := store.CreateChirp(context.Background(), database.CreateChirpParams{UserID: uuid.New()}); !errors.Is(err, database.ErrForeignKeyViolation) {
+		t.Fatalf("CreateChirp for a missing user = %v, want a foreign key violation", err)
+	}
+}
+
+func TestDeleteUserCascades(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	author := createUser(t, store, "author@example.com")
+	reader := createUser(t, store, "reader@example.com")
+	chirp := createChirp(t, store, database.CreateChirpParams{Body: "hello", UserID: author.ID})
+	reply := createChirp(t, store, database.CreateChirpParams{
+		Body:      "hi back",
+		UserID:    reader.ID,
+		ReplyToID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+	})
+	rechirp, err := store.CreateRechirp(ctx, database.CreateRechirpParams{UserID: reader.ID, RechirpOfID: uuid.NullUUID{UUID: chirp.ID, Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.FollowUser(ctx, database.FollowUserParams{FollowerID: reader.ID, FolloweeID: author.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.LikeChirp(ctx, database.LikeChirpParams{UserID: reader.ID, ChirpID: chirp.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted, err := store.DeleteUser(ctx, author.ID); err != nil || deleted != 1 {
+		t.Fatalf("DeleteUser = %d, %v", deleted, err)
+	}
+	if _, err := store.GetChirpById(ctx, chirp.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatal("author's chirp survived")
+	}
+	if _, err := store.GetChirpById(ctx, rechirp.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatal("rechirp of a deleted chirp survived")
+	}
+	kept, err := store.GetChirpById(ctx, reply.ID)
+	if err != nil || kept.ReplyToID.Valid {
+		t.Fatalf("reply = %+v, %v; want it kept with no parent", kept, err)
+	}
+	if count, _ := store.CountFollowing(ctx, reader.ID); count != 0 {
+		t.Fatalf("reader still follows %d users", count)
+	}
+	if liked, _ := store.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{UserID: reader.ID, ChirpIds: []uuid.UUID{chirp.ID}}); len(liked) != 0 {
+		t.Fatal("like on a deleted chirp survived")
+	}
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	failed := errors.New("failed")
+	ran := false
+
+	err := store.WithTx(ctx, func(ctx context.Context, queries database.Querier) error {
+		if _, err := queries.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"}); err != nil {
+			return err
+		}
+		database.AfterCommit(ctx, func() { ran = true })
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("WithTx = %v, want %v", err, failed)
+	}
+	if _, err := store.GetUserByEmail(ctx, "a@example.com"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatal("rolled back user was kept")
+	}
+	if ran {
+		t.Fatal("after-commit hook ran on rollback")
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, queries database.Querier) error {
+		_, err := queries.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+		database.AfterCommit(ctx, func() { ran = true })
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetUserByEmail(ctx, "a@example.com"); err != nil {
+		t.Fatalf("committed user: %v", err)
+	}
+	if !ran {
+		t.Fatal("after-commit hook didn't run")
+	}
+}
+
+func TestRechirpsAndLikesKeepCounts(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	user := createUser(t, store, "a@example.com")
+	chirp := createChirp(t, store, database.CreateChirpParams{Body: "hello", UserID: user.ID})
+	of := uuid.NullUUID{UUID: chirp.ID, Valid: true}
+
+	if _, err := store.CreateRechirp(ctx, database.CreateRechirpParams{UserID: user.ID, RechirpOfID: of}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateRechirp(ctx, database.CreateRechirpParams{UserID: user.ID, RechirpOfID: of}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("second rechirp = %v, want sql.ErrNoRows", err)
+	}
+	if got, _ := store.GetChirpById(ctx, chirp.ID); got.RechirpCount != 1 {
+		t.Fatalf("rechirp count = %d, want 1", got.RechirpCount)
+	}
+	if removed, _ := store.DeleteRechirp(ctx, database.DeleteRechirpParams{UserID: user.ID, RechirpOfID: of}); removed != 1 {
+		t.Fatalf("DeleteRechirp = %d, want 1", removed)
+	}
+	if removed, _ := store.DeleteRechirp(ctx, database.DeleteRechirpParams{UserID: user.ID, RechirpOfID: of}); removed != 0 {
+		t.Fatalf("second DeleteRechirp = %d, want 0", removed)
+	}
+
+	like := database.LikeChirpParams{UserID: user.ID, ChirpID: chirp.ID}
+	store.LikeChirp(ctx, like)
+	if count, _ := store.LikeChirp(ctx, like); count != 1 {
+		t.Fatalf("liking twice counted %d", count)
+	}
+	if count, _ := store.UnlikeChirp(ctx, database.UnlikeChirpParams(like)); count != 0 {
+		t.Fatalf("like count after unlike = %d", count)
+	}
+}
+
+// The chirps are made in one transaction, so as in Postgres they share
+// created_at and the id decides their order.
+func TestTimelinePagesNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	reader := createUser(t, store, "reader@example.com")
+	author := createUser(t, store, "author@example.com")
+	store.FollowUser(ctx, database.FollowUserParams{FollowerID: reader.ID, FolloweeID: author.ID})
+	chirps := []database.Chirp{}
+	err := store.WithTx(ctx, func(ctx context.Context, queries database.Querier) error {
+		for range 3 {
+			chirp, err := queries.CreateChirp(ctx, database.CreateChirpParams{Body: "chirp", UserID: author.ID})
+			if err != nil {
+				return err
+			}
+			chirps = append(chirps, chirp)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chirps[0].CreatedAt.Equal(chirps[2].CreatedAt) {
+		t.Fatal("chirps made in one transaction have different created_at")
+	}
+	slices.SortFunc(chirps, func(a, b database.Chirp) int { return compareUUID(a.ID, b.ID) })
+
+	page, err := store.GetTimelineByJoin(ctx, database.GetTimelineByJoinParams{
+		UserID:          reader.ID,
+		BeforeCreatedAt: time.Now().Add(time.Hour),
+		BeforeID:        uuid.Max,
+		PageSize:        2,
+	})
+	if err != nil || len(page) != 2 || page[0].ID != chirps[2].ID || page[1].ID != chirps[1].ID {
+		t.Fatalf("first page = %v, %v", page, err)
+	}
+	page, _ = store.GetTimelineByJoin(ctx, database.GetTimelineByJoinParams{
+		UserID:          reader.ID,
+		BeforeCreatedAt: page[1].CreatedAt,
+		BeforeID:        page[1].ID,
+		PageSize:        2,
+	})
+	if len(page) != 1 || page[0].ID != chirps[0].ID {
+		t.Fatalf("second page = %v", page)
+	}
+}
+
+func TestSearchChirps(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	user := createUser(t, store, "a@example.com")
+	createChirp(t, store, database.CreateChirpParams{Body: "Hello world, hello Go", UserID: user.ID})
+	createChirp(t, store, database.CreateChirpParams{Body: "world hello", UserID: user.ID})
+	createChirp(t, store, database.CreateChirpParams{Body: "Gophers say hello", UserID: user.ID})
+
+	search := func(query string) []database.SearchChirpsRow {
+		rows, err := store.SearchChirps(ctx, database.SearchChirpsParams{
+			Query:    query,
+			Until:    time.Now().Add(time.Hour),
+			PageSize: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rows
+	}
+
+	if rows := search("(hello <-> world)"); len(rows) != 1 || rows[0].Headline != "\uE000Hello\uE001 \uE000world\uE001, \uE000hello\uE001 Go" {
+		t.Fatalf("phrase search = %+v", rows)
+	}
+	if rows := search("hello & !world"); len(rows) != 1 || rows[0].Chirp.Body != "Gophers say hello" {
+		t.Fatalf("negated search = %+v", rows)
+	}
+	if rows := search("go:*"); len(rows) != 2 {
+		t.Fatalf("prefix search found %d chirps, want 2", len(rows))
+	}
+}
+
+func TestLike(t *testing.T) {
+	cases := []struct {
+		text, pattern string
+		want          bool
+	}{
+		{"alice", "al%", true},
+		{"alice", "bo%", false},
+		{"a_b", `a\_%`, true},
+		{"axb", `a\_%`, false},
+		{"axb", "a_b", true},
+		{"50%", `50\%`, true},
+		{"500", `50\%`, false},
+	}
+	for _, c := range cases {
+		if got := like(c.text, c.pattern); got != c.want {
+			t.Errorf("like(%q, %q) = %v, want %v", c.text, c.pattern, got, c.want)
+		}
+	}
+}
+
+func TestDeleteUserLowersCounts(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	author := createUser(t, store, "author@example.com")
+	fan := createUser(t, store, "fan@example.com")
+	chirp := createChirp(t, store, database.CreateChirpParams{Body: "hello", UserID: author.ID})
+	store.LikeChirp(ctx, database.LikeChirpParams{UserID: fan.ID, ChirpID: chirp.ID})
+	store.CreateRechirp(ctx, database.CreateRechirpParams{UserID: fan.ID, RechirpOfID: uuid.NullUUID{UUID: chirp.ID, Valid: true}})
+
+	if _, err := store.DeleteUser(ctx, fan.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetChirpById(ctx, chirp.ID)
+	if err != nil || got.LikeCount != 0 || got.RechirpCount != 0 {
+		t.Fatalf("chirp = %+v, %v; want its counts back at 0", got, err)
+	}
+}
@@ -0,0 +1,150 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	var count int64
+	for _, notification := range t.notifications {
+		if notification.UserID == userID && !notification.ReadAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateNotification returns no rows when the actor is the user, the user has
+// turned the type off, or an identical notification already exists.
+func (s *Store) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) ([]database.Notification, error) {
+	t, done := s.begin()
+	defer done()
+	if arg.UserID == arg.ActorID {
+		return nil, nil
+	}
+	preference, ok := t.preferences[preferenceKey{userID: arg.UserID, kind: arg.Type}]
+	if ok && !preference.Enabled {
+		return nil, nil
+	}
+	if err := t.checkUser("notifications", arg.UserID); err != nil {
+		return nil, err
+	}
+	if _, ok := t.users[arg.ActorID]; !ok {
+		return nil, foreignKeyViolation("notifications", "fk_actors")
+	}
+	if arg.ChirpID.Valid {
+		if err := t.checkChirp("notifications", arg.ChirpID.UUID); err != nil {
+			return nil, err
+		}
+	}
+	for _, notification := range t.notifications {
+		if notification.UserID == arg.UserID && notification.ActorID == arg.ActorID &&
+			notification.Type == arg.Type && notification.ChirpID.UUID == arg.ChirpID.UUID {
+			return nil, nil
+		}
+	}
+	notification := database.Notification{
+		ID:        uuid.New(),
+		CreatedAt: s.now(),
+		UserID:    arg.UserID,
+		ActorID:   arg.ActorID,
+		Type:      arg.Type,
+		ChirpID:   arg.ChirpID,
+	}
+	t.notifications[notification.ID] = notification
+	return []database.Notification{notification}, nil
+}
+
+func (s *Store) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]database.NotificationPreference, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.NotificationPreference
+	for key, preference := range t.preferences {
+		if key.userID == userID {
+			items = append(items, preference)
+		}
+	}
+	slices.SortFunc(items, func(a, b database.NotificationPreference) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	return items, nil
+}
+
+func (s *Store) GetNotifications(ctx context.Context, arg database.GetNotificationsParams) ([]database.GetNotificationsRow, error) {
+	t, done := s.begin()
+	defer done()
+	notifications := []database.Notification{}
+	for _, notification := range t.notifications {
+		if notification.UserID != arg.UserID || (arg.UnreadOnly && notification.ReadAt.Valid) {
+			continue
+		}
+		if compareKey(notification.CreatedAt, notification.ID, arg.BeforeCreatedAt, arg.BeforeID) < 0 {
+			notifications = append(notifications, notification)
+		}
+	}
+	slices.SortFunc(notifications, func(a, b database.Notification) int {
+		return compareKey(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	var items []database.GetNotificationsRow
+	for _, notification := range limit(notifications, arg.PageSize) {
+		actor := t.users[notification.ActorID]
+		items = append(items, database.GetNotificationsRow{
+			ID:               notification.ID,
+			CreatedAt:        notification.CreatedAt,
+			UserID:           notification.UserID,
+			ActorID:          notification.ActorID,
+			Type:             notification.Type,
+			ChirpID:          notification.ChirpID,
+			ReadAt:           notification.ReadAt,
+			ActorHandle:      actor.Handle,
+			ActorDisplayName: actor.DisplayName,
+			ActorAvatarUrl:   actor.AvatarUrl,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.markNotificationsRead(func(notification database.Notification) bool {
+		return notification.UserID == userID
+	})
+}
+
+func (s *Store) MarkNotificationsRead(ctx context.Context, arg database.MarkNotificationsReadParams) (int64, error) {
+	return s.markNotificationsRead(func(notification database.Notification) bool {
+		return notification.UserID == arg.UserID && slices.Contains(arg.Ids, notification.ID)
+	})
+}
+
+func (s *Store) SetNotificationPreference(ctx context.Context, arg database.SetNotificationPreferenceParams) error {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("notification_preferences", arg.UserID); err != nil {
+		return err
+	}
+	t.preferences[preferenceKey{userID: arg.UserID, kind: arg.Type}] = database.NotificationPreference(arg)
+	return nil
+}
+
+func (s *Store) markNotificationsRead(keep func(notification database.Notification) bool) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	now := s.now()
+	var marked int64
+	for id, notification := range t.notifications {
+		if keep(notification) && !notification.ReadAt.Valid {
+			notification.ReadAt = sql.NullTime{Time: now, Valid: true}
+			t.notifications[id] = notification
+			marked++
+		}
+	}
+	return marked, nil
+}
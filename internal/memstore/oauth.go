@@ -0,0 +1,113 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) AddOAuthCode(ctx context.Context, arg database.AddOAuthCodeParams) error {
+	t, done := s.begin()
+	defer done()
+	if _, ok := t.oauthClients[arg.ClientID]; !ok {
+		return foreignKeyViolation("oauth_authorization_codes", "fk_oauth_clients")
+	}
+	if err := t.checkUser("oauth_authorization_codes", arg.UserID); err != nil {
+		return err
+	}
+	if _, ok := t.oauthCodes[arg.Code]; ok {
+		return uniqueViolation("oauth_authorization_codes_pkey")
+	}
+	t.oauthCodes[arg.Code] = database.OauthAuthorizationCode{
+		Code:                arg.Code,
+		CreatedAt:           s.now(),
+		ClientID:            arg.ClientID,
+		UserID:              arg.UserID,
+		RedirectUri:         arg.RedirectUri,
+		Scope:               arg.Scope,
+		CodeChallenge:       arg.CodeChallenge,
+		CodeChallengeMethod: arg.CodeChallengeMethod,
+		ExpiresAt:           arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (s *Store) CreateOAuthClient(ctx context.Context, arg database.CreateOAuthClientParams) (database.OauthClient, error) {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("oauth_clients", arg.OwnerID); err != nil {
+		return database.OauthClient{}, err
+	}
+	now := s.now()
+	client := database.OauthClient{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		OwnerID:      arg.OwnerID,
+		Name:         arg.Name,
+		SecretHash:   arg.SecretHash,
+		RedirectUris: arg.RedirectUris,
+		Scope:        arg.Scope,
+	}
+	t.oauthClients[client.ID] = client
+	return client, nil
+}
+
+func (s *Store) DeleteOAuthClient(ctx context.Context, arg database.DeleteOAuthClientParams) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	client, ok := t.oauthClients[arg.ID]
+	if !ok || client.OwnerID != arg.OwnerID {
+		return 0, nil
+	}
+	t.deleteOAuthClient(client.ID)
+	return 1, nil
+}
+
+func (s *Store) GetOAuthClient(ctx context.Context, id uuid.UUID) (database.OauthClient, error) {
+	t, done := s.begin()
+	defer done()
+	client, ok := t.oauthClients[id]
+	if !ok {
+		return notFound[database.OauthClient]()
+	}
+	return client, nil
+}
+
+func (s *Store) GetOAuthClientsByOwner(ctx context.Context, ownerID uuid.UUID) ([]database.OauthClient, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.OauthClient
+	for _, client := range t.oauthClients {
+		if client.OwnerID == ownerID {
+			items = append(items, client)
+		}
+	}
+	sortByCreatedAt(items, func(client database.OauthClient) time.Time { return client.CreatedAt })
+	return items, nil
+}
+
+func (s *Store) GetOAuthCode(ctx context.Context, code string) (database.OauthAuthorizationCode, error) {
+	t, done := s.begin()
+	defer done()
+	authorizationCode, ok := t.oauthCodes[code]
+	if !ok {
+		return notFound[database.OauthAuthorizationCode]()
+	}
+	return authorizationCode, nil
+}
+
+func (s *Store) UseOAuthCode(ctx context.Context, code string) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	authorizationCode, ok := t.oauthCodes[code]
+	if !ok || authorizationCode.UsedAt.Valid {
+		return 0, nil
+	}
+	authorizationCode.UsedAt = sql.NullTime{Time: s.now(), Valid: true}
+	t.oauthCodes[code] = authorizationCode
+	return 1, nil
+}
@@ -0,0 +1,99 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) AddOAuthRefreshToken(ctx context.Context, arg database.AddOAuthRefreshTokenParams) error {
+	t, done := s.begin()
+	defer done()
+	if _, ok := t.oauthClients[arg.ClientID.UUID]; arg.ClientID.Valid && !ok {
+		return foreignKeyViolation("refresh_tokens", "fk_oauth_clients")
+	}
+	return s.addRefreshToken(t, database.RefreshToken{
+		Token:     arg.Token,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+		ClientID:  arg.ClientID,
+		Scope:     arg.Scope,
+	})
+}
+
+func (s *Store) AddRefreshToken(ctx context.Context, arg database.AddRefreshTokenParams) error {
+	t, done := s.begin()
+	defer done()
+	return s.addRefreshToken(t, database.RefreshToken{
+		Token:     arg.Token,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	})
+}
+
+func (s *Store) GetToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	t, done := s.begin()
+	defer done()
+	refreshToken, ok := t.refreshTokens[token]
+	if !ok {
+		return notFound[database.RefreshToken]()
+	}
+	return refreshToken, nil
+}
+
+func (s *Store) GetTokensByUser(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.RefreshToken
+	for _, token := range t.refreshTokens {
+		if token.UserID == userID {
+			items = append(items, token)
+		}
+	}
+	sortByCreatedAt(items, func(token database.RefreshToken) time.Time { return token.CreatedAt })
+	return items, nil
+}
+
+func (s *Store) RevokeToken(ctx context.Context, token string) error {
+	t, done := s.begin()
+	defer done()
+	refreshToken, ok := t.refreshTokens[token]
+	if !ok {
+		return nil
+	}
+	now := s.now()
+	refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	refreshToken.UpdatedAt = now
+	t.refreshTokens[token] = refreshToken
+	return nil
+}
+
+func (s *Store) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	t, done := s.begin()
+	defer done()
+	now := s.now()
+	for token, refreshToken := range t.refreshTokens {
+		if refreshToken.UserID == userID && !refreshToken.RevokedAt.Valid {
+			refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			refreshToken.UpdatedAt = now
+			t.refreshTokens[token] = refreshToken
+		}
+	}
+	return nil
+}
+
+func (s *Store) addRefreshToken(t *tables, token database.RefreshToken) error {
+	if err := t.checkUser("refresh_tokens", token.UserID); err != nil {
+		return err
+	}
+	if _, ok := t.refreshTokens[token.Token]; ok {
+		return uniqueViolation("refresh_tokens_pkey")
+	}
+	token.CreatedAt = s.now()
+	token.UpdatedAt = token.CreatedAt
+	t.refreshTokens[token.Token] = token
+	return nil
+}
@@ -0,0 +1,169 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+)
+
+// tsQuery is the subset of to_tsquery that search.ParseQuery writes: clauses
+// joined by &, each a word or a (phrase <-> of <-> words), optionally negated
+// with ! and with :* on a word to match it as a prefix.
+type tsQuery []tsClause
+
+type tsClause struct {
+	negated bool
+	words   []tsWord
+}
+
+type tsWord struct {
+	text   string
+	prefix bool
+}
+
+// SearchChirps ranks a chirp by the share of its words that match the query
+// and marks every matching word in the headline.
+func (s *Store) SearchChirps(ctx context.Context, arg database.SearchChirpsParams) ([]database.SearchChirpsRow, error) {
+	t, done := s.begin()
+	defer done()
+	query := parseTSQuery(arg.Query)
+	var items []database.SearchChirpsRow
+	for _, chirp := range t.chirps {
+		if chirp.DeletedAt.Valid || (arg.AuthorID.Valid && chirp.UserID != arg.AuthorID.UUID) {
+			continue
+		}
+		if chirp.CreatedAt.Before(arg.Since) || !chirp.CreatedAt.Before(arg.Until) {
+			continue
+		}
+		words := tsWords(chirp.Body)
+		if !query.matches(words) {
+			continue
+		}
+		matched := 0
+		for _, word := range words {
+			if query.highlights(word) {
+				matched++
+			}
+		}
+		items = append(items, database.SearchChirpsRow{
+			Chirp:    chirp,
+			Rank:     float32(matched) / float32(len(words)),
+			Headline: query.headline(chirp.Body),
+		})
+	}
+	slices.SortFunc(items, func(a, b database.SearchChirpsRow) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), newestFirst(a.Chirp, b.Chirp))
+	})
+	return limit(offset(items, arg.PageOffset), arg.PageSize), nil
+}
+
+func parseTSQuery(query string) tsQuery {
+	parsed := tsQuery{}
+	for _, clauseText := range strings.Split(query, "&") {
+		clauseText = strings.TrimSpace(clauseText)
+		clause := tsClause{}
+		if strings.HasPrefix(clauseText, "!") {
+			clause.negated = true
+			clauseText = clauseText[1:]
+		}
+		clauseText = strings.Trim(clauseText, "()")
+		for _, wordText := range strings.Split(clauseText, "<->") {
+			wordText = strings.TrimSpace(wordText)
+			word := tsWord{text: strings.TrimSuffix(wordText, ":*")}
+			word.prefix = word.text != wordText
+			if word.text != "" {
+				clause.words = append(clause.words, word)
+			}
+		}
+		if len(clause.words) > 0 {
+			parsed = append(parsed, clause)
+		}
+	}
+	return parsed
+}
+
+// tsWords splits text the way to_tsvector does, into lowercased runs of
+// letters and digits.
+func tsWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isNotWordRune)
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func (word tsWord) matches(text string) bool {
+	if word.prefix {
+		return strings.HasPrefix(text, word.text)
+	}
+	return text == word.text
+}
+
+func (query tsQuery) matches(words []string) bool {
+	for _, clause := range query {
+		if clause.matches(words) == clause.negated {
+			return false
+		}
+	}
+	return true
+}
+
+// A clause matches if its words appear one after another somewhere in words.
+func (clause tsClause) matches(words []string) bool {
+	for start := 0; start+len(clause.words) <= len(words); start++ {
+		matched := true
+		for i, word := range clause.words {
+			if !word.matches(words[start+i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (query tsQuery) highlights(text string) bool {
+	for _, clause := range query {
+		if clause.negated {
+			continue
+		}
+		for _, word := range clause.words {
+			if word.matches(text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headline is ts_headline with HighlightAll: the whole body, with each
+// matching word between database.HeadlineStart and HeadlineStop.
+func (query tsQuery) headline(body string) string {
+	var headline strings.Builder
+	runes := []rune(body)
+	for i := 0; i < len(runes); {
+		if isNotWordRune(runes[i]) {
+			headline.WriteRune(runes[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(runes) && !isNotWordRune(runes[end]) {
+			end++
+		}
+		word := string(runes[i:end])
+		if query.highlights(strings.ToLower(word)) {
+			word = database.HeadlineStart + word + database.HeadlineStop
+		}
+		headline.WriteString(word)
+		i = end
+	}
+	return headline.String()
+}
@@ -0,0 +1,55 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+// GetThreadAncestorIDs returns the chirps above chirpID, root first.
+func (s *Store) GetThreadAncestorIDs(ctx context.Context, arg database.GetThreadAncestorIDsParams) ([]database.GetThreadAncestorIDsRow, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.GetThreadAncestorIDsRow
+	chirp, ok := t.chirps[arg.ChirpID]
+	for depth := int32(1); ok && chirp.ReplyToID.Valid; depth++ {
+		chirp, ok = t.chirps[chirp.ReplyToID.UUID]
+		if !ok {
+			break
+		}
+		items = append(items, database.GetThreadAncestorIDsRow{ID: chirp.ID, Depth: depth})
+		if depth >= arg.MaxDepth {
+			break
+		}
+	}
+	slices.Reverse(items)
+	return items, nil
+}
+
+// GetThreadDescendantIDs pages through the replies under chirpID, down to
+// maxDepth levels, oldest first.
+func (s *Store) GetThreadDescendantIDs(ctx context.Context, arg database.GetThreadDescendantIDsParams) ([]database.GetThreadDescendantIDsRow, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.GetThreadDescendantIDsRow
+	level := []uuid.UUID{arg.ChirpID}
+	for depth := int32(1); len(level) > 0 && (depth == 1 || depth <= arg.MaxDepth); depth++ {
+		next := []uuid.UUID{}
+		for _, chirp := range t.chirps {
+			if !chirp.ReplyToID.Valid || !slices.Contains(level, chirp.ReplyToID.UUID) {
+				continue
+			}
+			next = append(next, chirp.ID)
+			if compareKey(chirp.CreatedAt, chirp.ID, arg.AfterCreatedAt, arg.AfterID) > 0 {
+				items = append(items, database.GetThreadDescendantIDsRow{ID: chirp.ID, CreatedAt: chirp.CreatedAt, Depth: depth})
+			}
+		}
+		level = next
+	}
+	slices.SortFunc(items, func(a, b database.GetThreadDescendantIDsRow) int {
+		return compareKey(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return limit(items, arg.PageSize), nil
+}
@@ -0,0 +1,99 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) BackfillTimeline(ctx context.Context, arg database.BackfillTimelineParams) error {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("timeline_entries", arg.UserID); err != nil {
+		return err
+	}
+	recent := sortedChirps(t, func(chirp database.Chirp) bool {
+		return chirp.UserID == arg.FolloweeID
+	}, newestFirst)
+	for _, chirp := range limit(recent, arg.BackfillSize) {
+		t.addTimelineEntry(arg.UserID, chirp.ID, chirp.CreatedAt)
+	}
+	return nil
+}
+
+func (s *Store) FanOutChirp(ctx context.Context, arg database.FanOutChirpParams) error {
+	t, done := s.begin()
+	defer done()
+	followers := []uuid.UUID{}
+	for key := range t.follows {
+		if key.followeeID == arg.AuthorID {
+			followers = append(followers, key.followerID)
+		}
+	}
+	if len(followers) == 0 {
+		return nil
+	}
+	if err := t.checkChirp("timeline_entries", arg.ChirpID); err != nil {
+		return err
+	}
+	for _, follower := range followers {
+		t.addTimelineEntry(follower, arg.ChirpID, arg.ChirpCreatedAt)
+	}
+	return nil
+}
+
+func (s *Store) GetTimelineByFanOut(ctx context.Context, arg database.GetTimelineByFanOutParams) ([]database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	entries := []database.TimelineEntry{}
+	for key, entry := range t.timelineEntries {
+		if key.userID != arg.UserID || t.chirps[key.chirpID].DeletedAt.Valid {
+			continue
+		}
+		if compareKey(entry.ChirpCreatedAt, entry.ChirpID, arg.BeforeCreatedAt, arg.BeforeID) < 0 {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b database.TimelineEntry) int {
+		return compareKey(b.ChirpCreatedAt, b.ChirpID, a.ChirpCreatedAt, a.ChirpID)
+	})
+	var items []database.Chirp
+	for _, entry := range limit(entries, arg.PageSize) {
+		items = append(items, t.chirps[entry.ChirpID])
+	}
+	return items, nil
+}
+
+func (s *Store) GetTimelineByJoin(ctx context.Context, arg database.GetTimelineByJoinParams) ([]database.Chirp, error) {
+	t, done := s.begin()
+	defer done()
+	items := sortedChirps(t, func(chirp database.Chirp) bool {
+		_, following := t.follows[followKey{followerID: arg.UserID, followeeID: chirp.UserID}]
+		return following && !chirp.DeletedAt.Valid &&
+			compareKey(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt, arg.BeforeID) < 0
+	}, newestFirst)
+	return limit(items, arg.PageSize), nil
+}
+
+func (s *Store) RemoveFromTimeline(ctx context.Context, arg database.RemoveFromTimelineParams) error {
+	t, done := s.begin()
+	defer done()
+	for key := range t.timelineEntries {
+		if key.userID == arg.UserID && t.chirps[key.chirpID].UserID == arg.FolloweeID {
+			delete(t.timelineEntries, key)
+		}
+	}
+	return nil
+}
+
+// addTimelineEntry inserts with ON CONFLICT DO NOTHING.
+func (t *tables) addTimelineEntry(userID, chirpID uuid.UUID, chirpCreatedAt time.Time) {
+	key := userChirpKey{userID: userID, chirpID: chirpID}
+	if _, ok := t.timelineEntries[key]; ok {
+		return
+	}
+	t.timelineEntries[key] = database.TimelineEntry{UserID: userID, ChirpID: chirpID, ChirpCreatedAt: chirpCreatedAt}
+}
@@ -0,0 +1,103 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) AddRecoveryCode(ctx context.Context, arg database.AddRecoveryCodeParams) error {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("recovery_codes", arg.UserID); err != nil {
+		return err
+	}
+	code := database.RecoveryCode{
+		ID:        uuid.New(),
+		CreatedAt: s.now(),
+		UserID:    arg.UserID,
+		CodeHash:  arg.CodeHash,
+	}
+	t.recoveryCodes[code.ID] = code
+	return nil
+}
+
+func (s *Store) AddTwoFactorChallenge(ctx context.Context, arg database.AddTwoFactorChallengeParams) error {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkUser("two_factor_challenges", arg.UserID); err != nil {
+		return err
+	}
+	if _, ok := t.twoFactorChallenges[arg.Token]; ok {
+		return uniqueViolation("two_factor_challenges_pkey")
+	}
+	t.twoFactorChallenges[arg.Token] = database.TwoFactorChallenge{
+		Token:     arg.Token,
+		CreatedAt: s.now(),
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (s *Store) AttemptTwoFactorChallenge(ctx context.Context, arg database.AttemptTwoFactorChallengeParams) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	challenge, ok := t.twoFactorChallenges[arg.Token]
+	if !ok || challenge.UsedAt.Valid || challenge.Attempts >= arg.MaxAttempts {
+		return 0, nil
+	}
+	challenge.Attempts++
+	t.twoFactorChallenges[arg.Token] = challenge
+	return 1, nil
+}
+
+func (s *Store) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	t, done := s.begin()
+	defer done()
+	for id, code := range t.recoveryCodes {
+		if code.UserID == userID {
+			delete(t.recoveryCodes, id)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetTwoFactorChallenge(ctx context.Context, token string) (database.TwoFactorChallenge, error) {
+	t, done := s.begin()
+	defer done()
+	challenge, ok := t.twoFactorChallenges[token]
+	if !ok {
+		return notFound[database.TwoFactorChallenge]()
+	}
+	return challenge, nil
+}
+
+func (s *Store) UseRecoveryCode(ctx context.Context, arg database.UseRecoveryCodeParams) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	now := s.now()
+	var used int64
+	for id, code := range t.recoveryCodes {
+		if code.UserID == arg.UserID && code.CodeHash == arg.CodeHash && !code.UsedAt.Valid {
+			code.UsedAt = sql.NullTime{Time: now, Valid: true}
+			t.recoveryCodes[id] = code
+			used++
+		}
+	}
+	return used, nil
+}
+
+func (s *Store) UseTwoFactorChallenge(ctx context.Context, token string) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	challenge, ok := t.twoFactorChallenges[token]
+	if !ok || challenge.UsedAt.Valid {
+		return 0, nil
+	}
+	challenge.UsedAt = sql.NullTime{Time: s.now(), Valid: true}
+	t.twoFactorChallenges[token] = challenge
+	return 1, nil
+}
@@ -0,0 +1,331 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	t, done := s.begin()
+	defer done()
+	if err := t.checkEmail(uuid.Nil, arg.Email); err != nil {
+		return database.User{}, err
+	}
+	now := s.now()
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+	}
+	t.users[user.ID] = user
+	return user, nil
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	if _, ok := t.users[id]; !ok {
+		return 0, nil
+	}
+	t.deleteUser(id)
+	return 1, nil
+}
+
+func (s *Store) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	return s.updateUser(id, func(user *database.User) {
+		user.TotpSecret = sql.NullString{}
+		user.TotpEnabled = false
+		user.UpdatedAt = s.now()
+	})
+}
+
+func (s *Store) EnableTOTP(ctx context.Context, id uuid.UUID) error {
+	return s.updateUser(id, func(user *database.User) {
+		user.TotpEnabled = true
+		user.UpdatedAt = s.now()
+	})
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	t, done := s.begin()
+	defer done()
+	for _, user := range t.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return notFound[database.User]()
+}
+
+func (s *Store) GetUserByHandle(ctx context.Context, handle sql.NullString) (database.User, error) {
+	t, done := s.begin()
+	defer done()
+	for _, user := range t.users {
+		if handle.Valid && user.Handle == handle && !user.DeletedAt.Valid {
+			return user, nil
+		}
+	}
+	return notFound[database.User]()
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	t, done := s.begin()
+	defer done()
+	user, ok := t.users[id]
+	if !ok {
+		return notFound[database.User]()
+	}
+	return user, nil
+}
+
+func (s *Store) GetUsersByHandles(ctx context.Context, handles []string) ([]database.User, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.User
+	for _, user := range t.users {
+		if user.Handle.Valid && slices.Contains(handles, user.Handle.String) && !user.DeletedAt.Valid {
+			items = append(items, user)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]database.User, error) {
+	t, done := s.begin()
+	defer done()
+	var items []database.User
+	for _, user := range t.users {
+		if slices.Contains(ids, user.ID) {
+			items = append(items, user)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	t, done := s.begin()
+	defer done()
+	items := sortedUsers(t, func(user database.User) bool { return true }, func(a, b database.User) int {
+		return compareKey(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return limit(offset(items, arg.Offset), arg.Limit), nil
+}
+
+func (s *Store) PurgeDeletedUsers(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	var purged int64
+	for id, user := range t.users {
+		if deletedAt.Valid && user.DeletedAt.Valid && user.DeletedAt.Time.Before(deletedAt.Time) {
+			t.deleteUser(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *Store) ResetUsers(ctx context.Context) error {
+	t, done := s.begin()
+	defer done()
+	for id := range t.users {
+		t.deleteUser(id)
+	}
+	return nil
+}
+
+func (s *Store) RestoreUser(ctx context.Context, id uuid.UUID) error {
+	return s.updateUser(id, func(user *database.User) {
+		user.DeletedAt = sql.NullTime{}
+		user.UpdatedAt = s.now()
+	})
+}
+
+func (s *Store) SearchUsersByEmail(ctx context.Context, arg database.SearchUsersByEmailParams) ([]database.User, error) {
+	t, done := s.begin()
+	defer done()
+	items := sortedUsers(t, func(user database.User) bool {
+		return like(strings.ToLower(user.Email), arg.Prefix+"%")
+	}, func(a, b database.User) int {
+		return strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email))
+	})
+	return limit(items, arg.PageSize), nil
+}
+
+func (s *Store) SearchUsersByHandle(ctx context.Context, arg database.SearchUsersByHandleParams) ([]database.User, error) {
+	t, done := s.begin()
+	defer done()
+	items := sortedUsers(t, func(user database.User) bool {
+		return user.Handle.Valid && like(user.Handle.String, arg.Prefix+"%") && !user.DeletedAt.Valid
+	}, func(a, b database.User) int {
+		return strings.Compare(a.Handle.String, b.Handle.String)
+	})
+	return limit(items, arg.PageSize), nil
+}
+
+func (s *Store) SetChirpyRed(ctx context.Context, arg database.SetChirpyRedParams) error {
+	return s.updateUser(arg.ID, func(user *database.User) {
+		user.IsChirpyRed = arg.IsChirpyRed
+		user.UpdatedAt = s.now()
+	})
+}
+
+func (s *Store) SetTOTPSecret(ctx context.Context, arg database.SetTOTPSecretParams) error {
+	return s.updateUser(arg.ID, func(user *database.User) {
+		user.TotpSecret = arg.TotpSecret
+		user.TotpEnabled = false
+		user.UpdatedAt = s.now()
+	})
+}
+
+func (s *Store) SoftDeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.updateUser(id, func(user *database.User) {
+		now := s.now()
+		user.DeletedAt = sql.NullTime{Time: now, Valid: true}
+		user.UpdatedAt = now
+	})
+}
+
+func (s *Store) UpdateUserEmailAndPassword(ctx context.Context, arg database.UpdateUserEmailAndPasswordParams) (database.User, error) {
+	t, done := s.begin()
+	defer done()
+	user, ok := t.users[arg.ID]
+	if !ok {
+		return notFound[database.User]()
+	}
+	if err := t.checkEmail(user.ID, arg.Email); err != nil {
+		return database.User{}, err
+	}
+	user.Email = arg.Email
+	user.HashedPassword = arg.HashedPassword
+	t.users[user.ID] = user
+	return user, nil
+}
+
+func (s *Store) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) (database.User, error) {
+	t, done := s.begin()
+	defer done()
+	user, ok := t.users[arg.ID]
+	if !ok {
+		return notFound[database.User]()
+	}
+	if err := t.checkHandle(user.ID, arg.Handle); err != nil {
+		return database.User{}, err
+	}
+	user.Handle = arg.Handle
+	user.DisplayName = arg.DisplayName
+	user.Bio = arg.Bio
+	user.AvatarUrl = arg.AvatarUrl
+	user.UpdatedAt = s.now()
+	t.users[user.ID] = user
+	return user, nil
+}
+
+func (s *Store) UpgradeByID(ctx context.Context, id uuid.UUID) error {
+	return s.updateUser(id, func(user *database.User) {
+		user.IsChirpyRed = true
+	})
+}
+
+// updateUser applies an UPDATE ... WHERE id = $1, which is no error when the
+// user doesn't exist.
+func (s *Store) UseTOTPStep(ctx context.Context, arg database.UseTOTPStepParams) (int64, error) {
+	t, done := s.begin()
+	defer done()
+	user, ok := t.users[arg.ID]
+	if !ok || (user.TotpLastStep.Valid && user.TotpLastStep.Int64 >= arg.TotpLastStep.Int64) {
+		return 0, nil
+	}
+	user.TotpLastStep = arg.TotpLastStep
+	t.users[arg.ID] = user
+	return 1, nil
+}
+
+func (s *Store) updateUser(id uuid.UUID, update func(user *database.User)) error {
+	t, done := s.begin()
+	defer done()
+	user, ok := t.users[id]
+	if !ok {
+		return nil
+	}
+	update(&user)
+	t.users[id] = user
+	return nil
+}
+
+// checkEmail and checkHandle enforce the unique constraints against every
+// user other than id.
+func (t *tables) checkEmail(id uuid.UUID, email string) error {
+	for _, user := range t.users {
+		if user.ID != id && user.Email == email {
+			return uniqueViolation("users_email_key")
+		}
+	}
+	return nil
+}
+
+func (t *tables) checkHandle(id uuid.UUID, handle sql.NullString) error {
+	for _, user := range t.users {
+		if user.ID != id && handle.Valid && user.Handle == handle {
+			return uniqueViolation("users_handle_key")
+		}
+	}
+	return nil
+}
+
+func sortedUsers(t *tables, keep func(user database.User) bool, compare func(a, b database.User) int) []database.User {
+	var items []database.User
+	for _, user := range t.users {
+		if keep(user) {
+			items = append(items, user)
+		}
+	}
+	slices.SortFunc(items, func(a, b database.User) int {
+		return cmp.Or(compare(a, b), compareUUID(a.ID, b.ID))
+	})
+	return items
+}
+
+// like matches SQL LIKE patterns: % is any run of characters, _ is any one
+// character and a backslash makes the next character literal.
+func like(text, pattern string) bool {
+	textRunes, patternRunes := []rune(text), []rune(pattern)
+	var match func(i, j int) bool
+	match = func(i, j int) bool {
+		for j < len(patternRunes) {
+			switch patternRunes[j] {
+			case '%':
+				for k := i; k <= len(textRunes); k++ {
+					if match(k, j+1) {
+						return true
+					}
+				}
+				return false
+			case '_':
+				if i == len(textRunes) {
+					return false
+				}
+			case '\\':
+				if j+1 < len(patternRunes) {
+					j++
+				}
+				fallthrough
+			default:
+				if i == len(textRunes) || textRunes[i] != patternRunes[j] {
+					return false
+				}
+			}
+			i++
+			j++
+		}
+		return i == len(textRunes)
+	}
+	return match(0, 0)
+}
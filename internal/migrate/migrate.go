@@ -0,0 +1,395 @@
+// Package migrate applies goose-format migrations to Postgres or SQLite.
+// Versions are recorded in goose's own goose_db_version table, so databases
+// migrated with the goose CLI carry straight over.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shared by every Chirpy instance so only one of them migrates at a time.
+const lockID int64 = 7_463_218_950_113
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Set by "-- +goose NO TRANSACTION" for statements such as
+	// CREATE INDEX CONCURRENTLY.
+	NoTransaction bool
+}
+
+type Status struct {
+	Migration Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// The SQL that differs between the databases a Migrator runs against.
+type Dialect struct {
+	lock, unlock       string
+	versionTableExists string
+	createVersionTable string
+}
+
+var Postgres = Dialect{
+	lock:               `SELECT pg_advisory_lock($1)`,
+	unlock:             `SELECT pg_advisory_unlock($1)`,
+	versionTableExists: `SELECT to_regclass('goose_db_version') IS NOT NULL`,
+	createVersionTable: `
+		CREATE TABLE IF NOT EXISTS goose_db_version (
+			id SERIAL PRIMARY KEY,
+			version_id BIGINT NOT NULL,
+			is_applied BOOLEAN NOT NULL,
+			tstamp TIMESTAMP DEFAULT now()
+		)`,
+}
+
+// SQLite has no advisory locks, but only one connection can write at a time,
+// so a second migrator fails on the tables the first created rather than
+// applying anything twice.
+var SQLite = Dialect{
+	versionTableExists: `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'goose_db_version')`,
+	createVersionTable: `
+		CREATE TABLE IF NOT EXISTS goose_db_version (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			version_id INTEGER NOT NULL,
+			is_applied INTEGER NOT NULL,
+			tstamp TIMESTAMP DEFAULT (datetime('now'))
+		)`,
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func New(db *sql.DB, dialect Dialect, files fs.FS) (*Migrator, error) {
+	migrations, err := Load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Reads every NNN_name.sql file at the root of files, sorted by version.
+func Load(files fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	seen := map[int64]string{}
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("%s: migrations are named NNN_description.sql", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("%s: migrations are named NNN_description.sql", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("%s and %s have the same version", other, name)
+		}
+		seen[version] = name
+
+		contents, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		migration, err := parse(string(contents))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		migration.Version = version
+		migration.Name = strings.TrimSuffix(path.Base(name), ".sql")
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Both drivers run multi-statement strings in one Exec, so each direction is kept
+// whole and the StatementBegin/End markers can be ignored.
+func parse(contents string) (Migration, error) {
+	migration := Migration{}
+	var up, down strings.Builder
+	var current *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		directive, ok := strings.CutPrefix(strings.TrimSpace(line), "-- +goose ")
+		if !ok {
+			if current != nil {
+				current.WriteString(line)
+				current.WriteString("\n")
+			}
+			continue
+		}
+		switch strings.TrimSpace(directive) {
+		case "Up":
+			current = &up
+		case "Down":
+			current = &down
+		case "NO TRANSACTION":
+			migration.NoTransaction = true
+		case "StatementBegin", "StatementEnd":
+		default:
+			return Migration{}, fmt.Errorf("unknown goose directive %q", directive)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, err
+	}
+
+	migration.Up = strings.TrimSpace(up.String())
+	migration.Down = strings.TrimSpace(down.String())
+	if migration.Up == "" {
+		return Migration{}, fmt.Errorf("no -- +goose Up section")
+	}
+	return migration, nil
+}
+
+// The newest version among the loaded migrations.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// The newest applied version, or 0 for an empty database. Doesn't take the
+// lock or create the version table.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var exists bool
+	err := m.db.QueryRowContext(ctx, m.dialect.versionTableExists).Scan(&exists)
+	if err != nil || !exists {
+		return 0, err
+	}
+	applied, err := appliedVersions(ctx, m.db)
+	if err != nil {
+		return 0, err
+	}
+	return latestApplied(applied), nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	statuses := []Status{}
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			appliedAt, ok := applied[migration.Version]
+			statuses = append(statuses, Status{
+				Migration: migration,
+				Applied:   ok,
+				AppliedAt: appliedAt,
+			})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Applies every pending migration in order and returns the ones it ran.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	ran := []Migration{}
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			ran = append(ran, migration)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Rolls back the newest applied migration.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	var rolledBack Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		migration, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		rolledBack = migration
+		return apply(ctx, conn, migration, false)
+	})
+	return rolledBack, err
+}
+
+// Rolls back the newest applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	var redone Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		migration, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		redone = migration
+		if err := apply(ctx, conn, migration, false); err != nil {
+			return err
+		}
+		return apply(ctx, conn, migration, true)
+	})
+	return redone, err
+}
+
+func (m *Migrator) current(ctx context.Context, conn *sql.Conn) (Migration, error) {
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return Migration{}, err
+	}
+	version := latestApplied(applied)
+	if version == 0 {
+		return Migration{}, fmt.Errorf("no migrations have been applied")
+	}
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("version %d is applied but has no migration file", version)
+}
+
+// Session-level advisory locks belong to a connection, so everything runs on
+// the one that holds the lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect.lock != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.lock, lockID); err != nil {
+			return fmt.Errorf("could not take the migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), m.dialect.unlock, lockID)
+	}
+
+	if err := ensureVersionTable(ctx, conn, m.dialect); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureVersionTable(ctx context.Context, conn *sql.Conn, dialect Dialect) error {
+	if _, err := conn.ExecContext(ctx, dialect.createVersionTable); err != nil {
+		return err
+	}
+	// goose starts every table with a version 0 row.
+	_, err := conn.ExecContext(ctx, `
+		INSERT INTO goose_db_version (version_id, is_applied)
+		SELECT 0, true
+		WHERE NOT EXISTS (SELECT 1 FROM goose_db_version)`)
+	return err
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Older goose versions recorded rollbacks as is_applied = false rows rather
+// than deleting, so only the newest row for each version counts.
+func appliedVersions(ctx context.Context, db querier) (map[int64]time.Time, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT version_id, is_applied, tstamp
+		FROM goose_db_version
+		WHERE version_id > 0
+		ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	seen := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &appliedAt); err != nil {
+			return nil, err
+		}
+		if !appliedAt.Valid {
+			appliedAt.Time = time.Now()
+		}
+		if seen[version] {
+			continue
+		}
+		seen[version] = true
+		if isApplied {
+			applied[version] = appliedAt.Time
+		}
+	}
+	return applied, rows.Err()
+}
+
+func latestApplied(applied map[int64]time.Time) int64 {
+	latest := int64(0)
+	for version := range applied {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+func apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	statements, record := migration.Up, `INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, true)`
+	if !up {
+		statements, record = migration.Down, `DELETE FROM goose_db_version WHERE version_id = $1`
+	}
+
+	run := func(exec func(ctx context.Context, query string, args ...any) (sql.Result, error)) error {
+		if statements != "" {
+			if _, err := exec(ctx, statements); err != nil {
+				return fmt.Errorf("%s: %w", migration.Name, err)
+			}
+		}
+		_, err := exec(ctx, record, migration.Version)
+		return err
+	}
+
+	if migration.NoTransaction {
+		return run(conn.ExecContext)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := run(tx.ExecContext); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,120 @@
+package migrate
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/database/sqlite"
+	"github.com/FFB6C1/bootdev_webservers/sql/schema"
+	sqliteschema "github.com/FFB6C1/bootdev_webservers/sql/sqlite/schema"
+)
+
+func TestLoad(t *testing.T) {
+	files := fstest.MapFS{
+		"002_chirps.sql": {Data: []byte("-- +goose Up\nCREATE TABLE chirps (id UUID);\n\n-- +goose Down\nDROP TABLE chirps;\n")},
+		"001_users.sql":  {Data: []byte("-- a comment\n-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE users (id UUID);\n-- +goose StatementEnd\n-- +goose Down\nDROP TABLE users;\n")},
+		"003_index.sql":  {Data: []byte("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX CONCURRENTLY chirps_id ON chirps (id);\n")},
+		"README.md":      {Data: []byte("not a migration")},
+	}
+
+	migrations, err := Load(files)
+	if err != nil {
+		t.Fatal("Could not load migrations:", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatal("Wrong number of migrations:", len(migrations))
+	}
+	first := migrations[0]
+	if first.Version != 1 || first.Name != "001_users" {
+		t.Fatal("Migrations out of order:", first.Version, first.Name)
+	}
+	if first.Up != "CREATE TABLE users (id UUID);" || first.Down != "DROP TABLE users;" {
+		t.Fatalf("Wrong sections: %q %q", first.Up, first.Down)
+	}
+	if first.NoTransaction || !migrations[2].NoTransaction {
+		t.Fatal("NO TRANSACTION not read")
+	}
+	if migrations[2].Down != "" {
+		t.Fatal("Missing Down section not empty:", migrations[2].Down)
+	}
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"no version": {"users.sql": {Data: []byte("-- +goose Up\nSELECT 1;")}},
+		"duplicate": {
+			"001_users.sql":  {Data: []byte("-- +goose Up\nSELECT 1;")},
+			"0001_again.sql": {Data: []byte("-- +goose Up\nSELECT 1;")},
+		},
+		"no up":         {"001_users.sql": {Data: []byte("-- +goose Down\nSELECT 1;")}},
+		"bad directive": {"001_users.sql": {Data: []byte("-- +goose Sideways\nSELECT 1;")}},
+	}
+	for name, files := range cases {
+		if _, err := Load(files); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// The embedded migrations and the generated queries have to agree, or the
+// server would refuse to start straight after migrating.
+func TestSchemaVersionMatchesMigrations(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		files   fs.FS
+		version int64
+	}{
+		{"database", schema.FS, database.SchemaVersion},
+		{"sqlite", sqliteschema.FS, sqlite.SchemaVersion},
+	} {
+		migrations, err := Load(test.files)
+		if err != nil {
+			t.Fatal("Could not load embedded migrations:", err)
+		}
+		latest := migrations[len(migrations)-1].Version
+		if latest != test.version {
+			t.Fatalf("Newest migration is %d but %s.SchemaVersion is %d", latest, test.name, test.version)
+		}
+	}
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	files := fstest.MapFS{
+		"001_users.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE users (id UUID);\n-- +goose Down\nDROP TABLE users;\n")},
+		"002_chirps.sql": {Data: []byte("-- +goose Up\nCREATE TABLE chirps (id UUID);\n-- +goose Down\nDROP TABLE chirps;\n")},
+	}
+	migrator, err := New(db, SQLite, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version, err := migrator.Version(ctx); err != nil || version != 0 {
+		t.Fatalf("Version of an empty database = %d, %v", version, err)
+	}
+	if ran, err := migrator.Up(ctx); err != nil || len(ran) != 2 {
+		t.Fatalf("Up ran %d migrations: %v", len(ran), err)
+	}
+	if _, err := migrator.Down(ctx); err != nil {
+		t.Fatal("Down:", err)
+	}
+	if version, err := migrator.Version(ctx); err != nil || version != 1 {
+		t.Fatalf("Version after Down = %d, %v", version, err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal("Status:", err)
+	}
+	if !statuses[0].Applied || statuses[0].AppliedAt.IsZero() || statuses[1].Applied {
+		t.Fatalf("Status = %+v", statuses)
+	}
+}
@@ -0,0 +1,122 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// UserID restricts an event to one user's subscriptions; uuid.Nil sends it to
+// everyone. IDs sort in publish order, so clients can resume from the last one
+// they saw. Ephemeral events, like typing indicators, are not kept for replay.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Data      json.RawMessage `json:"data"`
+	Ephemeral bool            `json:"ephemeral,omitempty"`
+}
+
+func NewEventID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+type Subscription struct {
+	events chan Event
+	userID uuid.UUID
+}
+
+// Events is closed when the subscriber falls too far behind. It should
+// reconnect with the last event ID it handled to catch up from history.
+func (subscription *Subscription) Events() <-chan Event {
+	return subscription.events
+}
+
+// Hub fans events out to subscribers in this process and keeps the most recent
+// ones so reconnecting subscribers can replay what they missed.
+type Hub struct {
+	mu          sync.Mutex
+	history     []Event
+	historySize int
+	bufferSize  int
+	subscribers map[*Subscription]bool
+}
+
+func NewHub(historySize, bufferSize int) *Hub {
+	return &Hub{
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subscribers: map[*Subscription]bool{},
+	}
+}
+
+// Deliver never blocks on a slow subscriber: if its buffer is full it is
+// dropped instead.
+func (hub *Hub) Deliver(event Event) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if !event.Ephemeral {
+		hub.history = append(hub.history, event)
+		if len(hub.history) > hub.historySize {
+			hub.history = hub.history[len(hub.history)-hub.historySize:]
+		}
+	}
+
+	for subscription := range hub.subscribers {
+		if !subscription.wants(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			close(subscription.events)
+			delete(hub.subscribers, subscription)
+		}
+	}
+}
+
+// Subscribe returns the events after lastEventID that the subscriber should see
+// before anything on its channel. ok is false when lastEventID is no longer in
+// history, so the subscriber has missed events it can't get back.
+func (hub *Hub) Subscribe(userID uuid.UUID, lastEventID string) (subscription *Subscription, replay []Event, ok bool) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	subscription = &Subscription{
+		events: make(chan Event, hub.bufferSize),
+		userID: userID,
+	}
+	hub.subscribers[subscription] = true
+
+	if lastEventID == "" {
+		return subscription, nil, true
+	}
+	for i, event := range hub.history {
+		if event.ID != lastEventID {
+			continue
+		}
+		for _, missed := range hub.history[i+1:] {
+			if subscription.wants(missed) {
+				replay = append(replay, missed)
+			}
+		}
+		return subscription, replay, true
+	}
+	return subscription, nil, false
+}
+
+func (hub *Hub) Unsubscribe(subscription *Subscription) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.subscribers[subscription] {
+		close(subscription.events)
+		delete(hub.subscribers, subscription)
+	}
+}
+
+func (subscription *Subscription) wants(event Event) bool {
+	return event.UserID == uuid.Nil || event.UserID == subscription.userID
+}
@@ -0,0 +1,63 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHubDeliversToMatchingSubscribers(t *testing.T) {
+	hub := NewHub(10, 10)
+	alice, bob := uuid.New(), uuid.New()
+	aliceSubscription, _, _ := hub.Subscribe(alice, "")
+	anonymous, _, _ := hub.Subscribe(uuid.Nil, "")
+
+	hub.Deliver(Event{ID: NewEventID(), Type: "chirp"})
+	hub.Deliver(Event{ID: NewEventID(), Type: "notification", UserID: bob})
+	hub.Deliver(Event{ID: NewEventID(), Type: "notification", UserID: alice})
+
+	if len(aliceSubscription.Events()) != 2 {
+		t.Fatal("Alice should see the chirp and her notification")
+	}
+	if len(anonymous.Events()) != 1 {
+		t.Fatal("Anonymous subscribers should only see public events")
+	}
+}
+
+func TestHubReplaysFromLastEventID(t *testing.T) {
+	hub := NewHub(3, 10)
+	ids := []string{}
+	for i := 0; i < 4; i++ {
+		id := NewEventID()
+		ids = append(ids, id)
+		hub.Deliver(Event{ID: id, Type: "chirp"})
+	}
+
+	_, replay, ok := hub.Subscribe(uuid.Nil, ids[1])
+	if !ok || len(replay) != 2 || replay[0].ID != ids[2] || replay[1].ID != ids[3] {
+		t.Fatal("Wrong replay:", replay)
+	}
+
+	if _, _, ok := hub.Subscribe(uuid.Nil, ids[0]); ok {
+		t.Fatal("Event dropped from history should not be resumable")
+	}
+
+	hub.Deliver(Event{ID: NewEventID(), Type: "typing", Ephemeral: true})
+	if _, replay, _ := hub.Subscribe(uuid.Nil, ids[3]); len(replay) != 0 {
+		t.Fatal("Ephemeral event was replayed")
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub(10, 1)
+	subscription, _, _ := hub.Subscribe(uuid.Nil, "")
+
+	hub.Deliver(Event{ID: NewEventID(), Type: "chirp"})
+	hub.Deliver(Event{ID: NewEventID(), Type: "chirp"})
+
+	<-subscription.Events()
+	if _, open := <-subscription.Events(); open {
+		t.Fatal("Slow subscriber was not dropped")
+	}
+	hub.Unsubscribe(subscription)
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type term struct {
+	text    string
+	negated bool
+	prefix  bool
+}
+
+// ParseQuery turns a user's search into a to_tsquery expression. Words are
+// ANDed together; "quoted words" must appear in order, a trailing * matches
+// any word starting with the rest, and a leading - excludes a word or phrase.
+// Anything but letters and digits is dropped, so the result is always a valid
+// tsquery whatever the user typed.
+func ParseQuery(query string) (string, error) {
+	clauses := []string{}
+	positive := false
+	for _, term := range splitTerms(query) {
+		words := strings.FieldsFunc(term.text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+		if term.prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		clause := strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			clause = "(" + clause + ")"
+		}
+		if term.negated {
+			clause = "!" + clause
+		} else {
+			positive = true
+		}
+		clauses = append(clauses, clause)
+	}
+
+	if !positive {
+		return "", fmt.Errorf("Search needs at least one word")
+	}
+	return strings.Join(clauses, " & "), nil
+}
+
+func splitTerms(query string) []term {
+	terms := []term{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		current := term{}
+		if runes[i] == '-' {
+			current.negated = true
+			i++
+		}
+
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			current.text = string(runes[i+1 : end])
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			current.text = string(runes[i:end])
+			i = end
+			if strings.HasSuffix(current.text, "*") {
+				current.prefix = true
+			}
+		}
+		terms = append(terms, current)
+	}
+	return terms
+}
@@ -0,0 +1,30 @@
+package search
+
+import "testing"
+
+func TestParseQuery(t *testing.T) {
+	cases := map[string]string{
+		"hello world":             "hello & world",
+		`"hello world" go`:        "(hello <-> world) & go",
+		"chirp* -spam":            "chirp:* & !spam",
+		`-"bad phrase" good`:      "!(bad <-> phrase) & good",
+		"it's   fine!":            "(it <-> s) & fine",
+		`o'reilly & x | y:* ) (!`: "(o <-> reilly) & x & y:*",
+		`"unterminated phrase`:    "(unterminated <-> phrase)",
+		"Café":                    "café",
+	}
+	for query, want := range cases {
+		got, err := ParseQuery(query)
+		if err != nil || got != want {
+			t.Fatalf("ParseQuery(%q) = %q, %v; want %q", query, got, err, want)
+		}
+	}
+}
+
+func TestParseQueryNeedsAWord(t *testing.T) {
+	for _, query := range []string{"", "   ", "-spam", "!!! ***"} {
+		if _, err := ParseQuery(query); err == nil {
+			t.Fatalf("ParseQuery(%q) accepted", query)
+		}
+	}
+}
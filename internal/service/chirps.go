@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+type ChirpService struct {
+	store database.Store
+}
+
+func NewChirpService(store database.Store) *ChirpService {
+	return &ChirpService{store: store}
+}
+
+// Deletes a chirp on behalf of userID, who has to be its author. The ownership
+// check and the delete happen in one transaction.
+func (s *ChirpService) Delete(ctx context.Context, userID, chirpID uuid.UUID) (database.Chirp, error) {
+	var chirp database.Chirp
+	err := s.store.WithTx(ctx, func(ctx context.Context, queries database.Querier) error {
+		var err error
+		chirp, err = queries.GetChirpById(ctx, chirpID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && chirp.DeletedAt.Valid) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if chirp.UserID != userID {
+			return ErrForbidden
+		}
+		return remove(ctx, queries, chirp)
+	})
+	return chirp, err
+}
+
+// Deletes a chirp without checking who's asking, for moderation.
+func (s *ChirpService) Remove(ctx context.Context, chirp database.Chirp) error {
+	return s.store.WithTx(ctx, func(ctx context.Context, queries database.Querier) error {
+		return remove(ctx, queries, chirp)
+	})
+}
+
+func remove(ctx context.Context, queries database.Querier, chirp database.Chirp) error {
+	// Going through DeleteRechirp keeps the original's rechirp_count right.
+	if chirp.RechirpOfID.Valid {
+		_, err := queries.DeleteRechirp(ctx, database.DeleteRechirpParams{
+			UserID:      chirp.UserID,
+			RechirpOfID: chirp.RechirpOfID,
+		})
+		return err
+	}
+
+	// A chirp with replies or quotes becomes a tombstone so they keep
+	// something to point at. Rechirps have nothing of their own, so they go.
+	hasReferences, err := queries.HasReferences(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	if !hasReferences {
+		return queries.DeleteChirp(ctx, chirp.ID)
+	}
+
+	if err := queries.TombstoneChirp(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if err := queries.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true}); err != nil {
+		return err
+	}
+	return queries.DeleteChirpEntities(ctx, chirp.ID)
+}
@@ -0,0 +1,12 @@
+// Package service holds the business rules that sit between the HTTP handlers
+// and the generated queries. Services depend on the database.Store interface,
+// so the rules can be tested without HTTP or Postgres.
+package service
+
+import "errors"
+
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrForbidden          = errors.New("forbidden")
+	ErrInvalidCredentials = errors.New("incorrect email or password")
+)
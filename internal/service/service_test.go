@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+// A Store that keeps just enough state for the rules under test. Queries the
+// services don't use fall through to the nil Querier. WithTx works on a copy
+// and only keeps it if fn succeeds.
+type fakeStore struct {
+	database.Querier
+
+	chirps        map[uuid.UUID]database.Chirp
+	users         map[string]database.User
+	refreshTokens []database.AddRefreshTokenParams
+	failDelete    bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		chirps: map[uuid.UUID]database.Chirp{},
+		users:  map[string]database.User{},
+	}
+}
+
+func (s *fakeStore) WithTx(ctx context.Context, fn func(ctx context.Context, queries database.Querier) error) error {
+	tx := newFakeStore()
+	for id, chirp := range s.chirps {
+		tx.chirps[id] = chirp
+	}
+	for email, user := range s.users {
+		tx.users[email] = user
+	}
+	tx.refreshTokens = append(tx.refreshTokens, s.refreshTokens...)
+	tx.failDelete = s.failDelete
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	s.chirps, s.users, s.refreshTokens = tx.chirps, tx.users, tx.refreshTokens
+	return nil
+}
+
+func (s *fakeStore) GetChirpById(_ context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, ok := s.chirps[id]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+func (s *fakeStore) HasReferences(_ context.Context, chirpID uuid.NullUUID) (bool, error) {
+	for _, chirp := range s.chirps {
+		if chirp.ReplyToID == chirpID || chirp.QuoteOfID == chirpID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeStore) DeleteChirp(_ context.Context, id uuid.UUID) error {
+	if s.failDelete {
+		return errors.New("delete failed")
+	}
+	delete(s.chirps, id)
+	for otherID, chirp := range s.chirps {
+		if chirp.RechirpOfID.Valid && chirp.RechirpOfID.UUID == id {
+			delete(s.chirps, otherID)
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) DeleteRechirp(_ context.Context, arg database.DeleteRechirpParams) (int64, error) {
+	deleted := int64(0)
+	for id, chirp := range s.chirps {
+		if chirp.UserID == arg.UserID && chirp.RechirpOfID == arg.RechirpOfID {
+			delete(s.chirps, id)
+			deleted++
+		}
+	}
+	if original, ok := s.chirps[arg.RechirpOfID.UUID]; ok {
+		original.RechirpCount -= int32(deleted)
+		s.chirps[original.ID] = original
+	}
+	return deleted, nil
+}
+
+func (s *fakeStore) TombstoneChirp(_ context.Context, id uuid.UUID) error {
+	chirp := s.chirps[id]
+	chirp.Body = ""
+	chirp.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.chirps[id] = chirp
+	return nil
+}
+
+func (s *fakeStore) DeleteRechirpsOf(_ context.Context, rechirpOfID uuid.NullUUID) error {
+	for id, chirp := range s.chirps {
+		if chirp.RechirpOfID == rechirpOfID {
+			delete(s.chirps, id)
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) DeleteChirpEntities(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func (s *fakeStore) CreateUser(_ context.Context, arg database.CreateUserParams) (database.User, error) {
+	user := database.User{ID: uuid.New(), Email: arg.Email, HashedPassword: arg.HashedPassword}
+	s.users[arg.Email] = user
+	return user, nil
+}
+
+func (s *fakeStore) GetUserByEmail(_ context.Context, email string) (database.User, error) {
+	user, ok := s.users[email]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *fakeStore) RestoreUser(_ context.Context, id uuid.UUID) error {
+	for email, user := range s.users {
+		if user.ID == id {
+			user.DeletedAt = sql.NullTime{}
+			s.users[email] = user
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) AddRefreshToken(_ context.Context, arg database.AddRefreshTokenParams) error {
+	s.refreshTokens = append(s.refreshTokens, arg)
+	return nil
+}
+
+func (s *fakeStore) addChirp(userID uuid.UUID, change func(*database.Chirp)) database.Chirp {
+	chirp := database.Chirp{ID: uuid.New(), UserID: userID, Body: "hello"}
+	if change != nil {
+		change(&chirp)
+	}
+	s.chirps[chirp.ID] = chirp
+	return chirp
+}
+
+func TestDeleteChirp(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	chirps := NewChirpService(store)
+	author, other := uuid.New(), uuid.New()
+
+	chirp := store.addChirp(author, nil)
+	if _, err := chirps.Delete(ctx, other, chirp.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatal("Someone else deleted the chirp:", err)
+	}
+	if _, err := chirps.Delete(ctx, author, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Missing chirp not reported:", err)
+	}
+	if _, err := chirps.Delete(ctx, author, chirp.ID); err != nil {
+		t.Fatal("Could not delete chirp:", err)
+	}
+	if _, ok := store.chirps[chirp.ID]; ok {
+		t.Fatal("Chirp still there")
+	}
+}
+
+func TestDeleteChirpWithReplies(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	chirps := NewChirpService(store)
+	author := uuid.New()
+
+	parent := store.addChirp(author, nil)
+	reply := store.addChirp(uuid.New(), func(chirp *database.Chirp) {
+		chirp.ReplyToID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	})
+	rechirp := store.addChirp(uuid.New(), func(chirp *database.Chirp) {
+		chirp.RechirpOfID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	})
+
+	if _, err := chirps.Delete(ctx, author, parent.ID); err != nil {
+		t.Fatal("Could not delete chirp:", err)
+	}
+	if !store.chirps[parent.ID].DeletedAt.Valid {
+		t.Fatal("Chirp with replies not tombstoned")
+	}
+	if _, ok := store.chirps[reply.ID]; !ok {
+		t.Fatal("Reply removed with its parent")
+	}
+	if _, ok := store.chirps[rechirp.ID]; ok {
+		t.Fatal("Rechirp of a tombstone kept")
+	}
+	if _, err := chirps.Delete(ctx, author, parent.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Tombstone deleted twice:", err)
+	}
+}
+
+func TestDeleteRechirp(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	chirps := NewChirpService(store)
+	sharer := uuid.New()
+
+	original := store.addChirp(uuid.New(), func(chirp *database.Chirp) { chirp.RechirpCount = 1 })
+	rechirp := store.addChirp(sharer, func(chirp *database.Chirp) {
+		chirp.RechirpOfID = uuid.NullUUID{UUID: original.ID, Valid: true}
+	})
+
+	if _, err := chirps.Delete(ctx, sharer, rechirp.ID); err != nil {
+		t.Fatal("Could not delete rechirp:", err)
+	}
+	if store.chirps[original.ID].RechirpCount != 0 {
+		t.Fatal("Rechirp count not updated:", store.chirps[original.ID].RechirpCount)
+	}
+}
+
+func TestDeleteChirpRollsBack(t *testing.T) {
+	store := newFakeStore()
+	store.failDelete = true
+	chirp := store.addChirp(uuid.New(), nil)
+
+	if err := NewChirpService(store).Remove(context.Background(), chirp); err == nil {
+		t.Fatal("Failed delete reported success")
+	}
+	if _, ok := store.chirps[chirp.ID]; !ok {
+		t.Fatal("Failed transaction changed the store")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	users := NewUserService(store, "secret")
+
+	user, err := users.Register(ctx, "walt@example.com", "hunter2")
+	if err != nil {
+		t.Fatal("Could not register:", err)
+	}
+	if user.HashedPassword == "hunter2" {
+		t.Fatal("Password stored in plain text")
+	}
+
+	if _, err := users.Authenticate(ctx, "walt@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatal("Wrong password accepted:", err)
+	}
+	if _, err := users.Authenticate(ctx, "nobody@example.com", "hunter2"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatal("Unknown email accepted:", err)
+	}
+
+	deleted := store.users["walt@example.com"]
+	deleted.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	store.users["walt@example.com"] = deleted
+	user, err = users.Authenticate(ctx, "walt@example.com", "hunter2")
+	if err != nil {
+		t.Fatal("Could not log in:", err)
+	}
+	if !store.users["walt@example.com"].DeletedAt.Valid {
+		t.Fatal("The password alone cancelled the deletion")
+	}
+	if _, err := users.IssueTokens(ctx, user); err != nil {
+		t.Fatal("Could not issue tokens:", err)
+	}
+	if store.users["walt@example.com"].DeletedAt.Valid {
+		t.Fatal("Logging in did not cancel the deletion")
+	}
+}
+
+func TestIssueTokens(t *testing.T) {
+	store := newFakeStore()
+	users := NewUserService(store, "secret")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	users.now = func() time.Time { return now }
+	user := database.User{ID: uuid.New()}
+
+	tokens, err := users.IssueTokens(context.Background(), user)
+	if err != nil {
+		t.Fatal("Could not issue tokens:", err)
+	}
+	userID, err := auth.ValidateJWT(tokens.Access, "secret")
+	if err != nil || userID != user.ID {
+		t.Fatal("Access token not valid for the user:", err)
+	}
+	if len(store.refreshTokens) != 1 || store.refreshTokens[0].Token != tokens.Refresh {
+		t.Fatal("Refresh token not stored")
+	}
+	if !store.refreshTokens[0].ExpiresAt.Equal(now.Add(refreshTokenLifetime)) {
+		t.Fatal("Wrong refresh token expiry:", store.refreshTokens[0].ExpiresAt)
+	}
+}
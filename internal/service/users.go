@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+)
+
+const (
+	accessTokenLifetime  = time.Hour
+	refreshTokenLifetime = 60 * 24 * time.Hour
+)
+
+type UserService struct {
+	store  database.Store
+	secret string
+	now    func() time.Time
+}
+
+type Tokens struct {
+	Access  string
+	Refresh string
+}
+
+func NewUserService(store database.Store, secret string) *UserService {
+	return &UserService{store: store, secret: secret, now: time.Now}
+}
+
+func (s *UserService) Register(ctx context.Context, email, password string) (database.User, error) {
+	hashed, err := auth.HashPassword(password)
+	if err != nil {
+		return database.User{}, err
+	}
+	return s.store.CreateUser(ctx, database.CreateUserParams{
+		Email:          email,
+		HashedPassword: hashed,
+	})
+}
+
+// Checks an email and password. That's only the first factor, so a pending
+// deletion stays pending until IssueTokens.
+func (s *UserService) Authenticate(ctx context.Context, email, password string) (database.User, error) {
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		return database.User{}, ErrInvalidCredentials
+	}
+	if err := auth.CheckPasswordHash(password, user.HashedPassword); err != nil {
+		return database.User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// Makes an access token and stores a new refresh token for user, once they've
+// passed every factor. Logging in during the deletion grace period cancels
+// the pending deletion.
+func (s *UserService) IssueTokens(ctx context.Context, user database.User) (Tokens, error) {
+	if user.DeletedAt.Valid {
+		if err := s.store.RestoreUser(ctx, user.ID); err != nil {
+			return Tokens{}, err
+		}
+	}
+
+	access, err := auth.MakeJWT(user.ID, s.secret, accessTokenLifetime)
+	if err != nil {
+		return Tokens{}, err
+	}
+	refresh, err := auth.MakeRefreshToken()
+	if err != nil {
+		return Tokens{}, err
+	}
+	if err := s.store.AddRefreshToken(ctx, database.AddRefreshTokenParams{
+		Token:     refresh,
+		UserID:    user.ID,
+		ExpiresAt: s.now().Add(refreshTokenLifetime),
+	}); err != nil {
+		return Tokens{}, err
+	}
+	return Tokens{Access: access, Refresh: refresh}, nil
+}
@@ -0,0 +1,243 @@
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Precompressed variants live next to the original, e.g. app.js.br, and are
+// tried in this order.
+var encodings = []struct {
+	name      string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Serves files from an fs.FS without directory listings or dotfiles. Directories
+// are only served through their index.html.
+type Handler struct {
+	// When set, Index serves the root index.html and any missing path without
+	// an extension, so client-side routes load the app instead of a 404.
+	Index http.Handler
+
+	files fs.FS
+
+	mu    sync.Mutex
+	etags map[string]cachedETag
+}
+
+type cachedETag struct {
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+func New(files fs.FS) *Handler {
+	return &Handler{
+		files: files,
+		etags: map[string]cachedETag{},
+	}
+}
+
+func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		writer.Header().Set("Allow", "GET, HEAD")
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, ok := cleanPath(request.URL.Path)
+	if !ok {
+		http.NotFound(writer, request)
+		return
+	}
+	if h.Index != nil && (name == "." || name == "index.html") {
+		h.Index.ServeHTTP(writer, request)
+		return
+	}
+	info, err := fs.Stat(h.files, name)
+	if err != nil {
+		if h.Index != nil && path.Ext(name) == "" {
+			h.Index.ServeHTTP(writer, request)
+			return
+		}
+		http.NotFound(writer, request)
+		return
+	}
+	if info.IsDir() {
+		if !strings.HasSuffix(request.URL.Path, "/") {
+			redirectToDir(writer, request)
+			return
+		}
+		name = path.Join(name, "index.html")
+		if info, err = fs.Stat(h.files, name); err != nil || info.IsDir() {
+			http.NotFound(writer, request)
+			return
+		}
+	}
+	h.serveFile(writer, request, name)
+}
+
+func (h *Handler) serveFile(writer http.ResponseWriter, request *http.Request, name string) {
+	header := writer.Header()
+	header.Set("Cache-Control", CacheControl(name))
+	header.Add("Vary", "Accept-Encoding")
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	servedName := name
+	accepted := acceptedEncodings(request.Header.Get("Accept-Encoding"))
+	for _, encoding := range encodings {
+		if !accepted[encoding.name] {
+			continue
+		}
+		if info, err := fs.Stat(h.files, name+encoding.extension); err == nil && !info.IsDir() {
+			servedName = name + encoding.extension
+			header.Set("Content-Encoding", encoding.name)
+			if header.Get("Content-Type") == "" {
+				header.Set("Content-Type", "application/octet-stream")
+			}
+			break
+		}
+	}
+
+	content, info, err := openSeeker(h.files, servedName)
+	if err != nil {
+		http.NotFound(writer, request)
+		return
+	}
+	defer content.Close()
+
+	etag, err := h.etag(servedName, info, content)
+	if err != nil {
+		http.Error(writer, "Could not read file", http.StatusInternalServerError)
+		return
+	}
+	header.Set("ETag", etag)
+
+	// ServeContent handles If-None-Match, If-Modified-Since and ranges.
+	http.ServeContent(writer, request, name, info.ModTime(), content)
+}
+
+// Hashes are cached until the file's size or modification time changes. Files
+// in an embed.FS have no modification time but never change either.
+func (h *Handler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	h.mu.Lock()
+	cached, ok := h.etags[name]
+	h.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.value, nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	value := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+
+	h.mu.Lock()
+	h.etags[name] = cachedETag{modTime: info.ModTime(), size: info.Size(), value: value}
+	h.mu.Unlock()
+	return value, nil
+}
+
+// HTML is revalidated on every load so new deploys show up straight away.
+// Everything else can be cached for a while.
+func CacheControl(name string) string {
+	switch path.Ext(strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".br")) {
+	case ".html", ".htm", "":
+		return "no-cache"
+	case ".css", ".js", ".mjs", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".ico", ".woff", ".woff2":
+		return "public, max-age=86400"
+	default:
+		return "public, max-age=3600"
+	}
+}
+
+// Returns the fs.FS name for a URL path. Paths with any segment starting with a
+// dot are refused so .env, .git and friends are never served.
+func cleanPath(urlPath string) (string, bool) {
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		return ".", true
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return "", false
+		}
+	}
+	return name, fs.ValidPath(name)
+}
+
+func redirectToDir(writer http.ResponseWriter, request *http.Request) {
+	target := path.Base(request.URL.Path) + "/"
+	if request.URL.RawQuery != "" {
+		target += "?" + request.URL.RawQuery
+	}
+	// Set directly because http.Redirect would resolve the relative target
+	// against the path after http.StripPrefix.
+	writer.Header().Set("Location", target)
+	writer.WriteHeader(http.StatusMovedPermanently)
+}
+
+func acceptedEncodings(header string) map[string]bool {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		accepted[name] = quality > 0
+	}
+	return accepted
+}
+
+type readSeekCloser struct {
+	io.ReadSeeker
+	io.Closer
+}
+
+// Files from os.DirFS and embed.FS can seek; anything else is read into memory.
+func openSeeker(files fs.FS, name string) (readSeekCloser, fs.FileInfo, error) {
+	file, err := files.Open(name)
+	if err != nil {
+		return readSeekCloser{}, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return readSeekCloser{}, nil, fs.ErrNotExist
+	}
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		return readSeekCloser{seeker, file}, info, nil
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return readSeekCloser{}, nil, err
+	}
+	return readSeekCloser{bytes.NewReader(data), file}, info, nil
+}
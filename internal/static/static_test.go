@@ -0,0 +1,126 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func testFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":         {Data: []byte("<h1>Chirpy</h1>")},
+		"assets/logo.png":    {Data: []byte("png")},
+		"assets/app.js":      {Data: []byte("console.log('hi')")},
+		"assets/app.js.br":   {Data: []byte("brotli")},
+		"assets/app.js.gz":   {Data: []byte("gzip")},
+		"empty/readme.txt":   {Data: []byte("no index here")},
+		".env":               {Data: []byte("SECRET=hunter2")},
+		"assets/.hidden.png": {Data: []byte("hidden")},
+	}
+}
+
+func get(handler http.Handler, target string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("GET", target, nil)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestServesFiles(t *testing.T) {
+	handler := New(testFiles())
+
+	response := get(handler, "/", nil)
+	if response.Code != 200 || response.Body.String() != "<h1>Chirpy</h1>" {
+		t.Fatal("Index not served:", response.Code, response.Body.String())
+	}
+	if response.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatal("Wrong HTML cache policy:", response.Header().Get("Cache-Control"))
+	}
+
+	response = get(handler, "/assets/logo.png", nil)
+	if response.Code != 200 || response.Header().Get("Content-Type") != "image/png" {
+		t.Fatal("Logo not served:", response.Code, response.Header().Get("Content-Type"))
+	}
+	if response.Header().Get("Cache-Control") != "public, max-age=86400" {
+		t.Fatal("Wrong asset cache policy:", response.Header().Get("Cache-Control"))
+	}
+}
+
+func TestBlocksDotfilesAndListings(t *testing.T) {
+	handler := New(testFiles())
+	for _, target := range []string{"/.env", "/assets/.hidden.png", "/assets/../.env", "/empty/", "/missing"} {
+		if response := get(handler, target, nil); response.Code != 404 {
+			t.Errorf("%s: expected 404, got %d", target, response.Code)
+		}
+	}
+
+	if response := get(handler, "/assets", nil); response.Code != 301 || response.Header().Get("Location") != "assets/" {
+		t.Error("Directory without a slash not redirected:", response.Code, response.Header().Get("Location"))
+	}
+}
+
+func TestETags(t *testing.T) {
+	handler := New(testFiles())
+
+	response := get(handler, "/assets/logo.png", nil)
+	etag := response.Header().Get("ETag")
+	if len(etag) < 3 || etag[0] != '"' {
+		t.Fatal("Missing strong ETag:", etag)
+	}
+
+	response = get(handler, "/assets/logo.png", map[string]string{"If-None-Match": etag})
+	if response.Code != 304 {
+		t.Fatal("Matching ETag not revalidated:", response.Code)
+	}
+}
+
+func TestPrecompressed(t *testing.T) {
+	handler := New(testFiles())
+
+	response := get(handler, "/assets/app.js", map[string]string{"Accept-Encoding": "gzip, br"})
+	if response.Body.String() != "brotli" || response.Header().Get("Content-Encoding") != "br" {
+		t.Fatal("Brotli variant not served:", response.Body.String())
+	}
+	if response.Header().Get("Content-Type") != "text/javascript; charset=utf-8" {
+		t.Fatal("Wrong content type for variant:", response.Header().Get("Content-Type"))
+	}
+	brETag := response.Header().Get("ETag")
+
+	response = get(handler, "/assets/app.js", map[string]string{"Accept-Encoding": "gzip, br;q=0"})
+	if response.Body.String() != "gzip" || response.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("Gzip variant not served:", response.Body.String())
+	}
+	if response.Header().Get("ETag") == brETag {
+		t.Fatal("Variants share an ETag")
+	}
+
+	response = get(handler, "/assets/app.js", nil)
+	if response.Body.String() != "console.log('hi')" || response.Header().Get("Content-Encoding") != "" {
+		t.Fatal("Uncompressed file not served:", response.Body.String())
+	}
+}
+
+func TestIndexFallback(t *testing.T) {
+	handler := New(testFiles())
+	handler.Index = http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.Write([]byte("app"))
+	})
+
+	for _, target := range []string{"/", "/index.html", "/chirps/123", "/settings"} {
+		if response := get(handler, target, nil); response.Code != 200 || response.Body.String() != "app" {
+			t.Errorf("%s: expected the index, got %d %q", target, response.Code, response.Body.String())
+		}
+	}
+	for _, target := range []string{"/assets/missing.js", "/.env", "/empty/"} {
+		if response := get(handler, target, nil); response.Code != 404 {
+			t.Errorf("%s: expected 404, got %d", target, response.Code)
+		}
+	}
+	if response := get(handler, "/assets/logo.png", nil); response.Body.String() != "png" {
+		t.Error("Files not served with an index set:", response.Body.String())
+	}
+}
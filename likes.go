@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+type likeResponse struct {
+	LikeCount int32 `json:"like_count"`
+	LikedByMe bool  `json:"liked_by_me"`
+}
+
+func (cfg *apiConfig) likeChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	cfg.setChirpLike(true, writer, request)
+}
+
+func (cfg *apiConfig) unlikeChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	cfg.setChirpLike(false, writer, request)
+}
+
+func (cfg *apiConfig) setChirpLike(liked bool, writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, auth.ScopeChirpsWrite)
+	if err != nil {
+		handleError("Unauthorized", err, code, writer)
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		handleError("Could not parse chirp ID", err, 400, writer)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(request.Context(), chirpID)
+	if err != nil {
+		handleError("Could not find chirp", err, 404, writer)
+		return
+	}
+	if chirp.DeletedAt.Valid {
+		handleError("Could not find chirp", nil, 404, writer)
+		return
+	}
+
+	var likeCount int32
+	if liked {
+		err = cfg.store.WithTx(request.Context(), func(ctx context.Context, queries database.Querier) error {
+			likeCount, err = queries.LikeChirp(ctx, database.LikeChirpParams{
+				UserID:  userID,
+				ChirpID: chirpID,
+			})
+			if err != nil {
+				return err
+			}
+			return cfg.notifier.notify(ctx, queries, likeEvent{
+				likerID: userID,
+				chirp:   chirp,
+			})
+		})
+	} else {
+		likeCount, err = cfg.db.UnlikeChirp(request.Context(), database.UnlikeChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+	}
+	if err != nil {
+		handleError("Could not update like", err, 500, writer)
+		return
+	}
+
+	response, err := json.Marshal(likeResponse{
+		LikeCount: likeCount,
+		LikedByMe: liked,
+	})
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) getLikedChirps(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) (map[uuid.UUID]bool, error) {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+
+	likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	liked := map[uuid.UUID]bool{}
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+	return liked, nil
+}
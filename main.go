@@ -1,64 +1,213 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/FFB6C1/bootdev_webservers/internal/blob"
 	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/memstore"
+	"github.com/FFB6C1/bootdev_webservers/internal/pubsub"
+	"github.com/FFB6C1/bootdev_webservers/internal/service"
+	"github.com/FFB6C1/bootdev_webservers/web"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
+const shutdownTimeout = 10 * time.Second
+const defaultAPIBaseURL = apiPrefix
+
 type apiConfig struct {
 	fileServerHits atomic.Int32
-	db             *database.Queries
+	db             database.Querier
+	store          database.Store
+	dbConn         *sql.DB
+	backend        backend
 	platform       string
 	secret         string
 	polkaKey       string
+
+	deletionGracePeriod time.Duration
+	timelineStrategy    string
+	adminEmails         map[string]bool
+	notifier            notificationProducer
+	hub                 *pubsub.Hub
+	events              eventPublisher
+	blobs               blob.Store
+	chirps              *service.ChirpService
+	users               *service.UserService
+
+	// Closed when the server starts shutting down, so long-lived streams and
+	// websockets can finish; Shutdown doesn't wait for hijacked connections.
+	shuttingDown chan struct{}
+	websockets   sync.WaitGroup
 }
 
 func main() {
 	godotenv.Load(".env")
+	command, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
+	}
+	if command == "serve" {
+		serve()
+		return
+	}
+	if err := runCommand(command, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve() {
 	dbURL := os.Getenv("DB_URL")
 	platform := os.Getenv("PLATFORM")
 	secret := os.Getenv("SECRET")
 	polkaKey := os.Getenv("POLKA_KEY")
-	db, err := sql.Open("postgres", dbURL)
+	deletionGracePeriod := defaultDeletionGracePeriod
+	if grace := os.Getenv("DELETION_GRACE_PERIOD"); grace != "" {
+		parsed, err := time.ParseDuration(grace)
+		if err != nil {
+			log.Fatal("Could not parse DELETION_GRACE_PERIOD:", err)
+		}
+		deletionGracePeriod = parsed
+	}
+	timelineStrategy := os.Getenv("TIMELINE_STRATEGY")
+	if timelineStrategy == "" {
+		timelineStrategy = timelineJoin
+	}
+	if timelineStrategy != timelineJoin && timelineStrategy != timelineFanOut {
+		log.Fatalf("TIMELINE_STRATEGY must be %q or %q", timelineJoin, timelineFanOut)
+	}
+	adminEmails := map[string]bool{}
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			adminEmails[email] = true
+		}
+	}
+	eventBroker := os.Getenv("EVENT_BROKER")
+	if eventBroker == "" {
+		eventBroker = eventBrokerLocal
+	}
+	if eventBroker != eventBrokerLocal && eventBroker != eventBrokerPostgres {
+		log.Fatalf("EVENT_BROKER must be %q or %q", eventBrokerLocal, eventBrokerPostgres)
+	}
+	// STATIC_DIR serves the frontend from disk instead of the binary.
+	staticDir := os.Getenv("STATIC_DIR")
+	apiBaseURL := os.Getenv("API_BASE_URL")
+	if apiBaseURL == "" {
+		apiBaseURL = defaultAPIBaseURL
+	}
+	blobs, err := makeBlobStore(os.Getenv("MEDIA_STORE"))
+	if err != nil {
+		log.Fatal("Could not set up media storage:", err)
+	}
+	startupTimeout, err := getEnvDuration("DB_STARTUP_TIMEOUT", defaultDBStartupTimeout)
 	if err != nil {
-		log.Fatal("Could not open database:", err)
+		log.Fatal(err)
+	}
+	var db *sql.DB
+	var dbBackend backend
+	var store database.Store
+	if platform == "dev" && dbURL == "" {
+		if eventBroker == eventBrokerPostgres {
+			log.Fatal("EVENT_BROKER=postgres needs DB_URL")
+		}
+		log.Print("No DB_URL set, using an in-memory store. Nothing is saved between runs.")
+		store = memstore.New()
+	} else {
+		db, dbBackend, err = openDatabase(dbURL)
+		if err != nil {
+			log.Fatal("Could not open database:", err)
+		}
+		if eventBroker == eventBrokerPostgres && dbBackend.name != postgresBackend.name {
+			log.Fatalf("EVENT_BROKER=postgres needs a Postgres DB_URL, not %s", dbBackend.name)
+		}
+		if err := waitForDatabase(db, startupTimeout); err != nil {
+			log.Fatal("Could not start: ", err)
+		}
+		if err := checkSchemaVersion(context.Background(), db, dbBackend); err != nil {
+			log.Fatal("Could not start: ", err)
+		}
+		store = dbBackend.newStore(db)
 	}
-	dbQueries := database.New(db)
 	apiConfig := apiConfig{
 		fileServerHits: atomic.Int32{},
-		db:             dbQueries,
+		db:             store,
+		store:          store,
+		dbConn:         db,
+		backend:        dbBackend,
 		platform:       platform,
 		secret:         secret,
 		polkaKey:       polkaKey,
+
+		deletionGracePeriod: deletionGracePeriod,
+		timelineStrategy:    timelineStrategy,
+		adminEmails:         adminEmails,
+		hub:                 pubsub.NewHub(eventHistorySize, eventBufferSize),
+		blobs:               blobs,
+		chirps:              service.NewChirpService(store),
+		users:               service.NewUserService(store, secret),
+
+		shuttingDown: make(chan struct{}),
+	}
+	apiConfig.notifier = dbNotifier{cfg: &apiConfig}
+	apiConfig.events = hubPublisher{hub: apiConfig.hub}
+	if eventBroker == eventBrokerPostgres {
+		apiConfig.events = postgresPublisher{db: store}
+		go listenForEvents(context.Background(), dbURL, apiConfig.hub)
 	}
-	mux := http.NewServeMux()
-	handler := http.StripPrefix("/app", http.FileServer(http.Dir(".")))
+	webHandler, err := web.Handler(web.Files(staticDir), web.Config{APIBaseURL: apiBaseURL}, staticDir != "")
+	if err != nil {
+		log.Fatal("Could not load frontend:", err)
+	}
+	mux := apiConfig.routes(webHandler)
 
-	mux.Handle("/app/", apiConfig.middlewareMetricsInc(handler))
-	mux.HandleFunc("GET /api/healthz", readinessHandler)
-	mux.HandleFunc("GET /admin/metrics", apiConfig.metricsHandler)
-	mux.HandleFunc("POST /admin/reset", apiConfig.resetHandler)
-	mux.HandleFunc("POST /api/chirps", apiConfig.postNewChirpHandler)
-	mux.HandleFunc("GET /api/chirps", apiConfig.getChirpsHandler)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiConfig.getChirpByIdHandler)
-	mux.HandleFunc("POST /api/users", apiConfig.newUserHandler)
-	mux.HandleFunc("POST /api/login", apiConfig.loginHandler)
-	mux.HandleFunc("POST /api/refresh", apiConfig.refreshHandler)
-	mux.HandleFunc("POST /api/revoke", apiConfig.revokeHandler)
-	mux.HandleFunc("PUT /api/users", apiConfig.updateEmailPasswordHandler)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiConfig.deleteChirpByIdHandler)
-	mux.HandleFunc("POST /api/polka/webhooks", apiConfig.recievePolkaEvent)
+	go apiConfig.purgeDeletedUsers(context.Background())
 
 	server := http.Server{
 		Addr:    ":8080",
 		Handler: mux,
 	}
-	server.ListenAndServe()
+	server.RegisterOnShutdown(func() {
+		close(apiConfig.shuttingDown)
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Could not shut down cleanly: %s", err)
+		}
+		websocketsClosed := make(chan struct{})
+		go func() {
+			apiConfig.websockets.Wait()
+			close(websocketsClosed)
+		}()
+		select {
+		case <-websocketsClosed:
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-stopped
 }
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/blob"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/memstore"
+	"github.com/FFB6C1/bootdev_webservers/internal/migrate"
+	"github.com/FFB6C1/bootdev_webservers/internal/pubsub"
+	"github.com/FFB6C1/bootdev_webservers/internal/service"
+	"github.com/FFB6C1/bootdev_webservers/web"
+	"github.com/google/uuid"
+)
+
+const (
+	testSecret     = "test-secret"
+	testPolkaKey   = "test-polka-key"
+	testPassword   = "correct horse battery staple"
+	testAdminEmail = "admin@example.com"
+)
+
+// The store the suite is running against: memory, sqlite or postgres.
+var testStore string
+
+// Runs every test against the in-memory store and SQLite, then against
+// Postgres too when TEST_DB_URL is set. That database is migrated and emptied
+// before each test.
+func TestMain(m *testing.M) {
+	stores := []string{"memory", "sqlite"}
+	if os.Getenv("TEST_DB_URL") != "" {
+		stores = append(stores, "postgres")
+	}
+	code := 0
+	for _, store := range stores {
+		testStore = store
+		fmt.Printf("Testing against the %s store\n", store)
+		if result := m.Run(); result != 0 {
+			code = result
+		}
+	}
+	os.Exit(code)
+}
+
+type testServer struct {
+	*httptest.Server
+	t   *testing.T
+	cfg *apiConfig
+}
+
+// newTestServer serves every route against an empty store, the way serve
+// wires them up with PLATFORM=dev.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	var store database.Store = memstore.New()
+	var db *sql.DB
+	var dbBackend backend
+	switch testStore {
+	case "sqlite":
+		db, dbBackend = openTestDatabase(t, sqliteScheme+filepath.Join(t.TempDir(), "chirpy.db"))
+		store = dbBackend.newStore(db)
+	case "postgres":
+		db, dbBackend = openTestDatabase(t, os.Getenv("TEST_DB_URL"))
+		emptyTestDatabase(t, db)
+		store = dbBackend.newStore(db)
+	}
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal("Could not make blob store:", err)
+	}
+	cfg := &apiConfig{
+		db:       store,
+		store:    store,
+		dbConn:   db,
+		backend:  dbBackend,
+		platform: "dev",
+		secret:   testSecret,
+		polkaKey: testPolkaKey,
+
+		deletionGracePeriod: defaultDeletionGracePeriod,
+		timelineStrategy:    timelineJoin,
+		adminEmails:         map[string]bool{testAdminEmail: true},
+		hub:                 pubsub.NewHub(eventHistorySize, eventBufferSize),
+		blobs:               blobs,
+		chirps:              service.NewChirpService(store),
+		users:               service.NewUserService(store, testSecret),
+
+		shuttingDown: make(chan struct{}),
+	}
+	cfg.notifier = dbNotifier{cfg: cfg}
+	cfg.events = hubPublisher{hub: cfg.hub}
+
+	frontend, err := web.Handler(web.Files(""), web.Config{APIBaseURL: defaultAPIBaseURL}, false)
+	if err != nil {
+		t.Fatal("Could not load frontend:", err)
+	}
+	server := httptest.NewServer(cfg.routes(frontend))
+	t.Cleanup(server.Close)
+	// Cleanups run last first, so streams and websockets end before Close
+	// waits for them.
+	t.Cleanup(func() { close(cfg.shuttingDown) })
+	return &testServer{Server: server, t: t, cfg: cfg}
+}
+
+func openTestDatabase(t *testing.T, dbURL string) (*sql.DB, backend) {
+	t.Helper()
+	db, dbBackend, err := openDatabase(dbURL)
+	if err != nil {
+		t.Fatal("Could not open database:", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, dbBackend.dialect, dbBackend.migrations)
+	if err != nil {
+		t.Fatal("Could not load migrations:", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal("Could not migrate:", err)
+	}
+	return db, dbBackend
+}
+
+func emptyTestDatabase(t *testing.T, db *sql.DB) {
+	t.Helper()
+	rows, err := db.Query(`
+		SELECT quote_ident(tablename) FROM pg_tables
+		WHERE schemaname = current_schema() AND tablename <> 'goose_db_version'`)
+	if err != nil {
+		t.Fatal("Could not list tables:", err)
+	}
+	defer rows.Close()
+	tables := []string{}
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, table)
+	}
+	if _, err := db.Exec("TRUNCATE " + strings.Join(tables, ", ") + " CASCADE"); err != nil {
+		t.Fatal("Could not empty database:", err)
+	}
+}
+
+// do sends body as JSON, fails the test unless the status is wantStatus, and
+// decodes the reply into out when it isn't nil. auth is a bearer token, or a
+// whole Authorization header if it has a space in it.
+func (server *testServer) do(method, path, auth string, body any, wantStatus int, out any) *http.Response {
+	server.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			server.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	request, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	return server.send(request, auth, wantStatus, out)
+}
+
+func (server *testServer) send(request *http.Request, auth string, wantStatus int, out any) *http.Response {
+	server.t.Helper()
+	if auth != "" && !strings.Contains(auth, " ") {
+		auth = "Bearer " + auth
+	}
+	if auth != "" {
+		request.Header.Set("Authorization", auth)
+	}
+	response, err := server.Client().Do(request)
+	if err != nil {
+		server.t.Fatalf("%s %s: %v", request.Method, request.URL.Path, err)
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	if response.StatusCode != wantStatus {
+		server.t.Fatalf("%s %s: got %d, want %d: %s", request.Method, request.URL.Path, response.StatusCode, wantStatus, responseBody)
+	}
+	if out != nil {
+		if err := json.Unmarshal(responseBody, out); err != nil {
+			server.t.Fatalf("%s %s: could not decode %s: %v", request.Method, request.URL.Path, responseBody, err)
+		}
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+	return response
+}
+
+// signUp registers email and logs in. If handle isn't empty it's set on the
+// profile too.
+func (server *testServer) signUp(email, handle string) newUserResponse {
+	server.t.Helper()
+	server.do("POST", "/api/v1/users", "", userRequest{Email: email, Password: testPassword}, 201, nil)
+	user := newUserResponse{}
+	server.do("POST", "/api/v1/login", "", userRequest{Email: email, Password: testPassword}, 200, &user)
+	if handle != "" {
+		server.do("PUT", "/api/v1/users/profile", user.Token, profileRequest{Handle: handle}, 200, nil)
+		user.Handle = handle
+	}
+	return user
+}
+
+func (server *testServer) postChirp(token string, body chirp) chirpResponse {
+	server.t.Helper()
+	response := chirpResponse{}
+	server.do("POST", "/api/v1/chirps", token, body, 201, &response)
+	return response
+}
+
+func chirpIDs(chirps []chirpResponse) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.Id)
+	}
+	return ids
+}
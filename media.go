@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/blob"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	mediaStoreLocal = "local"
+	mediaStoreS3    = "s3"
+)
+
+const defaultMediaDir = "media"
+const maxMediaSize = 5 << 20
+const maxChirpMedia = 4
+const unattachedMediaLifetime = 24 * time.Hour
+
+type mediaResponse struct {
+	Id          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int32     `json:"size_bytes"`
+	Width       int32     `json:"width"`
+	Height      int32     `json:"height"`
+}
+
+// Takes a multipart form with the image in a field called file.
+func (cfg *apiConfig) uploadMediaHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, auth.ScopeChirpsWrite)
+	if err != nil {
+		handleError("Unauthorized", err, code, writer)
+		return
+	}
+
+	// Leave room for the rest of the multipart body around the file.
+	request.Body = http.MaxBytesReader(writer, request.Body, maxMediaSize+64<<10)
+	file, _, err := request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			handleError("Upload too large", err, 413, writer)
+			return
+		}
+		handleError("Could not read upload", err, 400, writer)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaSize+1))
+	if err != nil {
+		handleError("Could not read upload", err, 400, writer)
+		return
+	}
+	if len(data) > maxMediaSize {
+		handleError(fmt.Sprintf("Uploads can be at most %d bytes", maxMediaSize), nil, 413, writer)
+		return
+	}
+
+	info, cleaned, err := media.Process(data)
+	if err != nil {
+		handleError("Unsupported media", err, 415, writer)
+		return
+	}
+
+	mediaID := uuid.New()
+	blobKey := "media/" + mediaID.String() + info.Extension
+	if err := cfg.blobs.Put(request.Context(), blobKey, info.ContentType, cleaned); err != nil {
+		handleError("Could not store upload", err, 500, writer)
+		return
+	}
+
+	mediaFile, err := cfg.db.CreateMediaFile(request.Context(), database.CreateMediaFileParams{
+		ID:          mediaID,
+		UserID:      userID,
+		BlobKey:     blobKey,
+		ContentType: info.ContentType,
+		SizeBytes:   int32(len(cleaned)),
+		Width:       int32(info.Width),
+		Height:      int32(info.Height),
+	})
+	if err != nil {
+		if err := cfg.blobs.Delete(context.Background(), blobKey); err != nil {
+			log.Printf("Could not clean up blob %s: %s", blobKey, err)
+		}
+		handleError("Could not save upload", err, 500, writer)
+		return
+	}
+
+	response, err := json.Marshal(makeMediaResponse(mediaFile))
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(201)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) getMediaHandler(writer http.ResponseWriter, request *http.Request) {
+	mediaID, err := uuid.Parse(request.PathValue("mediaID"))
+	if err != nil {
+		handleError("Could not parse media ID", err, 400, writer)
+		return
+	}
+
+	mediaFile, err := cfg.db.GetMediaFileByID(request.Context(), mediaID)
+	if err != nil {
+		handleError("Could not find media", err, 404, writer)
+		return
+	}
+
+	body, err := cfg.blobs.Get(request.Context(), mediaFile.BlobKey)
+	if err != nil {
+		code := 500
+		if errors.Is(err, blob.ErrNotFound) {
+			code = 404
+		}
+		handleError("Could not get media", err, code, writer)
+		return
+	}
+	defer body.Close()
+
+	// Media never changes once uploaded.
+	writer.Header().Set("Content-Type", mediaFile.ContentType)
+	writer.Header().Set("Content-Length", fmt.Sprint(mediaFile.SizeBytes))
+	writer.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.WriteHeader(200)
+	io.Copy(writer, body)
+}
+
+// Users can only attach media they uploaded themselves, each at most once.
+func (cfg *apiConfig) getAttachableMedia(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) > maxChirpMedia {
+		return nil, fmt.Errorf("Chirps can have at most %d media", maxChirpMedia)
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return nil, fmt.Errorf("Media %s is attached twice", id)
+		}
+		seen[id] = true
+	}
+	mediaFiles, err := cfg.db.GetMediaFilesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	owned := map[uuid.UUID]bool{}
+	for _, mediaFile := range mediaFiles {
+		owned[mediaFile.ID] = mediaFile.UserID == userID
+	}
+	for _, id := range ids {
+		if !owned[id] {
+			return nil, fmt.Errorf("Could not find media %s", id)
+		}
+	}
+	return ids, nil
+}
+
+func (cfg *apiConfig) getChirpMedia(ctx context.Context, chirps []database.Chirp) (map[uuid.UUID][]mediaResponse, error) {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+
+	attachments, err := cfg.db.GetChirpAttachments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[uuid.UUID][]mediaResponse{}
+	for _, attachment := range attachments {
+		found[attachment.ChirpID] = append(found[attachment.ChirpID], makeMediaResponse(attachment.MediaFile))
+	}
+	return found, nil
+}
+
+// Rows cascade away with their users without touching the blobs, so purges
+// collect media first. Media that was never attached, or whose chirps are
+// gone, is collected once it's too old to be about to be attached.
+func (cfg *apiConfig) collectMedia(ctx context.Context, deletedBefore sql.NullTime, uploadedBefore time.Time) (int, error) {
+	mediaFiles, err := cfg.db.GetCollectableMediaFiles(ctx, database.GetCollectableMediaFilesParams{
+		DeletedBefore:  deletedBefore,
+		UploadedBefore: uploadedBefore,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(mediaFiles), cfg.deleteMediaFiles(ctx, mediaFiles)
+}
+
+// Blobs go first, so a failure leaves the row for the next sweep to retry
+// rather than a blob nothing points to.
+func (cfg *apiConfig) deleteMediaFiles(ctx context.Context, mediaFiles []database.MediaFile) error {
+	for _, mediaFile := range mediaFiles {
+		if err := cfg.blobs.Delete(ctx, mediaFile.BlobKey); err != nil {
+			return fmt.Errorf("Could not delete blob %s: %w", mediaFile.BlobKey, err)
+		}
+		if err := cfg.db.DeleteMediaFile(ctx, mediaFile.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func makeMediaResponse(mediaFile database.MediaFile) mediaResponse {
+	return mediaResponse{
+		Id:          mediaFile.ID,
+		URL:         apiPrefix + "/media/" + mediaFile.ID.String(),
+		ContentType: mediaFile.ContentType,
+		SizeBytes:   mediaFile.SizeBytes,
+		Width:       mediaFile.Width,
+		Height:      mediaFile.Height,
+	}
+}
+
+func makeBlobStore(kind string) (blob.Store, error) {
+	switch kind {
+	case "", mediaStoreLocal:
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = defaultMediaDir
+		}
+		return blob.NewLocalStore(dir)
+	case mediaStoreS3:
+		store := &blob.S3Store{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Region:          os.Getenv("S3_REGION"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		}
+		if store.Endpoint == "" || store.Bucket == "" || store.Region == "" {
+			return nil, fmt.Errorf("S3_ENDPOINT, S3_BUCKET and S3_REGION are required")
+		}
+		return store, nil
+	}
+	return nil, fmt.Errorf("MEDIA_STORE must be %q or %q", mediaStoreLocal, mediaStoreS3)
+}
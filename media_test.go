@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/blob"
+	"github.com/google/uuid"
+)
+
+func (server *testServer) upload(token string, data []byte, wantStatus int, out any) {
+	server.t.Helper()
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", "upload")
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	request, err := http.NewRequest("POST", server.URL+"/api/v1/media", body)
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	server.send(request, token, wantStatus, out)
+}
+
+func testPicture(t *testing.T) []byte {
+	t.Helper()
+	picture := &bytes.Buffer{}
+	if err := png.Encode(picture, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return picture.Bytes()
+}
+
+func TestMedia(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	picture := testPicture(t)
+
+	server.upload("", picture, 401, nil)
+	server.upload(user.Token, []byte("not an image"), 415, nil)
+	uploaded := mediaResponse{}
+	server.upload(user.Token, picture, 201, &uploaded)
+	if uploaded.ContentType != "image/png" || uploaded.Width != 3 || uploaded.Height != 2 {
+		t.Fatalf("uploaded = %+v", uploaded)
+	}
+
+	response := server.do("GET", uploaded.URL, "", nil, 200, nil)
+	data, _ := io.ReadAll(response.Body)
+	if response.Header.Get("Content-Type") != "image/png" || int32(len(data)) != uploaded.SizeBytes {
+		t.Fatalf("got %d bytes of %s", len(data), response.Header.Get("Content-Type"))
+	}
+
+	posted := server.postChirp(user.Token, chirp{Body: "look", MediaIds: []uuid.UUID{uploaded.Id}})
+	if len(posted.Media) != 1 || posted.Media[0].Id != uploaded.Id {
+		t.Fatalf("chirp media = %+v", posted.Media)
+	}
+	server.do("POST", "/api/v1/chirps", user.Token, chirp{Body: "twice", MediaIds: []uuid.UUID{uploaded.Id, uploaded.Id}}, 400, nil)
+	other := server.signUp("b@example.com", "")
+	server.do("POST", "/api/v1/chirps", other.Token, chirp{Body: "mine now", MediaIds: []uuid.UUID{uploaded.Id}}, 400, nil)
+	server.do("GET", "/api/v1/media/not-a-uuid", "", nil, 400, nil)
+}
+
+func TestMediaCollection(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	user := server.signUp("a@example.com", "")
+	upload := func() (mediaResponse, string) {
+		uploaded := mediaResponse{}
+		server.upload(user.Token, testPicture(t), 201, &uploaded)
+		mediaFile, err := server.cfg.db.GetMediaFileByID(ctx, uploaded.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uploaded, mediaFile.BlobKey
+	}
+	stored := func(blobKey string) bool {
+		body, err := server.cfg.blobs.Get(ctx, blobKey)
+		if errors.Is(err, blob.ErrNotFound) {
+			return false
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body.Close()
+		return true
+	}
+
+	attached, attachedKey := upload()
+	posted := server.postChirp(user.Token, chirp{Body: "look", MediaIds: []uuid.UUID{attached.Id}})
+	_, unattachedKey := upload()
+	if collected, err := server.cfg.collectMedia(ctx, sql.NullTime{}, time.Now().Add(time.Hour)); err != nil || collected != 1 {
+		t.Fatalf("collectMedia = %d, %v; want only the unattached upload", collected, err)
+	}
+	if stored(unattachedKey) || !stored(attachedKey) {
+		t.Fatal("collected the wrong blob")
+	}
+
+	server.do("DELETE", "/api/v1/chirps/"+posted.Id.String(), user.Token, nil, 204, nil)
+	if collected, err := server.cfg.collectMedia(ctx, sql.NullTime{}, time.Now().Add(time.Hour)); err != nil || collected != 1 {
+		t.Fatalf("collectMedia after deleting the chirp = %d, %v", collected, err)
+	}
+	server.do("GET", attached.URL, "", nil, 404, nil)
+	if stored(attachedKey) {
+		t.Fatal("blob of a deleted chirp's media survived")
+	}
+
+	kept, keptKey := upload()
+	server.postChirp(user.Token, chirp{Body: "again", MediaIds: []uuid.UUID{kept.Id}})
+	server.do("DELETE", "/api/v1/users", user.Token, deleteUserRequest{Password: testPassword}, 202, nil)
+	server.cfg.deletionGracePeriod = -time.Hour
+	server.cfg.purgeOnce(ctx)
+	if stored(keptKey) {
+		t.Fatal("blob of a purged user's media survived")
+	}
+	if _, err := server.cfg.db.GetMediaFileByID(ctx, kept.Id); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("purged user's media = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/migrate"
+)
+
+const migrateUsage = "usage: chirpy migrate up|down|status|redo"
+
+func runMigrateCommand(db *sql.DB, backend backend, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf(migrateUsage)
+	}
+	migrator, err := migrate.New(db, backend.dialect, backend.migrations)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		ran, err := migrator.Up(ctx)
+		for _, migration := range ran {
+			fmt.Println("Applied", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Println("Already up to date")
+		}
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Rolled back", migration.Name)
+	case "redo":
+		migration, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Redid", migration.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Printf("%-19s  %s\n", appliedAt, status.Migration.Name)
+		}
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+	return nil
+}
+
+// The server only runs against the schema its queries were generated for.
+func checkSchemaVersion(ctx context.Context, db *sql.DB, backend backend) error {
+	migrator, err := migrate.New(db, backend.dialect, backend.migrations)
+	if err != nil {
+		return err
+	}
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version != backend.schemaVersion {
+		return fmt.Errorf("database is at schema version %d but this build expects %d, run `chirpy migrate up`", version, backend.schemaVersion)
+	}
+	return nil
+}
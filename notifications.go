@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	notificationMention = "mention"
+	notificationReply   = "reply"
+	notificationLike    = "like"
+	notificationFollow  = "follow"
+)
+
+// A notificationEvent knows who should hear about it. Adding a new kind of
+// notification means adding an event type; the producer doesn't change.
+type notificationEvent interface {
+	notifications() []database.CreateNotificationParams
+}
+
+// The producer writes through the caller's queries so notifications commit or
+// roll back with the change that caused them.
+type notificationProducer interface {
+	notify(ctx context.Context, queries database.Querier, event notificationEvent) error
+}
+
+// Self-notifications, duplicates and types the recipient has turned off are
+// filtered out by CreateNotification. Whatever is left is streamed to the
+// recipient once the transaction commits.
+type dbNotifier struct {
+	cfg *apiConfig
+}
+
+func (notifier dbNotifier) notify(ctx context.Context, queries database.Querier, event notificationEvent) error {
+	for _, params := range event.notifications() {
+		created, err := queries.CreateNotification(ctx, params)
+		if err != nil {
+			return err
+		}
+		if len(created) == 0 {
+			continue
+		}
+
+		actor, err := queries.GetUserByID(ctx, params.ActorID)
+		if err != nil {
+			return err
+		}
+		for _, notification := range created {
+			response := makeNotificationResponse(database.GetNotificationsRow{
+				ID:               notification.ID,
+				CreatedAt:        notification.CreatedAt,
+				UserID:           notification.UserID,
+				ActorID:          notification.ActorID,
+				Type:             notification.Type,
+				ChirpID:          notification.ChirpID,
+				ReadAt:           notification.ReadAt,
+				ActorHandle:      actor.Handle,
+				ActorDisplayName: actor.DisplayName,
+				ActorAvatarUrl:   actor.AvatarUrl,
+			})
+			database.AfterCommit(ctx, func() {
+				notifier.cfg.publishEvent(ctx, eventNotification, notification.UserID, response)
+			})
+		}
+	}
+	return nil
+}
+
+type mentionEvent struct {
+	chirp     database.Chirp
+	mentioned []uuid.UUID
+}
+
+func (event mentionEvent) notifications() []database.CreateNotificationParams {
+	notifications := []database.CreateNotificationParams{}
+	for _, userID := range event.mentioned {
+		notifications = append(notifications, database.CreateNotificationParams{
+			UserID:  userID,
+			ActorID: event.chirp.UserID,
+			Type:    notificationMention,
+			ChirpID: uuid.NullUUID{UUID: event.chirp.ID, Valid: true},
+		})
+	}
+	return notifications
+}
+
+type replyEvent struct {
+	reply  database.Chirp
+	parent database.Chirp
+}
+
+func (event replyEvent) notifications() []database.CreateNotificationParams {
+	return []database.CreateNotificationParams{{
+		UserID:  event.parent.UserID,
+		ActorID: event.reply.UserID,
+		Type:    notificationReply,
+		ChirpID: uuid.NullUUID{UUID: event.reply.ID, Valid: true},
+	}}
+}
+
+type likeEvent struct {
+	likerID uuid.UUID
+	chirp   database.Chirp
+}
+
+func (event likeEvent) notifications() []database.CreateNotificationParams {
+	return []database.CreateNotificationParams{{
+		UserID:  event.chirp.UserID,
+		ActorID: event.likerID,
+		Type:    notificationLike,
+		ChirpID: uuid.NullUUID{UUID: event.chirp.ID, Valid: true},
+	}}
+}
+
+type followEvent struct {
+	followerID uuid.UUID
+	followeeID uuid.UUID
+}
+
+func (event followEvent) notifications() []database.CreateNotificationParams {
+	return []database.CreateNotificationParams{{
+		UserID:  event.followeeID,
+		ActorID: event.followerID,
+		Type:    notificationFollow,
+	}}
+}
+
+type notificationResponse struct {
+	Id        uuid.UUID   `json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Type      string      `json:"type"`
+	Actor     chirpAuthor `json:"actor"`
+	ChirpId   *uuid.UUID  `json:"chirp_id,omitempty"`
+	Read      bool        `json:"read"`
+}
+
+type notificationListResponse struct {
+	UnreadCount   int64                  `json:"unread_count"`
+	Notifications []notificationResponse `json:"notifications"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
+type markReadRequest struct {
+	Ids []uuid.UUID `json:"ids"`
+	All bool        `json:"all"`
+}
+
+type unreadCountResponse struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+// Pass ?unread=true to see only unread notifications.
+func (cfg *apiConfig) getNotificationsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	pageSize, err := getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+	beforeCreatedAt, beforeID, err := getCursor(request)
+	if err != nil {
+		handleError("Bad cursor", err, 400, writer)
+		return
+	}
+
+	unreadCount, err := cfg.db.CountUnreadNotifications(request.Context(), userID)
+	if err != nil {
+		handleError("Could not count notifications", err, 500, writer)
+		return
+	}
+
+	notifications, err := cfg.db.GetNotifications(request.Context(), database.GetNotificationsParams{
+		UserID:          userID,
+		UnreadOnly:      request.URL.Query().Get("unread") == "true",
+		BeforeCreatedAt: beforeCreatedAt,
+		BeforeID:        beforeID,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		handleError("Could not get notifications", err, 500, writer)
+		return
+	}
+
+	response := notificationListResponse{UnreadCount: unreadCount, Notifications: []notificationResponse{}}
+	for _, notification := range notifications {
+		response.Notifications = append(response.Notifications, makeNotificationResponse(notification))
+	}
+	if len(notifications) == int(pageSize) {
+		last := notifications[len(notifications)-1]
+		response.NextCursor = makeCursor(last.CreatedAt, last.ID)
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+func (cfg *apiConfig) markNotificationsReadHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	params := markReadRequest{}
+	if !decodeRequest(writer, request, &params) {
+		return
+	}
+
+	if params.All {
+		_, err = cfg.db.MarkAllNotificationsRead(request.Context(), userID)
+	} else if len(params.Ids) > 0 {
+		_, err = cfg.db.MarkNotificationsRead(request.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    params.Ids,
+		})
+	} else {
+		handleError("Send ids or all", nil, 400, writer)
+		return
+	}
+	if err != nil {
+		handleError("Could not mark notifications read", err, 500, writer)
+		return
+	}
+
+	unreadCount, err := cfg.db.CountUnreadNotifications(request.Context(), userID)
+	if err != nil {
+		handleError("Could not count notifications", err, 500, writer)
+		return
+	}
+
+	responseJSON, err := json.Marshal(unreadCountResponse{UnreadCount: unreadCount})
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+func (cfg *apiConfig) getNotificationPreferencesHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+	cfg.writeNotificationPreferences(userID, writer, request)
+}
+
+// Takes a map of type to enabled; types left out keep their current setting.
+func (cfg *apiConfig) updateNotificationPreferencesHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	params := map[string]bool{}
+	if !decodeRequest(writer, request, &params) {
+		return
+	}
+	for notificationType := range params {
+		if _, ok := getNotificationTypes()[notificationType]; !ok {
+			handleError("Unknown notification type", fmt.Errorf("Unknown notification type %s", notificationType), 400, writer)
+			return
+		}
+	}
+
+	err = cfg.store.WithTx(request.Context(), func(ctx context.Context, queries database.Querier) error {
+		for notificationType, enabled := range params {
+			if err := queries.SetNotificationPreference(ctx, database.SetNotificationPreferenceParams{
+				UserID:  userID,
+				Type:    notificationType,
+				Enabled: enabled,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		handleError("Could not update preferences", err, 500, writer)
+		return
+	}
+
+	cfg.writeNotificationPreferences(userID, writer, request)
+}
+
+// Every type is on until the user turns it off.
+func (cfg *apiConfig) writeNotificationPreferences(userID uuid.UUID, writer http.ResponseWriter, request *http.Request) {
+	preferences, err := cfg.db.GetNotificationPreferences(request.Context(), userID)
+	if err != nil {
+		handleError("Could not get preferences", err, 500, writer)
+		return
+	}
+
+	response := map[string]bool{}
+	for notificationType := range getNotificationTypes() {
+		response[notificationType] = true
+	}
+	for _, preference := range preferences {
+		response[preference.Type] = preference.Enabled
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+func makeNotificationResponse(notification database.GetNotificationsRow) notificationResponse {
+	return notificationResponse{
+		Id:        notification.ID,
+		CreatedAt: notification.CreatedAt,
+		Type:      notification.Type,
+		Actor: chirpAuthor{
+			Id:          notification.ActorID,
+			Handle:      notification.ActorHandle.String,
+			DisplayName: notification.ActorDisplayName,
+			AvatarURL:   notification.ActorAvatarUrl,
+		},
+		ChirpId: nullUUIDPointer(notification.ChirpID),
+		Read:    notification.ReadAt.Valid,
+	}
+}
+
+func getNotificationTypes() map[string]int {
+	return map[string]int{
+		notificationMention: 0,
+		notificationReply:   0,
+		notificationLike:    0,
+		notificationFollow:  0,
+	}
+}
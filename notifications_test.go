@@ -0,0 +1,78 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNotifications(t *testing.T) {
+	server := newTestServer(t)
+	alice := server.signUp("alice@example.com", "alice")
+	bob := server.signUp("bob@example.com", "bob")
+
+	posted := server.postChirp(alice.Token, chirp{Body: "hello"})
+	server.do("POST", "/api/v1/users/alice/follow", bob.Token, nil, 204, nil)
+	server.do("POST", "/api/v1/chirps/"+posted.Id.String()+"/like", bob.Token, nil, 200, nil)
+	server.postChirp(bob.Token, chirp{Body: "hi @alice", ReplyToId: &posted.Id})
+	// Nobody is told about their own actions.
+	server.do("POST", "/api/v1/chirps/"+posted.Id.String()+"/like", alice.Token, nil, 200, nil)
+
+	list := notificationListResponse{}
+	server.do("GET", "/api/v1/notifications", alice.Token, nil, 200, &list)
+	types := []string{}
+	for _, notification := range list.Notifications {
+		types = append(types, notification.Type)
+		if notification.Actor.Handle != "bob" || notification.Read {
+			t.Fatalf("notification = %+v", notification)
+		}
+	}
+	slices.Sort(types)
+	want := []string{notificationFollow, notificationLike, notificationMention, notificationReply}
+	if list.UnreadCount != 4 || !slices.Equal(types, want) {
+		t.Fatalf("notifications = %v, %d unread", types, list.UnreadCount)
+	}
+
+	unread := unreadCountResponse{}
+	server.do("POST", "/api/v1/notifications/read", alice.Token, markReadRequest{Ids: []uuid.UUID{list.Notifications[0].Id}}, 200, &unread)
+	if unread.UnreadCount != 3 {
+		t.Fatalf("unread after reading one = %d", unread.UnreadCount)
+	}
+	server.do("GET", "/api/v1/notifications?unread=true", alice.Token, nil, 200, &list)
+	if len(list.Notifications) != 3 {
+		t.Fatalf("%d unread notifications listed", len(list.Notifications))
+	}
+	server.do("POST", "/api/v1/notifications/read", alice.Token, markReadRequest{All: true}, 200, &unread)
+	if unread.UnreadCount != 0 {
+		t.Fatalf("unread after reading all = %d", unread.UnreadCount)
+	}
+	server.do("GET", "/api/v1/notifications", "", nil, 401, nil)
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	server := newTestServer(t)
+	alice := server.signUp("alice@example.com", "alice")
+	bob := server.signUp("bob@example.com", "bob")
+
+	preferences := map[string]bool{}
+	server.do("GET", "/api/v1/notifications/preferences", alice.Token, nil, 200, &preferences)
+	for _, kind := range []string{notificationMention, notificationReply, notificationLike, notificationFollow} {
+		if !preferences[kind] {
+			t.Fatalf("%s notifications off by default: %v", kind, preferences)
+		}
+	}
+
+	server.do("PUT", "/api/v1/notifications/preferences", alice.Token, map[string]bool{"carrier pigeon": true}, 400, nil)
+	server.do("PUT", "/api/v1/notifications/preferences", alice.Token, map[string]bool{notificationFollow: false}, 200, &preferences)
+	if preferences[notificationFollow] || !preferences[notificationLike] {
+		t.Fatalf("preferences = %v", preferences)
+	}
+
+	server.do("POST", "/api/v1/users/alice/follow", bob.Token, nil, 204, nil)
+	list := notificationListResponse{}
+	server.do("GET", "/api/v1/notifications", alice.Token, nil, 200, &list)
+	if len(list.Notifications) != 0 {
+		t.Fatalf("muted follow notified: %+v", list.Notifications)
+	}
+}
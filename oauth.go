@@ -0,0 +1,495 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+const oauthCodeLifetime = 10 * time.Minute
+const oauthAccessTokenLifetime = time.Hour
+
+type oauthClientRequest struct {
+	Name         string   `json:"name" validate:"required"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required"`
+	Scope        string   `json:"scope"`
+	Confidential bool     `json:"confidential"`
+}
+
+type oauthClientResponse struct {
+	ClientID     uuid.UUID `json:"client_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scope        string    `json:"scope"`
+	Confidential bool      `json:"confidential"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+}
+
+type authorizeRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Approve             bool   `json:"approve"`
+}
+
+type consentResponse struct {
+	ClientID    uuid.UUID `json:"client_id"`
+	ClientName  string    `json:"client_name"`
+	Scope       string    `json:"scope"`
+	RedirectURI string    `json:"redirect_uri"`
+	State       string    `json:"state"`
+}
+
+type authorizeResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+func (cfg *apiConfig) createOAuthClientHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	clientRequest := oauthClientRequest{}
+	if !decodeRequest(writer, request, &clientRequest) {
+		return
+	}
+
+	if err := checkRedirectURIs(clientRequest.RedirectURIs); err != nil {
+		handleError("Bad redirect URIs", err, 400, writer)
+		return
+	}
+	if err := auth.ValidateScopes(clientRequest.Scope); err != nil {
+		handleError("Bad scope", err, 400, writer)
+		return
+	}
+
+	clientParams := database.CreateOAuthClientParams{
+		OwnerID:      userID,
+		Name:         clientRequest.Name,
+		RedirectUris: strings.Join(clientRequest.RedirectURIs, " "),
+		Scope:        strings.Join(strings.Fields(clientRequest.Scope), " "),
+	}
+
+	secret := ""
+	if clientRequest.Confidential {
+		secret, err = auth.MakeRefreshToken()
+		if err != nil {
+			handleError("Could not make client secret", err, 500, writer)
+			return
+		}
+		hashed, err := auth.HashPassword(secret)
+		if err != nil {
+			handleError("Could not hash client secret", err, 500, writer)
+			return
+		}
+		clientParams.SecretHash = sql.NullString{String: hashed, Valid: true}
+	}
+
+	client, err := cfg.db.CreateOAuthClient(request.Context(), clientParams)
+	if err != nil {
+		handleError("Could not create client", err, 500, writer)
+		return
+	}
+
+	response := makeOAuthClientResponse(client)
+	response.ClientSecret = secret
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Created client but cannot respond", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(201)
+	writer.Write(responseJSON)
+}
+
+func (cfg *apiConfig) getOAuthClientsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	clients, err := cfg.db.GetOAuthClientsByOwner(request.Context(), userID)
+	if err != nil {
+		handleError("Could not get clients", err, 500, writer)
+		return
+	}
+
+	response := []oauthClientResponse{}
+	for _, client := range clients {
+		response = append(response, makeOAuthClientResponse(client))
+	}
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+func (cfg *apiConfig) deleteOAuthClientHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	clientID, err := uuid.Parse(request.PathValue("clientID"))
+	if err != nil {
+		handleError("Could not parse client ID", err, 400, writer)
+		return
+	}
+
+	deleteParams := database.DeleteOAuthClientParams{
+		ID:      clientID,
+		OwnerID: userID,
+	}
+	deleted, err := cfg.db.DeleteOAuthClient(request.Context(), deleteParams)
+	if err != nil {
+		handleError("Could not delete client", err, 500, writer)
+		return
+	}
+	if deleted == 0 {
+		handleError("Could not find client", nil, 404, writer)
+		return
+	}
+
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) getAuthorizeHandler(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	authRequest := authorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	client, err := cfg.checkAuthorizeRequest(request, authRequest)
+	if err != nil {
+		handleError("Bad authorization request", err, 400, writer)
+		return
+	}
+
+	response, err := json.Marshal(consentResponse{
+		ClientID:    client.ID,
+		ClientName:  client.Name,
+		Scope:       authRequest.Scope,
+		RedirectURI: authRequest.RedirectURI,
+		State:       authRequest.State,
+	})
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) postAuthorizeHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	authRequest := authorizeRequest{}
+	if !decodeRequest(writer, request, &authRequest) {
+		return
+	}
+
+	client, err := cfg.checkAuthorizeRequest(request, authRequest)
+	if err != nil {
+		handleError("Bad authorization request", err, 400, writer)
+		return
+	}
+
+	redirect, err := url.Parse(authRequest.RedirectURI)
+	if err != nil {
+		handleError("Bad redirect URI", err, 400, writer)
+		return
+	}
+	redirectQuery := redirect.Query()
+	if authRequest.State != "" {
+		redirectQuery.Set("state", authRequest.State)
+	}
+
+	if !authRequest.Approve {
+		redirectQuery.Set("error", "access_denied")
+	} else {
+		authCode, err := auth.MakeRefreshToken()
+		if err != nil {
+			handleError("Could not make authorization code", err, 500, writer)
+			return
+		}
+		codeParams := database.AddOAuthCodeParams{
+			Code:                authCode,
+			ClientID:            client.ID,
+			UserID:              userID,
+			RedirectUri:         authRequest.RedirectURI,
+			Scope:               strings.Join(strings.Fields(authRequest.Scope), " "),
+			CodeChallenge:       authRequest.CodeChallenge,
+			CodeChallengeMethod: authRequest.CodeChallengeMethod,
+			ExpiresAt:           time.Now().Add(oauthCodeLifetime),
+		}
+		if err := cfg.db.AddOAuthCode(request.Context(), codeParams); err != nil {
+			handleError("Could not save authorization code", err, 500, writer)
+			return
+		}
+		redirectQuery.Set("code", authCode)
+	}
+	redirect.RawQuery = redirectQuery.Encode()
+
+	response, err := json.Marshal(authorizeResponse{RedirectTo: redirect.String()})
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) oauthTokenHandler(writer http.ResponseWriter, request *http.Request) {
+	if err := request.ParseForm(); err != nil {
+		handleOAuthError("invalid_request", err, 400, writer)
+		return
+	}
+
+	clientIDString, clientSecret, ok := request.BasicAuth()
+	if !ok {
+		clientIDString = request.PostForm.Get("client_id")
+		clientSecret = request.PostForm.Get("client_secret")
+	}
+	clientID, err := uuid.Parse(clientIDString)
+	if err != nil {
+		handleOAuthError("invalid_client", err, 401, writer)
+		return
+	}
+	client, err := cfg.db.GetOAuthClient(request.Context(), clientID)
+	if err != nil {
+		handleOAuthError("invalid_client", err, 401, writer)
+		return
+	}
+	if client.SecretHash.Valid {
+		if err := auth.CheckPasswordHash(clientSecret, client.SecretHash.String); err != nil {
+			handleOAuthError("invalid_client", err, 401, writer)
+			return
+		}
+	}
+
+	switch request.PostForm.Get("grant_type") {
+	case "authorization_code":
+		cfg.exchangeOAuthCode(client, writer, request)
+	case "refresh_token":
+		cfg.refreshOAuthToken(client, writer, request)
+	default:
+		handleOAuthError("unsupported_grant_type", nil, 400, writer)
+	}
+}
+
+func (cfg *apiConfig) exchangeOAuthCode(client database.OauthClient, writer http.ResponseWriter, request *http.Request) {
+	authCode, err := cfg.db.GetOAuthCode(request.Context(), request.PostForm.Get("code"))
+	if err != nil {
+		handleOAuthError("invalid_grant", err, 400, writer)
+		return
+	}
+	if err := checkOAuthCodeValid(authCode, client, request.PostForm.Get("redirect_uri")); err != nil {
+		handleOAuthError("invalid_grant", err, 400, writer)
+		return
+	}
+	if err := auth.VerifyPKCE(request.PostForm.Get("code_verifier"), authCode.CodeChallenge, authCode.CodeChallengeMethod); err != nil {
+		handleOAuthError("invalid_grant", err, 400, writer)
+		return
+	}
+
+	used, err := cfg.db.UseOAuthCode(request.Context(), authCode.Code)
+	if err != nil {
+		handleOAuthError("server_error", err, 500, writer)
+		return
+	}
+	if used == 0 {
+		handleOAuthError("invalid_grant", fmt.Errorf("Code Used"), 400, writer)
+		return
+	}
+
+	cfg.writeOAuthTokens(client, authCode.UserID, authCode.Scope, writer, request)
+}
+
+func (cfg *apiConfig) refreshOAuthToken(client database.OauthClient, writer http.ResponseWriter, request *http.Request) {
+	tokenFull, err := cfg.db.GetToken(request.Context(), request.PostForm.Get("refresh_token"))
+	if err != nil {
+		handleOAuthError("invalid_grant", err, 400, writer)
+		return
+	}
+	if err := checkTokenValid(tokenFull); err != nil {
+		handleOAuthError("invalid_grant", err, 400, writer)
+		return
+	}
+	if !tokenFull.ClientID.Valid || tokenFull.ClientID.UUID != client.ID {
+		handleOAuthError("invalid_grant", fmt.Errorf("Token issued to another client"), 400, writer)
+		return
+	}
+
+	if err := cfg.db.RevokeToken(request.Context(), tokenFull.Token); err != nil {
+		handleOAuthError("server_error", err, 500, writer)
+		return
+	}
+
+	cfg.writeOAuthTokens(client, tokenFull.UserID, tokenFull.Scope.String, writer, request)
+}
+
+func (cfg *apiConfig) writeOAuthTokens(client database.OauthClient, userID uuid.UUID, scope string, writer http.ResponseWriter, request *http.Request) {
+	accessToken, err := auth.MakeScopedJWT(userID, cfg.secret, oauthAccessTokenLifetime, scope)
+	if err != nil {
+		handleOAuthError("server_error", err, 500, writer)
+		return
+	}
+
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		handleOAuthError("server_error", err, 500, writer)
+		return
+	}
+
+	refreshTokenParams := database.AddOAuthRefreshTokenParams{
+		Token:     refreshToken,
+		UserID:    userID,
+		ExpiresAt: time.Now().AddDate(0, 0, 60),
+		ClientID:  uuid.NullUUID{UUID: client.ID, Valid: true},
+		Scope:     sql.NullString{String: scope, Valid: true},
+	}
+	if err := cfg.db.AddOAuthRefreshToken(request.Context(), refreshTokenParams); err != nil {
+		handleOAuthError("server_error", err, 500, writer)
+		return
+	}
+
+	response, err := json.Marshal(oauthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(oauthAccessTokenLifetime.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	})
+	if err != nil {
+		handleOAuthError("server_error", err, 500, writer)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) checkAuthorizeRequest(request *http.Request, authRequest authorizeRequest) (database.OauthClient, error) {
+	clientID, err := uuid.Parse(authRequest.ClientID)
+	if err != nil {
+		return database.OauthClient{}, fmt.Errorf("Bad client_id")
+	}
+	client, err := cfg.db.GetOAuthClient(request.Context(), clientID)
+	if err != nil {
+		return database.OauthClient{}, fmt.Errorf("Unknown client")
+	}
+	if !containsField(client.RedirectUris, authRequest.RedirectURI) {
+		return database.OauthClient{}, fmt.Errorf("redirect_uri not registered for client")
+	}
+	if authRequest.ResponseType != "code" {
+		return database.OauthClient{}, fmt.Errorf("Unsupported response_type")
+	}
+	if err := auth.ValidateScopes(authRequest.Scope); err != nil {
+		return database.OauthClient{}, err
+	}
+	for _, scope := range strings.Fields(authRequest.Scope) {
+		if !auth.HasScope(client.Scope, scope) {
+			return database.OauthClient{}, fmt.Errorf("Scope %s not allowed for client", scope)
+		}
+	}
+	if authRequest.CodeChallenge == "" || authRequest.CodeChallengeMethod != "S256" {
+		return database.OauthClient{}, fmt.Errorf("PKCE with S256 is required")
+	}
+	return client, nil
+}
+
+func checkOAuthCodeValid(authCode database.OauthAuthorizationCode, client database.OauthClient, redirectURI string) error {
+	if authCode.ClientID != client.ID {
+		return fmt.Errorf("Code issued to another client")
+	}
+	if authCode.RedirectUri != redirectURI {
+		return fmt.Errorf("redirect_uri does not match")
+	}
+	if time.Now().After(authCode.ExpiresAt) {
+		return fmt.Errorf("Code Expired")
+	}
+	if authCode.UsedAt.Valid {
+		return fmt.Errorf("Code Used")
+	}
+	return nil
+}
+
+func checkRedirectURIs(redirectURIs []string) error {
+	if len(redirectURIs) == 0 {
+		return fmt.Errorf("At least one redirect URI is required")
+	}
+	for _, redirectURI := range redirectURIs {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil {
+			return err
+		}
+		if parsed.Scheme == "" || parsed.Host == "" || parsed.Fragment != "" || strings.ContainsAny(redirectURI, " \t\n") {
+			return fmt.Errorf("Bad redirect URI: %s", redirectURI)
+		}
+	}
+	return nil
+}
+
+func containsField(fields, value string) bool {
+	for _, field := range strings.Fields(fields) {
+		if field == value {
+			return true
+		}
+	}
+	return false
+}
+
+func makeOAuthClientResponse(client database.OauthClient) oauthClientResponse {
+	return oauthClientResponse{
+		ClientID:     client.ID,
+		CreatedAt:    client.CreatedAt,
+		Name:         client.Name,
+		RedirectURIs: strings.Fields(client.RedirectUris),
+		Scope:        client.Scope,
+		Confidential: client.SecretHash.Valid,
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testRedirectURI = "https://client.example.com/callback"
+
+// pkce returns a code verifier and its S256 challenge.
+func pkce() (string, string) {
+	verifier := strings.Repeat("verifier-", 6)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (server *testServer) tokenRequest(client oauthClientResponse, form url.Values, wantStatus int) oauthTokenResponse {
+	server.t.Helper()
+	request, err := http.NewRequest("POST", server.URL+"/api/v1/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth(client.ClientID.String(), client.ClientSecret)
+	tokens := oauthTokenResponse{}
+	if wantStatus != 200 {
+		server.send(request, "", wantStatus, nil)
+		return tokens
+	}
+	server.send(request, "", wantStatus, &tokens)
+	return tokens
+}
+
+func TestOAuthClients(t *testing.T) {
+	server := newTestServer(t)
+	owner := server.signUp("owner@example.com", "")
+
+	server.do("POST", "/api/v1/oauth/clients", owner.Token, oauthClientRequest{Name: "app", RedirectURIs: []string{"not a uri"}, Scope: "chirps:read"}, 400, nil)
+	server.do("POST", "/api/v1/oauth/clients", owner.Token, oauthClientRequest{Name: "app", RedirectURIs: []string{testRedirectURI}, Scope: "everything"}, 400, nil)
+	client := oauthClientResponse{}
+	server.do("POST", "/api/v1/oauth/clients", owner.Token, oauthClientRequest{Name: "app", RedirectURIs: []string{testRedirectURI}, Scope: "chirps:read", Confidential: true}, 201, &client)
+	if client.ClientSecret == "" {
+		t.Fatalf("confidential client = %+v", client)
+	}
+
+	clients := []oauthClientResponse{}
+	server.do("GET", "/api/v1/oauth/clients", owner.Token, nil, 200, &clients)
+	if len(clients) != 1 || clients[0].ClientID != client.ClientID || clients[0].ClientSecret != "" {
+		t.Fatalf("clients = %+v", clients)
+	}
+
+	other := server.signUp("other@example.com", "")
+	server.do("DELETE", "/api/v1/oauth/clients/"+client.ClientID.String(), other.Token, nil, 404, nil)
+	server.do("DELETE", "/api/v1/oauth/clients/"+client.ClientID.String(), owner.Token, nil, 204, nil)
+	server.do("GET", "/api/v1/oauth/clients", owner.Token, nil, 200, &clients)
+	if len(clients) != 0 {
+		t.Fatalf("clients after delete = %+v", clients)
+	}
+}
+
+func TestOAuthAuthorizationCodeFlow(t *testing.T) {
+	server := newTestServer(t)
+	owner := server.signUp("owner@example.com", "")
+	user := server.signUp("user@example.com", "")
+	client := oauthClientResponse{}
+	server.do("POST", "/api/v1/oauth/clients", owner.Token, oauthClientRequest{
+		Name:         "app",
+		RedirectURIs: []string{testRedirectURI},
+		Scope:        "chirps:read chirps:write",
+		Confidential: true,
+	}, 201, &client)
+
+	verifier, challenge := pkce()
+	authRequest := authorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ClientID.String(),
+		RedirectURI:         testRedirectURI,
+		Scope:               "chirps:write",
+		State:               "xyz",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: "S256",
+	}
+	query := url.Values{
+		"response_type":         {authRequest.ResponseType},
+		"client_id":             {authRequest.ClientID},
+		"redirect_uri":          {authRequest.RedirectURI},
+		"scope":                 {authRequest.Scope},
+		"state":                 {authRequest.State},
+		"code_challenge":        {authRequest.CodeChallenge},
+		"code_challenge_method": {authRequest.CodeChallengeMethod},
+	}
+	consent := consentResponse{}
+	server.do("GET", "/api/v1/oauth/authorize?"+query.Encode(), "", nil, 200, &consent)
+	if consent.ClientName != "app" || consent.State != "xyz" {
+		t.Fatalf("consent = %+v", consent)
+	}
+	query.Set("scope", "profile")
+	server.do("GET", "/api/v1/oauth/authorize?"+query.Encode(), "", nil, 400, nil)
+
+	denied := authorizeResponse{}
+	server.do("POST", "/api/v1/oauth/authorize", user.Token, authRequest, 200, &denied)
+	if !strings.Contains(denied.RedirectTo, "error=access_denied") {
+		t.Fatalf("denied redirect = %s", denied.RedirectTo)
+	}
+
+	authRequest.Approve = true
+	approved := authorizeResponse{}
+	server.do("POST", "/api/v1/oauth/authorize", user.Token, authRequest, 200, &approved)
+	redirect, err := url.Parse(approved.RedirectTo)
+	if err != nil || redirect.Query().Get("state") != "xyz" {
+		t.Fatalf("approved redirect = %s", approved.RedirectTo)
+	}
+	code := redirect.Query().Get("code")
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {verifier + "x"},
+	}
+	server.tokenRequest(client, exchange, 400)
+	exchange.Set("code_verifier", verifier)
+	wrongSecret := client
+	wrongSecret.ClientSecret = "wrong"
+	server.tokenRequest(wrongSecret, exchange, 401)
+	tokens := server.tokenRequest(client, exchange, 200)
+	if tokens.Scope != "chirps:write" || tokens.TokenType != "Bearer" {
+		t.Fatalf("tokens = %+v", tokens)
+	}
+	server.tokenRequest(client, exchange, 400)
+
+	// The access token can only do what its scope allows.
+	server.postChirp(tokens.AccessToken, chirp{Body: "posted by an app"})
+	server.do("GET", "/api/v1/users/me", tokens.AccessToken, nil, 403, nil)
+
+	refreshed := server.tokenRequest(client, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {tokens.RefreshToken}}, 200)
+	if refreshed.AccessToken == "" || refreshed.Scope != "chirps:write" {
+		t.Fatalf("refreshed tokens = %+v", refreshed)
+	}
+	server.tokenRequest(client, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {tokens.RefreshToken}}, 400)
+}
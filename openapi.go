@@ -0,0 +1,13 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/FFB6C1/bootdev_webservers/api"
+)
+
+func openAPIHandler(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(200)
+	writer.Write(api.Spec)
+}
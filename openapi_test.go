@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/api"
+	"github.com/google/uuid"
+)
+
+type openAPISpec struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]map[string]any `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	RequestBody *struct {
+		Content map[string]openAPIMedia `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]openAPIMedia `json:"content"`
+	} `json:"responses"`
+}
+
+type openAPIMedia struct {
+	Schema map[string]any `json:"schema"`
+}
+
+func loadOpenAPISpec(t *testing.T) *openAPISpec {
+	t.Helper()
+	spec := &openAPISpec{}
+	if err := json.Unmarshal(api.Spec, spec); err != nil {
+		t.Fatal("Could not parse api/openapi.json:", err)
+	}
+	if spec.OpenAPI != "3.1.0" {
+		t.Fatalf("openapi = %q, want 3.1.0", spec.OpenAPI)
+	}
+	return spec
+}
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	server := newTestServer(t)
+
+	routed := []string{}
+	for _, route := range server.cfg.apiRoutes() {
+		routed = append(routed, route.method+" "+route.path)
+	}
+	documented := []string{}
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routed)
+	sort.Strings(documented)
+	if !slices.Equal(routed, documented) {
+		t.Fatalf("routes and api/openapi.json differ:\nrouted     %v\ndocumented %v", routed, documented)
+	}
+
+	response := server.do("GET", "/api/v1/openapi.json", "", nil, 200, nil)
+	served, _ := io.ReadAll(response.Body)
+	if !bytes.Equal(served, api.Spec) {
+		t.Fatal("/api/v1/openapi.json is not the embedded spec")
+	}
+}
+
+// The documented schemas list exactly the fields the Go types encode.
+// Responses require every field that isn't omitempty, and requests the ones
+// with a required rule.
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	for name, test := range map[string]struct {
+		value    any
+		response bool
+	}{
+		"chirp":           {chirp{}, false},
+		"userRequest":     {userRequest{}, false},
+		"polkaRequest":    {polkaRequest{}, false},
+		"polkaData":       {polkaData{}, false},
+		"chirpResponse":   {chirpResponse{}, true},
+		"newUserResponse": {newUserResponse{}, true},
+		"chirpAuthor":     {chirpAuthor{}, true},
+		"chirpEntities":   {chirpEntities{}, true},
+		"hashtagEntity":   {hashtagEntity{}, true},
+		"mentionEntity":   {mentionEntity{}, true},
+		"urlEntity":       {urlEntity{}, true},
+		"mediaResponse":   {mediaResponse{}, true},
+		"errorResponse":   {errorResponse{}, true},
+		"fieldError":      {fieldError{}, true},
+	} {
+		schema, ok := spec.Components.Schemas[name]
+		if !ok {
+			t.Errorf("no %s schema", name)
+			continue
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		required := map[string]bool{}
+		for _, field := range schema["required"].([]any) {
+			required[field.(string)] = true
+		}
+
+		fields := reflect.TypeOf(test.value)
+		for i := range fields.NumField() {
+			tag, options, _ := strings.Cut(fields.Field(i).Tag.Get("json"), ",")
+			omitempty := strings.Contains(options, "omitempty")
+			if _, ok := properties[tag]; !ok {
+				t.Errorf("%s.%s is not documented", name, tag)
+			}
+			if omitempty && required[tag] {
+				t.Errorf("%s.%s is omitempty but required", name, tag)
+			}
+			if test.response && !omitempty && !required[tag] {
+				t.Errorf("%s.%s is always sent but not required", name, tag)
+			}
+			rules := strings.Split(fields.Field(i).Tag.Get("validate"), ",")
+			if !test.response && slices.Contains(rules, "required") != required[tag] {
+				t.Errorf("%s.%s: the validate rules and the spec disagree on whether it's required", name, tag)
+			}
+			delete(properties, tag)
+		}
+		for tag := range properties {
+			t.Errorf("%s.%s is documented but %T has no such field", name, tag, test.value)
+		}
+	}
+}
+
+// Drives the handlers through sign-up, chirping and the Polka webhook,
+// checking each request, status and response against the spec.
+func TestHandlersMatchOpenAPISpec(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	server := newTestServer(t)
+	exchange := func(method, pattern, path, auth string, body any, wantStatus int, out any) {
+		t.Helper()
+		response := server.do(method, "/api/v1"+path, auth, body, wantStatus, out)
+		responseBody, _ := io.ReadAll(response.Body)
+		if err := spec.check(method, pattern, body, response.StatusCode, responseBody); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+
+	credentials := userRequest{Email: "a@example.com", Password: testPassword}
+	exchange("POST", "/users", "/users", "", credentials, 201, nil)
+	user := newUserResponse{}
+	exchange("POST", "/login", "/login", "", credentials, 200, &user)
+	exchange("POST", "/login", "/login", "", userRequest{Email: "a@example.com", Password: "wrong"}, 401, nil)
+	exchange("GET", "/users/me", "/users/me", user.Token, nil, 200, nil)
+	credentials.Email = "b@example.com"
+	exchange("PUT", "/users", "/users", user.Token, credentials, 200, nil)
+	exchange("POST", "/refresh", "/refresh", user.RefreshToken, nil, 200, nil)
+
+	posted := chirpResponse{}
+	exchange("POST", "/chirps", "/chirps", user.Token, chirp{Body: "hello #chirpy https://example.com"}, 201, &posted)
+	reply := chirp{Body: "replying", ReplyToId: &posted.Id}
+	exchange("POST", "/chirps", "/chirps", user.Token, reply, 201, nil)
+	exchange("POST", "/chirps", "/chirps", user.Token, chirp{Body: strings.Repeat("a", 141)}, 400, nil)
+	exchange("POST", "/chirps", "/chirps", "", chirp{Body: "hi"}, 401, nil)
+	exchange("POST", "/chirps/{chirpID}/rechirp", "/chirps/"+posted.Id.String()+"/rechirp", user.Token, nil, 201, nil)
+	exchange("GET", "/chirps", "/chirps", "", nil, 200, nil)
+	exchange("GET", "/chirps", "/chirps?sort=popular", "", nil, 200, nil)
+	exchange("GET", "/chirps", "/chirps?sort=sideways", "", nil, 400, nil)
+	exchange("GET", "/chirps/{chirpID}", "/chirps/"+posted.Id.String(), "", nil, 200, nil)
+	exchange("GET", "/chirps/{chirpID}", "/chirps/"+uuid.NewString(), "", nil, 404, nil)
+	exchange("DELETE", "/chirps/{chirpID}", "/chirps/"+posted.Id.String(), user.Token, nil, 204, nil)
+
+	upgrade := polkaRequest{Event: "user.upgraded", Data: polkaData{UserId: user.Id.String()}}
+	exchange("POST", "/polka/webhooks", "/polka/webhooks", "ApiKey "+testPolkaKey, upgrade, 204, nil)
+	exchange("POST", "/polka/webhooks", "/polka/webhooks", "ApiKey wrong", upgrade, 401, nil)
+	exchange("POST", "/revoke", "/revoke", user.RefreshToken, nil, 204, nil)
+}
+
+func TestDeprecatedAliases(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	current := server.do("GET", "/api/v1/chirps", "", nil, 200, nil)
+	if current.Header.Get("Deprecation") != "" {
+		t.Fatal("/api/v1 is marked deprecated")
+	}
+
+	checkDeprecated := func(response *http.Response, successor string) {
+		t.Helper()
+		if response.Header.Get("Deprecation") != fmt.Sprintf("@%d", legacyAPIDeprecated.Unix()) {
+			t.Fatalf("Deprecation = %q", response.Header.Get("Deprecation"))
+		}
+		if sunset, err := http.ParseTime(response.Header.Get("Sunset")); err != nil || !sunset.Equal(legacyAPISunset) {
+			t.Fatalf("Sunset = %q", response.Header.Get("Sunset"))
+		}
+		if link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor); response.Header.Get("Link") != link {
+			t.Fatalf("Link = %q, want %q", response.Header.Get("Link"), link)
+		}
+	}
+	checkDeprecated(server.do("GET", "/api/chirps", "", nil, 200, nil), "/api/v1/chirps")
+	posted := chirpResponse{}
+	checkDeprecated(server.do("POST", "/api/chirps", user.Token, chirp{Body: "from an old client"}, 201, &posted), "/api/v1/chirps")
+	chirpPath := "/chirps/" + posted.Id.String()
+	checkDeprecated(server.do("GET", "/api"+chirpPath, "", nil, 200, nil), "/api/v1"+chirpPath)
+	server.do("GET", "/api/openapi.json", "", nil, 404, nil)
+}
+
+// Checks a request body, status and response body against the operation.
+func (spec *openAPISpec) check(method, pattern string, body any, status int, responseBody []byte) error {
+	operation, ok := spec.Paths[pattern][strings.ToLower(method)]
+	if !ok {
+		return fmt.Errorf("%s %s is not documented", method, pattern)
+	}
+	if body != nil {
+		if operation.RequestBody == nil {
+			return fmt.Errorf("sent a body but none is documented")
+		}
+		if err := spec.validateJSON(operation.RequestBody.Content["application/json"].Schema, body, "request"); err != nil {
+			return err
+		}
+	}
+	response, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("status %d is not documented", status)
+	}
+	if media, ok := response.Content["application/json"]; ok {
+		var decoded any
+		if err := json.Unmarshal(responseBody, &decoded); err != nil {
+			return fmt.Errorf("response is not JSON: %w", err)
+		}
+		return spec.validate(media.Schema, decoded, "response")
+	}
+	return nil
+}
+
+func (spec *openAPISpec) validateJSON(schema map[string]any, value any, at string) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var decoded any
+	json.Unmarshal(encoded, &decoded)
+	return spec.validate(schema, decoded, at)
+}
+
+// Just enough JSON Schema for api/openapi.json: $ref, oneOf, const, type,
+// format, properties, required, additionalProperties and items.
+func (spec *openAPISpec) validate(schema map[string]any, value any, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		return spec.validate(spec.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")], value, at)
+	}
+	if options, ok := schema["oneOf"].([]any); ok {
+		errs := []string{}
+		for _, option := range options {
+			err := spec.validate(option.(map[string]any), value, at)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s matches none of oneOf: %s", at, strings.Join(errs, "; "))
+	}
+	if constant, ok := schema["const"]; ok && value != constant {
+		return fmt.Errorf("%s = %v, want %v", at, value, constant)
+	}
+
+	if types, ok := schema["type"]; ok {
+		allowed := []any{types}
+		if list, ok := types.([]any); ok {
+			allowed = list
+		}
+		if !slices.ContainsFunc(allowed, func(allowed any) bool { return jsonTypeMatches(allowed.(string), value) }) {
+			return fmt.Errorf("%s = %v, want type %v", at, value, types)
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		switch schema["format"] {
+		case "uuid":
+			if _, err := uuid.Parse(value); err != nil {
+				return fmt.Errorf("%s = %q, want a UUID", at, value)
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				return fmt.Errorf("%s = %q, want a date-time", at, value)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				if err := spec.validate(items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for _, field := range asSlice(schema["required"]) {
+			if _, ok := value[field.(string)]; !ok {
+				return fmt.Errorf("%s has no %s", at, field)
+			}
+		}
+		for field, fieldValue := range value {
+			property, ok := properties[field].(map[string]any)
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s.%s is not documented", at, field)
+				}
+				continue
+			}
+			if err := spec.validate(property, fieldValue, at+"."+field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func jsonTypeMatches(want string, value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return want == "null"
+	case bool:
+		return want == "boolean"
+	case float64:
+		return want == "number" || want == "integer" && value == float64(int64(value))
+	case string:
+		return want == "string"
+	case []any:
+		return want == "array"
+	case map[string]any:
+		return want == "object"
+	}
+	return false
+}
+
+func asSlice(value any) []any {
+	slice, _ := value.([]any)
+	return slice
+}
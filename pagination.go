@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultPageSize = 20
+const maxPageSize = 100
+
+func getPageSize(request *http.Request) (int32, error) {
+	limit := request.URL.Query().Get("limit")
+	if limit == "" {
+		return defaultPageSize, nil
+	}
+	pageSize, err := strconv.Atoi(limit)
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	return int32(pageSize), nil
+}
+
+// Cursors are opaque to clients: the created_at and id of the last item seen.
+// An empty cursor starts from the newest item.
+func getCursor(request *http.Request) (time.Time, uuid.UUID, error) {
+	cursor := request.URL.Query().Get("cursor")
+	if cursor == "" {
+		return time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), uuid.Max, nil
+	}
+	return parseCursor(cursor)
+}
+
+// As getCursor, but for lists in oldest-first order.
+func getAfterCursor(request *http.Request) (time.Time, uuid.UUID, error) {
+	cursor := request.URL.Query().Get("cursor")
+	if cursor == "" {
+		return time.Time{}, uuid.Nil, nil
+	}
+	return parseCursor(cursor)
+}
+
+func parseCursor(cursor string) (time.Time, uuid.UUID, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("Bad cursor")
+	}
+	createdAtString, idString, ok := strings.Cut(string(decoded), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, fmt.Errorf("Bad cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("Bad cursor")
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("Bad cursor")
+	}
+	return createdAt, id, nil
+}
+
+func makeCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+// Ranked results have no stable key to resume from, so their cursor is the
+// number of results already returned.
+func getOffsetCursor(request *http.Request) (int32, error) {
+	cursor := request.URL.Query().Get("cursor")
+	if cursor == "" {
+		return 0, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("Bad cursor")
+	}
+	offset, err := strconv.Atoi(string(decoded))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("Bad cursor")
+	}
+	return int32(offset), nil
+}
+
+func makeOffsetCursor(offset int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(int(offset))))
+}
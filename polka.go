@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/FFB6C1/bootdev_webservers/internal/auth"
@@ -13,18 +12,11 @@ type polkaData struct {
 }
 
 type polkaRequest struct {
-	Event string    `json:"event"`
+	Event string    `json:"event" validate:"required"`
 	Data  polkaData `json:"data"`
 }
 
 func (cfg *apiConfig) recievePolkaEvent(writer http.ResponseWriter, request *http.Request) {
-	polkaRequest := polkaRequest{}
-	decoder := json.NewDecoder(request.Body)
-	if err := decoder.Decode(&polkaRequest); err != nil {
-		handleError("Could not decode header", err, 400, writer)
-		return
-	}
-
 	apiKey, err := auth.GetAPIKey(request.Header)
 	if err != nil {
 		handleError("Could not get auth key", err, 401, writer)
@@ -33,6 +25,12 @@ func (cfg *apiConfig) recievePolkaEvent(writer http.ResponseWriter, request *htt
 
 	if apiKey != cfg.polkaKey {
 		handleError("Unauthorized", nil, 401, writer)
+		return
+	}
+
+	polkaRequest := polkaRequest{}
+	if !decodeWebhook(writer, request, &polkaRequest) {
+		return
 	}
 
 	if polkaRequest.Event != "user.upgraded" {
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxDisplayNameLength = 50
+const maxBioLength = 160
+const maxAvatarURLLength = 2048
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,20}$`)
+
+type profileRequest struct {
+	Handle      string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+type profileResponse struct {
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	CreatedAt      time.Time `json:"created_at"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+type chirpAuthor struct {
+	Id          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+}
+
+func (cfg *apiConfig) updateProfileHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	profile := profileRequest{}
+	if !decodeRequest(writer, request, &profile) {
+		return
+	}
+
+	handle, err := normaliseHandle(profile.Handle)
+	if err != nil {
+		handleError("Bad handle", err, 400, writer)
+		return
+	}
+	if err := checkProfile(profile); err != nil {
+		handleError("Bad profile", err, 400, writer)
+		return
+	}
+
+	profileParams := database.UpdateUserProfileParams{
+		ID:          userID,
+		Handle:      sql.NullString{String: handle, Valid: true},
+		DisplayName: strings.TrimSpace(profile.DisplayName),
+		Bio:         strings.TrimSpace(profile.Bio),
+		AvatarUrl:   profile.AvatarURL,
+	}
+	user, err := cfg.db.UpdateUserProfile(request.Context(), profileParams)
+	if err != nil {
+		if isUniqueViolation(err) {
+			handleError("Handle already taken", nil, 409, writer)
+			return
+		}
+		handleError("Could not update profile", err, 500, writer)
+		return
+	}
+
+	response, err := json.Marshal(makeProfileResponse(user))
+	if err != nil {
+		handleError("Could not make response - profile updated", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) getProfileHandler(writer http.ResponseWriter, request *http.Request) {
+	user, err := cfg.getUserFromHandle(request)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	profile := makeProfileResponse(user)
+	profile.FollowerCount, err = cfg.db.CountFollowers(request.Context(), user.ID)
+	if err != nil {
+		handleError("Could not count followers", err, 500, writer)
+		return
+	}
+	profile.FollowingCount, err = cfg.db.CountFollowing(request.Context(), user.ID)
+	if err != nil {
+		handleError("Could not count following", err, 500, writer)
+		return
+	}
+
+	response, err := json.Marshal(profile)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) getProfileChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	user, err := cfg.getUserFromHandle(request)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsByUser(request.Context(), user.ID)
+	if err != nil {
+		handleError("Failed to get chirps", err, 500, writer)
+		return
+	}
+
+	cfg.writeChirps(chirps, writer, request)
+}
+
+func (cfg *apiConfig) getUserFromHandle(request *http.Request) (database.User, error) {
+	handle, err := normaliseHandle(request.PathValue("handle"))
+	if err != nil {
+		return database.User{}, err
+	}
+	return cfg.db.GetUserByHandle(request.Context(), sql.NullString{String: handle, Valid: true})
+}
+
+func (cfg *apiConfig) getChirpAuthors(ctx context.Context, chirps []database.Chirp) (map[uuid.UUID]chirpAuthor, error) {
+	seen := map[uuid.UUID]bool{}
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if !seen[chirp.UserID] {
+			seen[chirp.UserID] = true
+			ids = append(ids, chirp.UserID)
+		}
+	}
+
+	users, err := cfg.db.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := map[uuid.UUID]chirpAuthor{}
+	for _, user := range users {
+		authors[user.ID] = chirpAuthor{
+			Id:          user.ID,
+			Handle:      user.Handle.String,
+			DisplayName: user.DisplayName,
+			AvatarURL:   user.AvatarUrl,
+		}
+	}
+	return authors, nil
+}
+
+func normaliseHandle(handle string) (string, error) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	if !handlePattern.MatchString(handle) {
+		return "", fmt.Errorf("Handles are 3-20 letters, digits or underscores")
+	}
+	if _, ok := getReservedHandles()[handle]; ok {
+		return "", fmt.Errorf("Handle %s is reserved", handle)
+	}
+	return handle, nil
+}
+
+func checkProfile(profile profileRequest) error {
+	if utf8.RuneCountInString(strings.TrimSpace(profile.DisplayName)) > maxDisplayNameLength {
+		return fmt.Errorf("Display name too long")
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(profile.Bio)) > maxBioLength {
+		return fmt.Errorf("Bio too long")
+	}
+	if profile.AvatarURL == "" {
+		return nil
+	}
+	if len(profile.AvatarURL) > maxAvatarURLLength {
+		return fmt.Errorf("Avatar URL too long")
+	}
+	avatarURL, err := url.Parse(profile.AvatarURL)
+	if err != nil {
+		return err
+	}
+	if (avatarURL.Scheme != "https" && avatarURL.Scheme != "http") || avatarURL.Host == "" {
+		return fmt.Errorf("Avatar URL must be an http(s) URL")
+	}
+	return nil
+}
+
+func makeProfileResponse(user database.User) profileResponse {
+	return profileResponse{
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarUrl,
+		CreatedAt:   user.CreatedAt,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+}
+
+func getReservedHandles() map[string]int {
+	return map[string]int{
+		"admin":    0,
+		"api":      0,
+		"app":      0,
+		"chirpy":   0,
+		"export":   0,
+		"help":     0,
+		"login":    0,
+		"me":       0,
+		"root":     0,
+		"settings": 0,
+		"support":  0,
+		"system":   0,
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestProfiles(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	server.signUp("b@example.com", "taken")
+
+	server.do("PUT", "/api/v1/users/profile", user.Token, profileRequest{Handle: "x"}, 400, nil)
+	server.do("PUT", "/api/v1/users/profile", user.Token, profileRequest{Handle: "taken"}, 409, nil)
+	updated := profileResponse{}
+	server.do("PUT", "/api/v1/users/profile", user.Token, profileRequest{Handle: "@Alice", DisplayName: "Alice", Bio: "hi"}, 200, &updated)
+	if updated.Handle != "alice" || updated.DisplayName != "Alice" {
+		t.Fatalf("updated profile = %+v", updated)
+	}
+
+	profile := profileResponse{}
+	server.do("GET", "/api/v1/users/alice", "", nil, 200, &profile)
+	if profile.Bio != "hi" || profile.FollowerCount != 0 {
+		t.Fatalf("profile = %+v", profile)
+	}
+	server.do("GET", "/api/v1/users/nobody", "", nil, 404, nil)
+
+	posted := server.postChirp(user.Token, chirp{Body: "on my profile"})
+	chirps := []chirpResponse{}
+	server.do("GET", "/api/v1/users/alice/chirps", "", nil, 200, &chirps)
+	if !slices.Equal(chirpIDs(chirps), []uuid.UUID{posted.Id}) {
+		t.Fatalf("profile chirps = %v", chirpIDs(chirps))
+	}
+}
+
+func TestFollows(t *testing.T) {
+	server := newTestServer(t)
+	alice := server.signUp("alice@example.com", "alice")
+	bob := server.signUp("bob@example.com", "bob")
+	carol := server.signUp("carol@example.com", "carol")
+
+	server.do("POST", "/api/v1/users/alice/follow", alice.Token, nil, 400, nil)
+	server.do("POST", "/api/v1/users/nobody/follow", alice.Token, nil, 404, nil)
+	server.do("POST", "/api/v1/users/alice/follow", bob.Token, nil, 204, nil)
+	server.do("POST", "/api/v1/users/alice/follow", carol.Token, nil, 204, nil)
+
+	followersPage := func(cursor string) followListResponse {
+		t.Helper()
+		page := followListResponse{}
+		server.do("GET", "/api/v1/users/alice/followers?limit=1&cursor="+cursor, "", nil, 200, &page)
+		return page
+	}
+	followers := followersPage("")
+	if followers.Count != 2 || len(followers.Users) != 1 || followers.Users[0].Handle != "carol" {
+		t.Fatalf("first page of followers = %+v", followers)
+	}
+	followers = followersPage(followers.NextCursor)
+	if len(followers.Users) != 1 || followers.Users[0].Handle != "bob" {
+		t.Fatalf("second page of followers = %+v", followers)
+	}
+	followers = followersPage(followers.NextCursor)
+	if len(followers.Users) != 0 || followers.NextCursor != "" {
+		t.Fatalf("last page of followers = %+v", followers)
+	}
+	server.do("GET", "/api/v1/users/alice/followers?limit=0", "", nil, 400, nil)
+
+	following := followListResponse{}
+	server.do("GET", "/api/v1/users/bob/following", "", nil, 200, &following)
+	if following.Count != 1 || following.Users[0].Handle != "alice" {
+		t.Fatalf("bob follows %+v", following)
+	}
+
+	profile := profileResponse{}
+	server.do("GET", "/api/v1/users/alice", "", nil, 200, &profile)
+	if profile.FollowerCount != 2 {
+		t.Fatalf("alice has %d followers", profile.FollowerCount)
+	}
+
+	server.do("DELETE", "/api/v1/users/alice/follow", bob.Token, nil, 204, nil)
+	server.do("DELETE", "/api/v1/users/alice/follow", bob.Token, nil, 404, nil)
+}
+
+// Follows made in one transaction share created_at in Postgres and the
+// memory store, and paging mustn't skip or repeat any of them.
+func TestFollowListsPageThroughTies(t *testing.T) {
+	server := newTestServer(t)
+	server.signUp("alice@example.com", "alice")
+	followers := []newUserResponse{}
+	for _, handle := range []string{"bob", "carol", "dave"} {
+		followers = append(followers, server.signUp(handle+"@example.com", handle))
+	}
+	alice, err := server.cfg.db.GetUserByHandle(context.Background(), sql.NullString{String: "alice", Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = server.cfg.store.WithTx(context.Background(), func(ctx context.Context, queries database.Querier) error {
+		for _, follower := range followers {
+			if _, err := queries.FollowUser(ctx, database.FollowUserParams{FollowerID: follower.Id, FolloweeID: alice.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Could not follow:", err)
+	}
+
+	seen := []string{}
+	cursor := ""
+	for range len(followers) + 1 {
+		page := followListResponse{}
+		server.do("GET", "/api/v1/users/alice/followers?limit=1&cursor="+cursor, "", nil, 200, &page)
+		for _, user := range page.Users {
+			seen = append(seen, user.Handle)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	slices.Sort(seen)
+	if !slices.Equal(seen, []string{"bob", "carol", "dave"}) {
+		t.Fatalf("paged through followers %v", seen)
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	for _, strategy := range []string{timelineJoin, timelineFanOut} {
+		t.Run(strategy, func(t *testing.T) {
+			server := newTestServer(t)
+			server.cfg.timelineStrategy = strategy
+			reader := server.signUp("reader@example.com", "reader")
+			author := server.signUp("author@example.com", "author")
+			stranger := server.signUp("stranger@example.com", "stranger")
+
+			before := server.postChirp(author.Token, chirp{Body: "before the follow"})
+			server.do("POST", "/api/v1/users/author/follow", reader.Token, nil, 204, nil)
+			server.postChirp(stranger.Token, chirp{Body: "not followed"})
+			after := server.postChirp(author.Token, chirp{Body: "after the follow"})
+			server.postChirp(reader.Token, chirp{Body: "my own"})
+
+			timelinePage := func(cursor string) timelineResponse {
+				t.Helper()
+				page := timelineResponse{}
+				server.do("GET", "/api/v1/timeline?limit=1&cursor="+cursor, reader.Token, nil, 200, &page)
+				return page
+			}
+			timeline := timelinePage("")
+			if !slices.Equal(chirpIDs(timeline.Chirps), []uuid.UUID{after.Id}) {
+				t.Fatalf("first page = %v", chirpIDs(timeline.Chirps))
+			}
+			timeline = timelinePage(timeline.NextCursor)
+			if !slices.Equal(chirpIDs(timeline.Chirps), []uuid.UUID{before.Id}) {
+				t.Fatalf("second page = %v", chirpIDs(timeline.Chirps))
+			}
+
+			server.do("DELETE", "/api/v1/users/author/follow", reader.Token, nil, 204, nil)
+			timeline = timelinePage("")
+			if len(timeline.Chirps) != 0 {
+				t.Fatalf("timeline after unfollow = %v", chirpIDs(timeline.Chirps))
+			}
+			server.do("GET", "/api/v1/timeline", "", nil, 401, nil)
+		})
+	}
+}
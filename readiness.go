@@ -1,9 +1,111 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
 
+	"github.com/FFB6C1/bootdev_webservers/internal/migrate"
+)
+
+const readinessTimeout = 2 * time.Second
+
+const (
+	checkOK          = "ok"
+	checkUnavailable = "unavailable"
+)
+
+type readinessResponse struct {
+	Status string                    `json:"status"`
+	Checks map[string]readinessCheck `json:"checks"`
+}
+
+type readinessCheck struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Version   *int64 `json:"version,omitempty"`
+	Expected  *int64 `json:"expected,omitempty"`
+}
+
+// Liveness: the process is up and serving. Deliberately checks nothing else
+// so a database outage doesn't get the server restarted.
 func readinessHandler(writer http.ResponseWriter, _ *http.Request) {
 	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	writer.WriteHeader(200)
 	writer.Write([]byte("OK"))
 }
+
+// Readiness: whether this instance should get traffic right now.
+func (cfg *apiConfig) readyzHandler(writer http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithTimeout(request.Context(), readinessTimeout)
+	defer cancel()
+
+	response := readinessResponse{
+		Status: checkOK,
+		Checks: map[string]readinessCheck{},
+	}
+
+	// The in-memory store has no connection to ping or schema to check.
+	if cfg.dbConn != nil {
+		cfg.checkDatabase(ctx, response.Checks)
+	}
+
+	// Load balancers should stop sending requests as soon as shutdown starts.
+	select {
+	case <-cfg.shuttingDown:
+		response.Checks["server"] = readinessCheck{Status: checkUnavailable, Error: "shutting down"}
+	default:
+		response.Checks["server"] = readinessCheck{Status: checkOK}
+	}
+
+	code := 200
+	for _, check := range response.Checks {
+		if check.Status != checkOK {
+			response.Status = checkUnavailable
+			code = 503
+		}
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(code)
+	writer.Write(body)
+}
+
+// Anyone can ask, so driver errors, which can name hosts and ports, are only
+// logged.
+func (cfg *apiConfig) checkDatabase(ctx context.Context, checks map[string]readinessCheck) {
+	started := time.Now()
+	if err := cfg.dbConn.PingContext(ctx); err != nil {
+		log.Printf("Could not ping database: %s", err)
+		checks["database"] = readinessCheck{Status: checkUnavailable}
+	} else {
+		checks["database"] = readinessCheck{Status: checkOK, LatencyMs: time.Since(started).Milliseconds()}
+	}
+
+	expected := cfg.backend.schemaVersion
+	schemaCheck := readinessCheck{Status: checkOK, Expected: &expected}
+	migrator, err := migrate.New(cfg.dbConn, cfg.backend.dialect, cfg.backend.migrations)
+	if err == nil {
+		var version int64
+		if version, err = migrator.Version(ctx); err == nil {
+			schemaCheck.Version = &version
+			if version != expected {
+				schemaCheck.Status = checkUnavailable
+				schemaCheck.Error = "schema version does not match this build"
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("Could not check schema version: %s", err)
+		schemaCheck.Status = checkUnavailable
+	}
+	checks["schema"] = schemaCheck
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	server := newTestServer(t)
+	server.do("GET", "/api/v1/healthz", "", nil, 200, nil)
+
+	ready := readinessResponse{}
+	server.do("GET", "/api/v1/readyz", "", nil, 200, &ready)
+	if ready.Status != checkOK {
+		t.Fatalf("readyz = %+v", ready)
+	}
+	_, checked := ready.Checks["database"]
+	if checked != (server.cfg.dbConn != nil) {
+		t.Fatalf("readyz checks = %+v", ready.Checks)
+	}
+
+	if server.cfg.dbConn != nil {
+		server.cfg.dbConn.Close()
+		response := server.do("GET", "/api/v1/readyz", "", nil, 503, &ready)
+		body, _ := io.ReadAll(response.Body)
+		if ready.Checks["database"].Status != checkUnavailable || strings.Contains(string(body), "sql:") {
+			t.Fatalf("readyz with the database closed = %s", body)
+		}
+	}
+}
+
+func TestFrontendMetricsAndReset(t *testing.T) {
+	server := newTestServer(t)
+	for range 2 {
+		response := server.do("GET", "/app/", "", nil, 200, nil)
+		if !strings.HasPrefix(response.Header.Get("Content-Type"), "text/html") {
+			t.Fatalf("frontend Content-Type = %q", response.Header.Get("Content-Type"))
+		}
+	}
+	metrics, _ := io.ReadAll(server.do("GET", "/admin/metrics", "", nil, 200, nil).Body)
+	if !strings.Contains(string(metrics), "visited 2 times") {
+		t.Fatalf("metrics = %s", metrics)
+	}
+
+	user := server.signUp("a@example.com", "")
+	server.do("POST", "/admin/reset", "", nil, 200, nil)
+	server.do("GET", "/api/v1/users/me", user.Token, nil, 401, nil)
+	metrics, _ = io.ReadAll(server.do("GET", "/admin/metrics", "", nil, 200, nil).Body)
+	if !strings.Contains(string(metrics), "visited 0 times") {
+		t.Fatalf("metrics after reset = %s", metrics)
+	}
+
+	server.cfg.platform = "prod"
+	server.do("POST", "/admin/reset", "", nil, 403, nil)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) rechirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, auth.ScopeChirpsWrite)
+	if err != nil {
+		handleError("Unauthorized", err, code, writer)
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		handleError("Could not parse chirp ID", err, 400, writer)
+		return
+	}
+
+	original, err := cfg.getShareableChirp(request.Context(), chirpID)
+	if err != nil {
+		handleError("Could not find chirp", err, 404, writer)
+		return
+	}
+
+	rechirp, err := cfg.db.CreateRechirp(request.Context(), database.CreateRechirpParams{
+		UserID:      userID,
+		RechirpOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		handleError("Already rechirped", nil, 409, writer)
+		return
+	}
+	if err != nil {
+		handleError("Could not rechirp", err, 500, writer)
+		return
+	}
+
+	if err := cfg.fanOutChirp(request.Context(), rechirp); err != nil {
+		log.Printf("Error fanning out rechirp: %s", err)
+	}
+
+	responses, err := cfg.makeChirpResponses([]database.Chirp{rechirp}, request)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	cfg.publishChirp(request.Context(), responses[0])
+
+	response, err := json.Marshal(responses[0])
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(201)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) unrechirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, auth.ScopeChirpsWrite)
+	if err != nil {
+		handleError("Unauthorized", err, code, writer)
+		return
+	}
+
+	chirpID, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		handleError("Could not parse chirp ID", err, 400, writer)
+		return
+	}
+
+	removed, err := cfg.db.DeleteRechirp(request.Context(), database.DeleteRechirpParams{
+		UserID:      userID,
+		RechirpOfID: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		handleError("Could not remove rechirp", err, 500, writer)
+		return
+	}
+	if removed == 0 {
+		handleError("Not rechirped", nil, 404, writer)
+		return
+	}
+
+	writer.WriteHeader(204)
+}
+
+// Sharing a rechirp shares the chirp it points at, so references never chain.
+func (cfg *apiConfig) getShareableChirp(ctx context.Context, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.db.GetChirpById(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.RechirpOfID.Valid {
+		chirp, err = cfg.db.GetChirpById(ctx, chirp.RechirpOfID.UUID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if chirp.DeletedAt.Valid {
+		return database.Chirp{}, fmt.Errorf("Chirp deleted")
+	}
+	return chirp, nil
+}
+
+func (cfg *apiConfig) getReferencedChirps(ctx context.Context, chirps []database.Chirp) ([]database.Chirp, error) {
+	seen := map[uuid.UUID]bool{}
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		for _, ref := range []uuid.NullUUID{chirp.RechirpOfID, chirp.QuoteOfID} {
+			if ref.Valid && !seen[ref.UUID] {
+				seen[ref.UUID] = true
+				ids = append(ids, ref.UUID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return cfg.db.GetChirpsByIDs(ctx, ids)
+}
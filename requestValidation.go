@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The largest JSON body a handler will read. Media uploads are multipart and
+// have their own limit.
+const maxJSONBodySize = 1 << 20
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Every error response. Fields is only set when a request body was rejected.
+type errorResponse struct {
+	Error  string       `json:"error"`
+	Fields []fieldError `json:"fields,omitempty"`
+}
+
+// Why a request body was rejected, with the status to respond with.
+type requestError struct {
+	status  int
+	message string
+	fields  []fieldError
+}
+
+func (err *requestError) Error() string {
+	return err.message
+}
+
+func badField(field, message string) *requestError {
+	return &requestError{
+		status:  400,
+		message: "Invalid request body",
+		fields:  []fieldError{{Field: field, Message: message}},
+	}
+}
+
+// Decodes a JSON request body into dst and checks it against the rules in
+// dst's validate tags. If anything is wrong it responds with 400, 413 or 415
+// and returns false, and the handler should just return.
+func decodeRequest(writer http.ResponseWriter, request *http.Request, dst any) bool {
+	return respondToDecode(writer, decodeJSONBody(writer, request, dst, true))
+}
+
+// As decodeRequest, for webhooks from services we don't control. Whatever
+// Content-Type they send is accepted and fields we don't know are ignored.
+func decodeWebhook(writer http.ResponseWriter, request *http.Request, dst any) bool {
+	return respondToDecode(writer, decodeJSONBody(writer, request, dst, false))
+}
+
+func respondToDecode(writer http.ResponseWriter, err error) bool {
+	if err == nil {
+		return true
+	}
+	var requestErr *requestError
+	if !errors.As(err, &requestErr) {
+		requestErr = &requestError{status: 400, message: "Could not read request body: " + err.Error()}
+	}
+	writeErrorResponse(writer, requestErr.status, errorResponse{Error: requestErr.message, Fields: requestErr.fields})
+	return false
+}
+
+func decodeJSONBody(writer http.ResponseWriter, request *http.Request, dst any, strict bool) error {
+	if strict {
+		mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return &requestError{status: 415, message: "Content-Type must be application/json"}
+		}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxJSONBodySize))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		if err == nil {
+			return &requestError{status: 400, message: "Request body must be a single JSON value"}
+		}
+		return decodeError(err)
+	}
+
+	if fields := validate(reflect.ValueOf(dst), ""); len(fields) > 0 {
+		return &requestError{status: 400, message: "Invalid request body", fields: fields}
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var sizeErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &requestError{status: 400, message: "Request body is empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &requestError{status: 400, message: "Request body is not valid JSON: unexpected end"}
+	case errors.As(err, &syntaxErr):
+		return &requestError{status: 400, message: fmt.Sprintf("Request body is not valid JSON at byte %d: %s", syntaxErr.Offset, syntaxErr)}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return badField(typeErr.Field, "must be "+jsonTypeName(typeErr.Type))
+	case errors.As(err, &typeErr):
+		return &requestError{status: 400, message: "Request body must be " + jsonTypeName(typeErr.Type)}
+	case errors.As(err, &sizeErr):
+		return &requestError{status: 413, message: fmt.Sprintf("Request body must be at most %d bytes", sizeErr.Limit)}
+	}
+	// DisallowUnknownFields has no error type of its own.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		name, _ := strconv.Unquote(field)
+		return badField(name, "is not a known field")
+	}
+	return err
+}
+
+func jsonTypeName(goType reflect.Type) string {
+	switch goType.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "an array"
+	case reflect.Pointer:
+		return jsonTypeName(goType.Elem())
+	}
+	return "an object"
+}
+
+// Checks the rules in validate tags, comma separated:
+//
+//	required  must be set; strings need more than spaces
+//	max=N     at most N characters, or N items
+//	min=N     at least N
+//	email     an email address
+//
+// Rules other than required pass empty values. Nested structs are checked
+// too, their fields named parent.child.
+func validate(value reflect.Value, prefix string) []fieldError {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := []fieldError{}
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		name = prefix + name
+
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "" {
+				continue
+			}
+			if message := checkRule(rule, value.Field(i)); message != "" {
+				fields = append(fields, fieldError{Field: name, Message: message})
+				break
+			}
+		}
+		if field.Type.Kind() == reflect.Struct {
+			fields = append(fields, validate(value.Field(i), name+".")...)
+		}
+	}
+	return fields
+}
+
+func checkRule(rule string, value reflect.Value) string {
+	rule, arg, _ := strings.Cut(rule, "=")
+	if rule == "required" {
+		if value.IsZero() || value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "" {
+			return "is required"
+		}
+		return ""
+	}
+	if value.IsZero() {
+		return ""
+	}
+
+	switch rule {
+	case "max", "min":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			panic(fmt.Sprintf("validate rule %s=%s needs a number", rule, arg))
+		}
+		size, unit := 0, ""
+		switch value.Kind() {
+		case reflect.String:
+			size, unit = utf8.RuneCountInString(value.String()), " characters"
+		case reflect.Slice, reflect.Map:
+			size, unit = value.Len(), " items"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			size = int(value.Int())
+		default:
+			panic(fmt.Sprintf("validate rule %s doesn't apply to %s", rule, value.Type()))
+		}
+		if rule == "max" && size > limit {
+			return fmt.Sprintf("must be at most %d%s", limit, unit)
+		}
+		if rule == "min" && size < limit {
+			return fmt.Sprintf("must be at least %d%s", limit, unit)
+		}
+	case "email":
+		if address, err := mail.ParseAddress(value.String()); err != nil || address.Address != value.String() {
+			return "must be an email address"
+		}
+	default:
+		panic(fmt.Sprintf("unknown validate rule %q", rule))
+	}
+	return ""
+}
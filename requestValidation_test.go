@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sendRaw posts body as it is, with contentType unless it's empty.
+func (server *testServer) sendRaw(path, auth, contentType, body string, wantStatus int) errorResponse {
+	server.t.Helper()
+	request, err := http.NewRequest("POST", server.URL+path, strings.NewReader(body))
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	response := errorResponse{}
+	server.send(request, auth, wantStatus, &response)
+	return response
+}
+
+func TestRequestBodyDecoding(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+
+	for _, test := range []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantField   string
+	}{
+		{"malformed", "application/json", `{"body": "hi"`, 400, ""},
+		{"empty", "application/json", ``, 400, ""},
+		{"two values", "application/json", `{"body": "hi"} {}`, 400, ""},
+		{"unknown field", "application/json", `{"body": "hi", "colour": "red"}`, 400, "colour"},
+		{"wrong type", "application/json", `{"body": 12}`, 400, "body"},
+		{"missing", "application/json", `{"body": "  "}`, 400, "body"},
+		{"too long", "application/json", `{"body": "` + strings.Repeat("a", 141) + `"}`, 400, "body"},
+		{"no content type", "", `{"body": "hi"}`, 415, ""},
+		{"form", "application/x-www-form-urlencoded", `body=hi`, 415, ""},
+		{"huge", "application/json", `{"body": "` + strings.Repeat("a", maxJSONBodySize) + `"}`, 413, ""},
+		{"charset", "application/json; charset=utf-8", `{"body": "hi"}`, 201, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			server := &testServer{Server: server.Server, t: t, cfg: server.cfg}
+			response := server.sendRaw("/api/v1/chirps", user.Token, test.contentType, test.body, test.wantStatus)
+			if test.wantStatus != 201 && response.Error == "" {
+				t.Fatal("no error message")
+			}
+			fields := []string{}
+			for _, field := range response.Fields {
+				fields = append(fields, field.Field)
+			}
+			if test.wantField != "" && !slices.Equal(fields, []string{test.wantField}) {
+				t.Fatalf("fields = %+v, want %s", response.Fields, test.wantField)
+			}
+		})
+	}
+}
+
+func TestRequestBodyValidation(t *testing.T) {
+	server := newTestServer(t)
+
+	response := server.sendRaw("/api/v1/users", "", "application/json", `{"email": "not an email"}`, 400)
+	if len(response.Fields) != 2 || response.Fields[0] != (fieldError{Field: "email", Message: "must be an email address"}) ||
+		response.Fields[1] != (fieldError{Field: "password", Message: "is required"}) {
+		t.Fatalf("fields = %+v", response.Fields)
+	}
+
+	response = server.sendRaw("/api/v1/polka/webhooks", "ApiKey "+testPolkaKey, "application/json", `{"event": "user.upgraded", "data": {"user_id": 7}}`, 400)
+	if len(response.Fields) != 1 || response.Fields[0].Field != "data.user_id" {
+		t.Fatalf("fields = %+v", response.Fields)
+	}
+	response = server.sendRaw("/api/v1/polka/webhooks", "ApiKey wrong", "application/json", `{"data": {"user_id": 7}}`, 401)
+	if len(response.Fields) != 0 {
+		t.Fatalf("fields sent without the key = %+v", response.Fields)
+	}
+
+	// Polka may send fields we don't know about, with any Content-Type.
+	user := server.signUp("a@example.com", "")
+	event := `{"id": "evt_1", "event": "user.upgraded", "data": {"user_id": "` + user.Id.String() + `", "plan": "red"}}`
+	request, err := http.NewRequest("POST", server.URL+"/api/v1/polka/webhooks", strings.NewReader(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "text/plain")
+	server.send(request, "ApiKey "+testPolkaKey, 204, nil)
+
+	response = server.sendRaw("/api/v1/keys", user.Token, "application/json", `{"name": "ci", "expires_in_days": -1}`, 400)
+	if len(response.Fields) != 1 || response.Fields[0] != (fieldError{Field: "expires_in_days", Message: "must be at least 0"}) {
+		t.Fatalf("fields = %+v", response.Fields)
+	}
+}
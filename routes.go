@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	apiPrefix = "/api/v1"
+	// The unversioned paths the API was first served on, kept as aliases
+	// until legacyAPISunset.
+	legacyAPIPrefix = "/api"
+)
+
+var (
+	legacyAPIDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	legacyAPISunset     = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
+
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+}
+
+// The API's routes, relative to apiPrefix. api/openapi.json documents each of
+// them.
+func (cfg *apiConfig) apiRoutes() []route {
+	return []route{
+		{"GET", "/healthz", readinessHandler},
+		{"GET", "/readyz", cfg.readyzHandler},
+		{"POST", "/chirps", cfg.postNewChirpHandler},
+		{"GET", "/chirps", cfg.getChirpsHandler},
+		{"GET", "/chirps/{chirpID}", cfg.getChirpByIdHandler},
+		{"POST", "/users", cfg.newUserHandler},
+		{"POST", "/login", cfg.loginHandler},
+		{"POST", "/refresh", cfg.refreshHandler},
+		{"POST", "/revoke", cfg.revokeHandler},
+		{"PUT", "/users", cfg.updateEmailPasswordHandler},
+		{"DELETE", "/chirps/{chirpID}", cfg.deleteChirpByIdHandler},
+		{"POST", "/polka/webhooks", cfg.recievePolkaEvent},
+		{"POST", "/login/2fa", cfg.twoFactorLoginHandler},
+		{"POST", "/users/2fa/enroll", cfg.enrollTwoFactorHandler},
+		{"POST", "/users/2fa/confirm", cfg.confirmTwoFactorHandler},
+		{"POST", "/users/2fa/disable", cfg.disableTwoFactorHandler},
+		{"GET", "/users/me", cfg.getCurrentUserHandler},
+		{"POST", "/oauth/clients", cfg.createOAuthClientHandler},
+		{"GET", "/oauth/clients", cfg.getOAuthClientsHandler},
+		{"DELETE", "/oauth/clients/{clientID}", cfg.deleteOAuthClientHandler},
+		{"GET", "/oauth/authorize", cfg.getAuthorizeHandler},
+		{"POST", "/oauth/authorize", cfg.postAuthorizeHandler},
+		{"POST", "/oauth/token", cfg.oauthTokenHandler},
+		{"POST", "/keys", cfg.createAPIKeyHandler},
+		{"GET", "/keys", cfg.getAPIKeysHandler},
+		{"DELETE", "/keys/{keyID}", cfg.revokeAPIKeyHandler},
+		{"DELETE", "/users", cfg.deleteUserHandler},
+		{"GET", "/users/export", cfg.exportUserHandler},
+		{"PUT", "/users/profile", cfg.updateProfileHandler},
+		{"GET", "/users/{handle}", cfg.getProfileHandler},
+		{"GET", "/users/{handle}/chirps", cfg.getProfileChirpsHandler},
+		{"POST", "/users/{handle}/follow", cfg.followHandler},
+		{"DELETE", "/users/{handle}/follow", cfg.unfollowHandler},
+		{"GET", "/users/{handle}/followers", cfg.getFollowersHandler},
+		{"GET", "/users/{handle}/following", cfg.getFollowingHandler},
+		{"GET", "/timeline", cfg.getTimelineHandler},
+		{"POST", "/chirps/{chirpID}/like", cfg.likeChirpHandler},
+		{"DELETE", "/chirps/{chirpID}/like", cfg.unlikeChirpHandler},
+		{"GET", "/chirps/{chirpID}/thread", cfg.getThreadHandler},
+		{"POST", "/chirps/{chirpID}/rechirp", cfg.rechirpHandler},
+		{"DELETE", "/chirps/{chirpID}/rechirp", cfg.unrechirpHandler},
+		{"GET", "/hashtags/{tag}", cfg.getHashtagChirpsHandler},
+		{"GET", "/trending/hashtags", cfg.getTrendingHashtagsHandler},
+		{"GET", "/search/chirps", cfg.searchChirpsHandler},
+		{"GET", "/search/users", cfg.searchUsersHandler},
+		{"GET", "/notifications", cfg.getNotificationsHandler},
+		{"POST", "/notifications/read", cfg.markNotificationsReadHandler},
+		{"GET", "/notifications/preferences", cfg.getNotificationPreferencesHandler},
+		{"PUT", "/notifications/preferences", cfg.updateNotificationPreferencesHandler},
+		{"GET", "/stream", cfg.streamHandler},
+		{"GET", "/ws", cfg.websocketHandler},
+		{"POST", "/media", cfg.uploadMediaHandler},
+		{"GET", "/media/{mediaID}", cfg.getMediaHandler},
+	}
+}
+
+// frontend serves the web app under /app/.
+func (cfg *apiConfig) routes(frontend http.Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/app/", cfg.middlewareMetricsInc(http.StripPrefix("/app", frontend)))
+	mux.HandleFunc("GET /admin/metrics", cfg.metricsHandler)
+	mux.HandleFunc("POST /admin/reset", cfg.resetHandler)
+	mux.HandleFunc("GET "+apiPrefix+"/openapi.json", openAPIHandler)
+	for _, route := range cfg.apiRoutes() {
+		mux.HandleFunc(route.method+" "+apiPrefix+route.path, route.handler)
+		mux.Handle(route.method+" "+legacyAPIPrefix+route.path, deprecatedAlias(route.handler))
+	}
+	return mux
+}
+
+// Serves a route on its old unversioned path, pointing clients at the
+// versioned one with Deprecation (RFC 9745) and Sunset (RFC 8594) headers.
+func deprecatedAlias(handler http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", legacyAPIDeprecated.Unix())
+	sunset := legacyAPISunset.Format(http.TimeFormat)
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		successor := apiPrefix + strings.TrimPrefix(request.URL.Path, legacyAPIPrefix)
+		writer.Header().Set("Deprecation", deprecation)
+		writer.Header().Set("Sunset", sunset)
+		writer.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		handler.ServeHTTP(writer, request)
+	})
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/search"
+	"github.com/google/uuid"
+)
+
+type chirpSearchResult struct {
+	chirpResponse
+	Rank     float32 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
+type chirpSearchResponse struct {
+	Chirps     []chirpSearchResult `json:"chirps"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+type userSearchResult struct {
+	chirpAuthor
+	Email string `json:"email,omitempty"`
+}
+
+type userSearchResponse struct {
+	Users []userSearchResult `json:"users"`
+}
+
+// Supports "phrases", prefix* and -excluded words in q, plus author, since and
+// until filters. Matches come back best first with <mark> around hits in the
+// headline, and the rest of it HTML-escaped.
+func (cfg *apiConfig) searchChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	query, err := search.ParseQuery(request.URL.Query().Get("q"))
+	if err != nil {
+		handleError("Bad search", err, 400, writer)
+		return
+	}
+
+	searchParams := database.SearchChirpsParams{
+		Query: query,
+		Since: time.Time{},
+		Until: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	if handle := request.URL.Query().Get("author"); handle != "" {
+		handle, err := normaliseHandle(handle)
+		if err != nil {
+			handleError("Bad author", err, 400, writer)
+			return
+		}
+		author, err := cfg.db.GetUserByHandle(request.Context(), sql.NullString{String: handle, Valid: true})
+		if err != nil {
+			handleError("Could not find author", err, 404, writer)
+			return
+		}
+		searchParams.AuthorID = uuid.NullUUID{UUID: author.ID, Valid: true}
+	}
+
+	if since := request.URL.Query().Get("since"); since != "" {
+		searchParams.Since, err = parseSearchDate(since)
+		if err != nil {
+			handleError("Bad since", err, 400, writer)
+			return
+		}
+	}
+	if until := request.URL.Query().Get("until"); until != "" {
+		searchParams.Until, err = parseSearchDate(until)
+		if err != nil {
+			handleError("Bad until", err, 400, writer)
+			return
+		}
+	}
+
+	searchParams.PageSize, err = getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+	searchParams.PageOffset, err = getOffsetCursor(request)
+	if err != nil {
+		handleError("Bad cursor", err, 400, writer)
+		return
+	}
+
+	rows, err := cfg.db.SearchChirps(request.Context(), searchParams)
+	if err != nil {
+		handleError("Failed to search chirps", err, 500, writer)
+		return
+	}
+
+	chirps := []database.Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	responses, err := cfg.makeChirpResponses(chirps, request)
+	if err != nil {
+		handleError("Failed to make chirp responses", err, 500, writer)
+		return
+	}
+
+	response := chirpSearchResponse{Chirps: []chirpSearchResult{}}
+	for i, row := range rows {
+		response.Chirps = append(response.Chirps, chirpSearchResult{
+			chirpResponse: responses[i],
+			Rank:          row.Rank,
+			Headline:      markHeadline(row.Chirp.Body, row.Headline),
+		})
+	}
+	if len(rows) == int(searchParams.PageSize) {
+		response.NextCursor = makeOffsetCursor(searchParams.PageOffset + searchParams.PageSize)
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Failed to marshal search results into json", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+// Everyone can look users up by handle prefix. Admins also match on email
+// prefix, and see the email of each result.
+func (cfg *apiConfig) searchUsersHandler(writer http.ResponseWriter, request *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(request.URL.Query().Get("q")))
+	if query == "" {
+		handleError("Bad search", fmt.Errorf("q is required"), 400, writer)
+		return
+	}
+
+	pageSize, err := getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+
+	isAdmin, err := cfg.isAdminRequest(request)
+	if err != nil {
+		handleError("Could not check admin", err, 500, writer)
+		return
+	}
+
+	users, err := cfg.db.SearchUsersByHandle(request.Context(), database.SearchUsersByHandleParams{
+		Prefix:   escapeLike(strings.TrimPrefix(query, "@")),
+		PageSize: pageSize,
+	})
+	if err != nil {
+		handleError("Failed to search users", err, 500, writer)
+		return
+	}
+
+	if isAdmin {
+		byEmail, err := cfg.db.SearchUsersByEmail(request.Context(), database.SearchUsersByEmailParams{
+			Prefix:   escapeLike(query),
+			PageSize: pageSize,
+		})
+		if err != nil {
+			handleError("Failed to search users", err, 500, writer)
+			return
+		}
+		users = append(users, byEmail...)
+	}
+
+	response := userSearchResponse{Users: []userSearchResult{}}
+	seen := map[uuid.UUID]bool{}
+	for _, user := range users {
+		if seen[user.ID] || len(response.Users) == int(pageSize) {
+			continue
+		}
+		seen[user.ID] = true
+		result := userSearchResult{chirpAuthor: chirpAuthor{
+			Id:          user.ID,
+			Handle:      user.Handle.String,
+			DisplayName: user.DisplayName,
+			AvatarURL:   user.AvatarUrl,
+		}}
+		if isAdmin {
+			result.Email = user.Email
+		}
+		response.Users = append(response.Users, result)
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Failed to marshal search results into json", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+// Only our own login counts here; OAuth tokens and API keys never act as an
+// admin.
+func (cfg *apiConfig) isAdminRequest(request *http.Request) (bool, error) {
+	if request.Header.Get("Authorization") == "" {
+		return false, nil
+	}
+	userID, _, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		return false, nil
+	}
+	return cfg.isAdmin(request.Context(), userID)
+}
+
+func (cfg *apiConfig) isAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if len(cfg.adminEmails) == 0 {
+		return false, nil
+	}
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return cfg.adminEmails[strings.ToLower(user.Email)], nil
+}
+
+func parseSearchDate(date string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, date); err == nil {
+		return parsed.UTC(), nil
+	}
+	parsed, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Dates must be YYYY-MM-DD or RFC 3339")
+	}
+	return parsed, nil
+}
+
+// Escapes the headline and marks the hits in it. The store returns the body
+// with delimiters added, so anything that isn't in the body is a delimiter,
+// even when someone typed the same character into their chirp.
+func markHeadline(body, headline string) string {
+	var marked strings.Builder
+	rest := body
+	for _, char := range headline {
+		if strings.HasPrefix(rest, string(char)) {
+			marked.WriteString(html.EscapeString(string(char)))
+			rest = rest[utf8.RuneLen(char):]
+			continue
+		}
+		switch string(char) {
+		case database.HeadlineStart:
+			marked.WriteString("<mark>")
+		case database.HeadlineStop:
+			marked.WriteString("</mark>")
+		default:
+			// The headline isn't the body plus delimiters, so don't trust it.
+			return html.EscapeString(body)
+		}
+	}
+	if rest != "" {
+		return html.EscapeString(body)
+	}
+	return marked.String()
+}
+
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
@@ -4,19 +4,24 @@ INSERT INTO chirps (
     created_at,
     updated_at,
     body,
-    user_id
+    user_id,
+    reply_to_id,
+    quote_of_id
 )
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4
 )
 RETURNING *;
 
 -- name: GetChirps :many
 SELECT * FROM chirps
+WHERE deleted_at IS NULL
 ORDER BY created_at;
 
 -- name: GetChirpById :one
@@ -29,7 +34,69 @@ WHERE id = $1;
 
 -- name: GetChirpsByUser :many
 SELECT * FROM chirps
-WHERE user_id = $1;
+WHERE user_id = $1 AND deleted_at IS NULL
+ORDER BY created_at;
 
 -- name: ResetChirps :exec
-DELETE FROM chirps;
\ No newline at end of file
+DELETE FROM chirps;
+
+-- name: GetChirpsByPopularity :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+ORDER BY like_count DESC, created_at DESC;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: HasReferences :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE reply_to_id = sqlc.arg(chirp_id) OR quote_of_id = sqlc.arg(chirp_id)
+);
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: CreateRechirp :one
+WITH inserted AS (
+    INSERT INTO chirps (
+        id,
+        created_at,
+        updated_at,
+        body,
+        user_id,
+        rechirp_of_id
+    )
+    VALUES (
+        gen_random_uuid(),
+        NOW(),
+        NOW(),
+        '',
+        sqlc.arg(user_id),
+        sqlc.arg(rechirp_of_id)
+    )
+    ON CONFLICT DO NOTHING
+    RETURNING *
+), counted AS (
+    UPDATE chirps
+    SET rechirp_count = rechirp_count + (SELECT COUNT(*) FROM inserted)
+    WHERE chirps.id = sqlc.arg(rechirp_of_id)
+)
+SELECT * FROM inserted;
+
+-- name: DeleteRechirp :execrows
+WITH deleted AS (
+    DELETE FROM chirps
+    WHERE chirps.user_id = sqlc.arg(user_id) AND chirps.rechirp_of_id = sqlc.arg(rechirp_of_id)
+    RETURNING chirps.id
+)
+UPDATE chirps
+SET rechirp_count = rechirp_count - (SELECT COUNT(*) FROM deleted)
+WHERE chirps.id = sqlc.arg(rechirp_of_id) AND EXISTS (SELECT 1 FROM deleted);
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of_id = $1;
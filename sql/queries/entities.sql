@@ -0,0 +1,47 @@
+-- name: CreateChirpEntity :exec
+INSERT INTO chirp_entities (
+    chirp_id,
+    kind,
+    value,
+    user_id,
+    start_offset,
+    end_offset,
+    created_at
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+-- name: GetChirpEntities :many
+SELECT * FROM chirp_entities
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, start_offset;
+
+-- name: DeleteChirpEntities :exec
+DELETE FROM chirp_entities
+WHERE chirp_id = $1;
+
+-- name: GetChirpsByHashtag :many
+SELECT * FROM chirps
+WHERE chirps.id IN (
+    SELECT chirp_id FROM chirp_entities
+    WHERE chirp_entities.kind = 'hashtag' AND chirp_entities.value = sqlc.arg(tag)
+)
+AND chirps.deleted_at IS NULL
+AND (chirps.created_at, chirps.id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetTrendingHashtags :many
+SELECT value AS tag, COUNT(DISTINCT chirp_id) AS chirp_count
+FROM chirp_entities
+WHERE kind = 'hashtag' AND created_at > sqlc.arg(since)
+GROUP BY value
+ORDER BY chirp_count DESC, tag
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,26 @@
+-- name: LikeChirp :one
+WITH inserted AS (
+    INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+    VALUES (sqlc.arg(user_id), sqlc.arg(chirp_id), NOW())
+    ON CONFLICT DO NOTHING
+    RETURNING chirp_likes.chirp_id
+)
+UPDATE chirps
+SET like_count = like_count + (SELECT COUNT(*) FROM inserted)
+WHERE chirps.id = sqlc.arg(chirp_id)
+RETURNING like_count;
+
+-- name: UnlikeChirp :one
+WITH deleted AS (
+    DELETE FROM chirp_likes
+    WHERE chirp_likes.user_id = sqlc.arg(user_id) AND chirp_likes.chirp_id = sqlc.arg(chirp_id)
+    RETURNING chirp_likes.chirp_id
+)
+UPDATE chirps
+SET like_count = like_count - (SELECT COUNT(*) FROM deleted)
+WHERE chirps.id = sqlc.arg(chirp_id)
+RETURNING like_count;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
@@ -0,0 +1,63 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files (
+    id,
+    created_at,
+    user_id,
+    blob_key,
+    content_type,
+    size_bytes,
+    width,
+    height
+)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetMediaFileByID :one
+SELECT * FROM media_files
+WHERE id = $1;
+
+-- name: GetMediaFilesByIDs :many
+SELECT * FROM media_files
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: AttachMediaFile :exec
+INSERT INTO chirp_attachments (chirp_id, media_id, position)
+VALUES ($1, $2, $3);
+
+-- name: GetChirpAttachments :many
+SELECT chirp_attachments.chirp_id, sqlc.embed(media_files)
+FROM chirp_attachments
+JOIN media_files ON media_files.id = chirp_attachments.media_id
+WHERE chirp_attachments.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_attachments.chirp_id, chirp_attachments.position;
+
+-- name: GetMediaFilesByUser :many
+SELECT * FROM media_files
+WHERE user_id = $1;
+
+-- name: GetCollectableMediaFiles :many
+SELECT * FROM media_files
+WHERE user_id IN (
+    SELECT id FROM users
+    WHERE deleted_at IS NOT NULL AND deleted_at < sqlc.arg(deleted_before)
+)
+OR (
+    created_at < sqlc.arg(uploaded_before)
+    AND NOT EXISTS (
+        SELECT 1 FROM chirp_attachments
+        WHERE chirp_attachments.media_id = media_files.id
+    )
+);
+
+-- name: DeleteMediaFile :exec
+DELETE FROM media_files
+WHERE id = $1;
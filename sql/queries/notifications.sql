@@ -0,0 +1,45 @@
+-- name: CreateNotification :many
+INSERT INTO notifications (id, created_at, user_id, actor_id, type, chirp_id)
+SELECT gen_random_uuid(), NOW(), sqlc.arg(user_id)::uuid, sqlc.arg(actor_id)::uuid, sqlc.arg(type)::text, sqlc.narg(chirp_id)::uuid
+WHERE sqlc.arg(user_id)::uuid <> sqlc.arg(actor_id)::uuid
+AND NOT EXISTS (
+    SELECT 1 FROM notification_preferences
+    WHERE notification_preferences.user_id = sqlc.arg(user_id)::uuid
+    AND notification_preferences.type = sqlc.arg(type)::text
+    AND NOT notification_preferences.enabled
+)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetNotifications :many
+SELECT notifications.*, users.handle AS actor_handle, users.display_name AS actor_display_name, users.avatar_url AS actor_avatar_url
+FROM notifications
+JOIN users ON users.id = notifications.actor_id
+WHERE notifications.user_id = sqlc.arg(user_id)
+AND (NOT sqlc.arg(unread_only)::boolean OR notifications.read_at IS NULL)
+AND (notifications.created_at, notifications.id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg(user_id) AND id = ANY(sqlc.arg(ids)::uuid[]) AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled;
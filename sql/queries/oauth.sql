@@ -0,0 +1,68 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (
+    id,
+    created_at,
+    updated_at,
+    owner_id,
+    name,
+    secret_hash,
+    redirect_uris,
+    scope
+)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients
+WHERE id = $1;
+
+-- name: GetOAuthClientsByOwner :many
+SELECT * FROM oauth_clients
+WHERE owner_id = $1
+ORDER BY created_at;
+
+-- name: DeleteOAuthClient :execrows
+DELETE FROM oauth_clients
+WHERE id = $1 AND owner_id = $2;
+
+-- name: AddOAuthCode :exec
+INSERT INTO oauth_authorization_codes (
+    code,
+    created_at,
+    client_id,
+    user_id,
+    redirect_uri,
+    scope,
+    code_challenge,
+    code_challenge_method,
+    expires_at
+)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+);
+
+-- name: GetOAuthCode :one
+SELECT * FROM oauth_authorization_codes
+WHERE code = $1;
+
+-- name: UseOAuthCode :execrows
+UPDATE oauth_authorization_codes
+SET used_at = NOW()
+WHERE code = $1 AND used_at IS NULL;
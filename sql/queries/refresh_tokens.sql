@@ -21,4 +21,34 @@ WHERE token = $1;
 -- name: RevokeToken :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE token = $1;
+
+-- name: AddOAuthRefreshToken :exec
+INSERT INTO refresh_tokens (
+    token,
+    created_at,
+    updated_at,
+    user_id,
+    expires_at,
+    client_id,
+    scope
+)
+VALUES (
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: RevokeUserTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: GetTokensByUser :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at;
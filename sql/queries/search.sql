@@ -0,0 +1,25 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+    ts_rank(chirps.search_vector, query)::real AS rank,
+    ts_headline('english', chirps.body, query,
+        'StartSel=' || chr(57344) || ', StopSel=' || chr(57345) || ', HighlightAll=true') AS headline
+FROM chirps, to_tsquery('english', sqlc.arg(query)) AS query
+WHERE chirps.search_vector @@ query
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg(author_id)::uuid IS NULL OR chirps.user_id = sqlc.narg(author_id))
+AND chirps.created_at >= sqlc.arg(since)::timestamp
+AND chirps.created_at < sqlc.arg(until)::timestamp
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: SearchUsersByHandle :many
+SELECT * FROM users
+WHERE handle LIKE sqlc.arg(prefix)::text || '%' AND deleted_at IS NULL
+ORDER BY handle
+LIMIT sqlc.arg(page_size);
+
+-- name: SearchUsersByEmail :many
+SELECT * FROM users
+WHERE lower(email) LIKE sqlc.arg(prefix)::text || '%'
+ORDER BY lower(email)
+LIMIT sqlc.arg(page_size);
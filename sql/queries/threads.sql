@@ -0,0 +1,30 @@
+-- name: GetThreadAncestorIDs :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.reply_to_id, 1 AS depth
+    FROM chirps AS child
+    JOIN chirps AS parent ON parent.id = child.reply_to_id
+    WHERE child.id = sqlc.arg(chirp_id)
+    UNION ALL
+    SELECT chirps.id, chirps.reply_to_id, ancestors.depth + 1
+    FROM chirps
+    JOIN ancestors ON chirps.id = ancestors.reply_to_id
+    WHERE ancestors.depth < sqlc.arg(max_depth)::integer
+)
+SELECT id, depth FROM ancestors
+ORDER BY depth DESC;
+
+-- name: GetThreadDescendantIDs :many
+WITH RECURSIVE descendants AS (
+    SELECT chirps.id, chirps.created_at, 1 AS depth
+    FROM chirps
+    WHERE chirps.reply_to_id = sqlc.arg(chirp_id)
+    UNION ALL
+    SELECT chirps.id, chirps.created_at, descendants.depth + 1
+    FROM chirps
+    JOIN descendants ON chirps.reply_to_id = descendants.id
+    WHERE descendants.depth < sqlc.arg(max_depth)::integer
+)
+SELECT id, created_at, depth FROM descendants
+WHERE (created_at, id) > (sqlc.arg(after_created_at)::timestamp, sqlc.arg(after_id)::uuid)
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,42 @@
+-- name: GetTimelineByJoin :many
+SELECT chirps.*
+FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = sqlc.arg(user_id)
+AND chirps.deleted_at IS NULL
+AND (chirps.created_at, chirps.id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetTimelineByFanOut :many
+SELECT chirps.*
+FROM timeline_entries
+JOIN chirps ON chirps.id = timeline_entries.chirp_id
+WHERE timeline_entries.user_id = sqlc.arg(user_id)
+AND chirps.deleted_at IS NULL
+AND (timeline_entries.chirp_created_at, timeline_entries.chirp_id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY timeline_entries.chirp_created_at DESC, timeline_entries.chirp_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: FanOutChirp :exec
+INSERT INTO timeline_entries (user_id, chirp_id, chirp_created_at)
+SELECT follows.follower_id, sqlc.arg(chirp_id), sqlc.arg(chirp_created_at)
+FROM follows
+WHERE follows.followee_id = sqlc.arg(author_id)
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, chirp_created_at)
+SELECT sqlc.arg(user_id), recent.id, recent.created_at
+FROM (
+    SELECT id, created_at FROM chirps
+    WHERE chirps.user_id = sqlc.arg(followee_id)
+    ORDER BY created_at DESC
+    LIMIT sqlc.arg(backfill_size)
+) AS recent
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveFromTimeline :exec
+DELETE FROM timeline_entries
+WHERE timeline_entries.user_id = sqlc.arg(user_id)
+AND chirp_id IN (SELECT id FROM chirps WHERE chirps.user_id = sqlc.arg(followee_id));
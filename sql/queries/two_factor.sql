@@ -0,0 +1,50 @@
+-- name: AddRecoveryCode :exec
+INSERT INTO recovery_codes (
+    id,
+    created_at,
+    user_id,
+    code_hash
+)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: AddTwoFactorChallenge :exec
+INSERT INTO two_factor_challenges (
+    token,
+    created_at,
+    user_id,
+    expires_at
+)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3
+);
+
+-- name: GetTwoFactorChallenge :one
+SELECT * FROM two_factor_challenges
+WHERE token = $1;
+
+-- name: UseTwoFactorChallenge :execrows
+UPDATE two_factor_challenges
+SET used_at = NOW()
+WHERE token = $1 AND used_at IS NULL;
+
+-- name: AttemptTwoFactorChallenge :execrows
+UPDATE two_factor_challenges
+SET attempts = attempts + 1
+WHERE token = $1 AND used_at IS NULL AND attempts < sqlc.arg(max_attempts);
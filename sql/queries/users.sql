@@ -26,4 +26,117 @@ RETURNING *;
 -- name: UpgradeByID :exec
 UPDATE users
 SET is_chirpy_red = true
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetUserByID :one
+SELECT *
+FROM users
+WHERE id = $1;
+
+-- name: SetTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_enabled = false, updated_at = NOW()
+WHERE id = $1;
+
+-- name: EnableTOTP :exec
+UPDATE users
+SET totp_enabled = true, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled = false, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2);
+
+-- name: SoftDeleteUser :exec
+UPDATE users
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: RestoreUser :exec
+UPDATE users
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: PurgeDeletedUsers :execrows
+WITH deleted AS (
+    SELECT id FROM users
+    WHERE deleted_at IS NOT NULL AND deleted_at < $1
+), counted AS (
+    UPDATE chirps
+    SET
+        like_count = like_count - (
+            SELECT COUNT(*) FROM chirp_likes
+            WHERE chirp_likes.chirp_id = chirps.id AND chirp_likes.user_id IN (SELECT id FROM deleted)
+        ),
+        rechirp_count = rechirp_count - (
+            SELECT COUNT(*) FROM chirps AS rechirps
+            WHERE rechirps.rechirp_of_id = chirps.id AND rechirps.user_id IN (SELECT id FROM deleted)
+        )
+    WHERE chirps.id IN (
+        SELECT chirp_id FROM chirp_likes WHERE user_id IN (SELECT id FROM deleted)
+        UNION
+        SELECT rechirp_of_id FROM chirps WHERE user_id IN (SELECT id FROM deleted)
+    )
+)
+DELETE FROM users
+WHERE id IN (SELECT id FROM deleted);
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2, display_name = $3, bio = $4, avatar_url = $5, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserByHandle :one
+SELECT *
+FROM users
+WHERE handle = $1 AND deleted_at IS NULL;
+
+-- name: GetUsersByIDs :many
+SELECT *
+FROM users
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE handle = ANY(sqlc.arg(handles)::text[]) AND deleted_at IS NULL;
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at
+LIMIT $1 OFFSET $2;
+
+-- name: SetChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteUser :execrows
+WITH deleted AS (
+    SELECT id FROM users
+    WHERE id = $1
+), counted AS (
+    UPDATE chirps
+    SET
+        like_count = like_count - (
+            SELECT COUNT(*) FROM chirp_likes
+            WHERE chirp_likes.chirp_id = chirps.id AND chirp_likes.user_id IN (SELECT id FROM deleted)
+        ),
+        rechirp_count = rechirp_count - (
+            SELECT COUNT(*) FROM chirps AS rechirps
+            WHERE rechirps.rechirp_of_id = chirps.id AND rechirps.user_id IN (SELECT id FROM deleted)
+        )
+    WHERE chirps.id IN (
+        SELECT chirp_id FROM chirp_likes WHERE user_id IN (SELECT id FROM deleted)
+        UNION
+        SELECT rechirp_of_id FROM chirps WHERE user_id IN (SELECT id FROM deleted)
+    )
+)
+DELETE FROM users
+WHERE id IN (SELECT id FROM deleted);
@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE oauth_clients (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    owner_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    secret_hash TEXT,
+    redirect_uris TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    CONSTRAINT fk_users
+    FOREIGN KEY (owner_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE oauth_authorization_codes (
+    code TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    client_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    code_challenge TEXT NOT NULL,
+    code_challenge_method TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    CONSTRAINT fk_oauth_clients
+    FOREIGN KEY (client_id)
+    REFERENCES oauth_clients(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+ALTER TABLE refresh_tokens
+ADD COLUMN client_id UUID
+CONSTRAINT fk_oauth_clients REFERENCES oauth_clients(id) ON DELETE CASCADE,
+ADD COLUMN scope TEXT;
+
+-- +goose Down
+ALTER TABLE refresh_tokens
+DROP COLUMN scope,
+DROP COLUMN client_id;
+DROP TABLE oauth_authorization_codes;
+DROP TABLE oauth_clients;
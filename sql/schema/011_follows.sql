@@ -0,0 +1,41 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID NOT NULL,
+    followee_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CONSTRAINT no_self_follow CHECK (follower_id <> followee_id),
+    CONSTRAINT fk_follower
+    FOREIGN KEY (follower_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_followee
+    FOREIGN KEY (followee_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX follows_followee_idx ON follows (followee_id, created_at DESC);
+CREATE INDEX chirps_user_created_idx ON chirps (user_id, created_at DESC, id DESC);
+
+CREATE TABLE timeline_entries (
+    user_id UUID NOT NULL,
+    chirp_id UUID NOT NULL,
+    chirp_created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id),
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX timeline_entries_page_idx ON timeline_entries (user_id, chirp_created_at DESC, chirp_id DESC);
+
+-- +goose Down
+DROP TABLE timeline_entries;
+DROP INDEX chirps_user_created_idx;
+DROP TABLE follows;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+    user_id UUID NOT NULL,
+    chirp_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id),
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_likes_chirp_idx ON chirp_likes (chirp_id);
+
+ALTER TABLE chirps
+ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX chirps_popularity_idx ON chirps (like_count DESC, created_at DESC);
+
+-- +goose Down
+DROP INDEX chirps_popularity_idx;
+ALTER TABLE chirps
+DROP COLUMN like_count;
+DROP TABLE chirp_likes;
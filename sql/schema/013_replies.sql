@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN reply_to_id UUID
+CONSTRAINT fk_reply_to REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_reply_to_idx ON chirps (reply_to_id, created_at, id);
+
+-- +goose Down
+DROP INDEX chirps_reply_to_idx;
+ALTER TABLE chirps
+DROP COLUMN deleted_at,
+DROP COLUMN reply_to_id;
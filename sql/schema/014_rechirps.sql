@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN rechirp_of_id UUID
+CONSTRAINT fk_rechirp_of REFERENCES chirps(id) ON DELETE CASCADE,
+ADD COLUMN quote_of_id UUID
+CONSTRAINT fk_quote_of REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN rechirp_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX chirps_one_rechirp_idx ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL;
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of_id);
+
+-- +goose Down
+DROP INDEX chirps_quote_of_idx;
+DROP INDEX chirps_one_rechirp_idx;
+ALTER TABLE chirps
+DROP COLUMN rechirp_count,
+DROP COLUMN quote_of_id,
+DROP COLUMN rechirp_of_id;
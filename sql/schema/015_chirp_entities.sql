@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE chirp_entities (
+    chirp_id UUID NOT NULL,
+    kind TEXT NOT NULL,
+    value TEXT NOT NULL,
+    user_id UUID,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset),
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_entities_value_idx ON chirp_entities (kind, value, created_at DESC);
+CREATE INDEX chirp_entities_created_at_idx ON chirp_entities (kind, created_at);
+CREATE INDEX chirp_entities_user_idx ON chirp_entities (user_id) WHERE user_id IS NOT NULL;
+
+-- +goose Down
+DROP TABLE chirp_entities;
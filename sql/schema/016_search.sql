@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN search_vector TSVECTOR
+GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX chirps_search_idx ON chirps USING GIN (search_vector);
+CREATE INDEX users_handle_prefix_idx ON users (handle text_pattern_ops);
+CREATE INDEX users_email_prefix_idx ON users (lower(email) text_pattern_ops);
+
+-- +goose Down
+DROP INDEX users_email_prefix_idx;
+DROP INDEX users_handle_prefix_idx;
+DROP INDEX chirps_search_idx;
+ALTER TABLE chirps
+DROP COLUMN search_vector;
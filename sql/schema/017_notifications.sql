@@ -0,0 +1,47 @@
+-- +goose Up
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    actor_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    chirp_id UUID,
+    read_at TIMESTAMP,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_actors
+    FOREIGN KEY (actor_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+-- Liking, unliking and liking again only notifies once.
+CREATE UNIQUE INDEX notifications_once_idx ON notifications (
+    user_id,
+    actor_id,
+    type,
+    COALESCE(chirp_id, '00000000-0000-0000-0000-000000000000')
+);
+CREATE INDEX notifications_page_idx ON notifications (user_id, created_at DESC, id DESC);
+CREATE INDEX notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE notification_preferences;
+DROP TABLE notifications;
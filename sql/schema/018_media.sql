@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE media_files (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    blob_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE chirp_attachments (
+    chirp_id UUID NOT NULL,
+    media_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, position),
+    CONSTRAINT max_four_attachments CHECK (position BETWEEN 0 AND 3),
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_media_files
+    FOREIGN KEY (media_id)
+    REFERENCES media_files(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_attachments_media_idx ON chirp_attachments (media_id);
+
+-- +goose Down
+DROP TABLE chirp_attachments;
+DROP TABLE media_files;
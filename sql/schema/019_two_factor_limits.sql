@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN totp_last_step BIGINT;
+
+ALTER TABLE two_factor_challenges
+ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE two_factor_challenges
+DROP COLUMN attempts;
+
+ALTER TABLE users
+DROP COLUMN totp_last_step;
@@ -0,0 +1,48 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    name,
+    prefix,
+    key_hash,
+    scope,
+    expires_at
+)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+)
+RETURNING *;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT * FROM api_keys
+WHERE prefix = ?;
+
+-- name: GetAPIKeysByUser :many
+SELECT * FROM api_keys
+WHERE user_id = ?
+ORDER BY created_at;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = ?;
+
+-- name: RevokeUserAPIKeys :exec
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = ? AND revoked_at IS NULL;
@@ -0,0 +1,91 @@
+-- name: CreateChirp :one
+INSERT INTO chirps (
+    id,
+    created_at,
+    updated_at,
+    body,
+    user_id,
+    reply_to_id,
+    quote_of_id
+)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    ?,
+    ?,
+    ?,
+    ?
+)
+RETURNING *;
+
+-- name: GetChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: GetChirpById :one
+SELECT * FROM chirps
+WHERE id = ?;
+
+-- name: DeleteChirp :exec
+DELETE FROM chirps
+WHERE id = ?;
+
+-- name: GetChirpsByUser :many
+SELECT * FROM chirps
+WHERE user_id = ? AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: ResetChirps :exec
+DELETE FROM chirps;
+
+-- name: GetChirpsByPopularity :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+ORDER BY like_count DESC, created_at DESC;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = ?;
+
+-- name: HasReferences :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE reply_to_id = sqlc.arg(chirp_id) OR quote_of_id = sqlc.arg(chirp_id)
+);
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id IN (sqlc.slice(ids));
+
+-- The chirps_rechirp_count triggers keep the original's rechirp_count.
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (
+    id,
+    created_at,
+    updated_at,
+    body,
+    user_id,
+    rechirp_of_id
+)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    '',
+    sqlc.arg(user_id),
+    sqlc.arg(rechirp_of_id)
+)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = sqlc.arg(user_id) AND rechirp_of_id = sqlc.arg(rechirp_of_id);
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of_id = ?;
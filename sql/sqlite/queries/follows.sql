@@ -0,0 +1,34 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (?, ?, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = ? AND followee_id = ?;
+
+-- name: CountFollowers :one
+SELECT COUNT(*) FROM follows
+WHERE followee_id = ?;
+
+-- name: CountFollowing :one
+SELECT COUNT(*) FROM follows
+WHERE follower_id = ?;
+
+-- name: GetFollowers :many
+SELECT users.id, users.handle, users.display_name, users.avatar_url, follows.created_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg(followee_id)
+AND (follows.created_at, users.id) < (sqlc.arg(before_created_at), CAST(sqlc.arg(before_id) AS TEXT))
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetFollowing :many
+SELECT users.id, users.handle, users.display_name, users.avatar_url, follows.created_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg(follower_id)
+AND (follows.created_at, users.id) < (sqlc.arg(before_created_at), CAST(sqlc.arg(before_id) AS TEXT))
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,19 @@
+-- The chirp_likes_count triggers keep like_count, so LikeChirp and
+-- UnlikeChirp are followed by GetLikeCount.
+
+-- name: LikeChirp :exec
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES (?, ?, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes
+WHERE user_id = ? AND chirp_id = ?;
+
+-- name: GetLikeCount :one
+SELECT like_count FROM chirps
+WHERE id = ?;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id) AND chirp_id IN (sqlc.slice(chirp_ids));
@@ -0,0 +1,44 @@
+-- name: CreateNotification :many
+INSERT INTO notifications (id, created_at, user_id, actor_id, type, chirp_id)
+SELECT gen_random_uuid(), NOW(), sqlc.arg(user_id), sqlc.arg(actor_id), sqlc.arg(type), sqlc.narg(chirp_id)
+WHERE sqlc.arg(user_id) <> sqlc.arg(actor_id)
+AND COALESCE((
+    SELECT notification_preferences.enabled FROM notification_preferences
+    WHERE notification_preferences.user_id = sqlc.arg(user_id)
+    AND notification_preferences.type = sqlc.arg(type)
+), true)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetNotifications :many
+SELECT notifications.*, users.handle AS actor_handle, users.display_name AS actor_display_name, users.avatar_url AS actor_avatar_url
+FROM notifications
+JOIN users ON users.id = notifications.actor_id
+WHERE notifications.user_id = sqlc.arg(user_id)
+AND (CAST(sqlc.arg(unread_only) AS BOOLEAN) = false OR notifications.read_at IS NULL)
+AND (notifications.created_at, notifications.id) < (sqlc.arg(before_created_at), CAST(sqlc.arg(before_id) AS TEXT))
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = ? AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg(user_id) AND id IN (sqlc.slice(ids)) AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = ? AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = ?;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = excluded.enabled;
@@ -0,0 +1,68 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (
+    id,
+    created_at,
+    updated_at,
+    owner_id,
+    name,
+    secret_hash,
+    redirect_uris,
+    scope
+)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+)
+RETURNING *;
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients
+WHERE id = ?;
+
+-- name: GetOAuthClientsByOwner :many
+SELECT * FROM oauth_clients
+WHERE owner_id = ?
+ORDER BY created_at;
+
+-- name: DeleteOAuthClient :execrows
+DELETE FROM oauth_clients
+WHERE id = ? AND owner_id = ?;
+
+-- name: AddOAuthCode :exec
+INSERT INTO oauth_authorization_codes (
+    code,
+    created_at,
+    client_id,
+    user_id,
+    redirect_uri,
+    scope,
+    code_challenge,
+    code_challenge_method,
+    expires_at
+)
+VALUES (
+    ?,
+    NOW(),
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?
+);
+
+-- name: GetOAuthCode :one
+SELECT * FROM oauth_authorization_codes
+WHERE code = ?;
+
+-- name: UseOAuthCode :execrows
+UPDATE oauth_authorization_codes
+SET used_at = NOW()
+WHERE code = ? AND used_at IS NULL;
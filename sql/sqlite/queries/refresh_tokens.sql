@@ -0,0 +1,54 @@
+-- name: AddRefreshToken :exec
+INSERT INTO refresh_tokens (
+    token,
+    created_at,
+    updated_at,
+    user_id,
+    expires_at
+)
+VALUES (
+    ?,
+    NOW(),
+    NOW(),
+    ?,
+    ?
+);
+
+-- name: GetToken :one
+SELECT * FROM refresh_tokens
+WHERE token = ?;
+
+-- name: RevokeToken :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE token = ?;
+
+-- name: AddOAuthRefreshToken :exec
+INSERT INTO refresh_tokens (
+    token,
+    created_at,
+    updated_at,
+    user_id,
+    expires_at,
+    client_id,
+    scope
+)
+VALUES (
+    ?,
+    NOW(),
+    NOW(),
+    ?,
+    ?,
+    ?,
+    ?
+);
+
+-- name: RevokeUserTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = ? AND revoked_at IS NULL;
+
+-- name: GetTokensByUser :many
+SELECT * FROM refresh_tokens
+WHERE user_id = ?
+ORDER BY created_at;
@@ -0,0 +1,29 @@
+-- query is in FTS5 syntax, and rank is negated bm25 so that, as with
+-- ts_rank, higher is better. Hits in the headline are delimited as in the
+-- Postgres query, U+E000 to U+E001.
+
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+    CAST(-bm25(chirps_search) AS REAL) AS "rank",
+    CAST(highlight(chirps_search, 1, char(57344), char(57345)) AS TEXT) AS headline
+FROM chirps_search
+JOIN chirps ON chirps.id = chirps_search.chirp_id
+WHERE chirps_search.body MATCH sqlc.arg(query)
+AND chirps.deleted_at IS NULL
+AND (chirps.user_id = sqlc.narg(author_id) OR sqlc.narg(author_id) IS NULL)
+AND chirps.created_at >= sqlc.arg(since)
+AND chirps.created_at < sqlc.arg(until)
+ORDER BY "rank" DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: SearchUsersByHandle :many
+SELECT * FROM users
+WHERE handle LIKE CAST(sqlc.arg(prefix) AS TEXT) || '%' ESCAPE '\' AND deleted_at IS NULL
+ORDER BY handle
+LIMIT sqlc.arg(page_size);
+
+-- name: SearchUsersByEmail :many
+SELECT * FROM users
+WHERE lower(email) LIKE CAST(sqlc.arg(prefix) AS TEXT) || '%' ESCAPE '\'
+ORDER BY lower(email)
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,37 @@
+-- name: GetThreadAncestorIDs :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.reply_to_id, 1 AS depth
+    FROM chirps AS child
+    JOIN chirps AS parent ON parent.id = child.reply_to_id
+    WHERE child.id = sqlc.arg(chirp_id)
+    UNION ALL
+    SELECT chirps.id, chirps.reply_to_id, ancestors.depth + 1
+    FROM chirps
+    JOIN ancestors ON chirps.id = ancestors.reply_to_id
+    WHERE ancestors.depth < CAST(sqlc.arg(max_depth) AS INTEGER)
+)
+SELECT chirps.id, CAST(ancestors.depth AS INTEGER) AS depth
+FROM ancestors
+JOIN chirps ON chirps.id = ancestors.id
+ORDER BY ancestors.depth DESC;
+
+-- Joins back to chirps for created_at, which SQLite only reads back as a
+-- timestamp straight from its column.
+
+-- name: GetThreadDescendantIDs :many
+WITH RECURSIVE descendants AS (
+    SELECT chirps.id, chirps.created_at, 1 AS depth
+    FROM chirps
+    WHERE chirps.reply_to_id = sqlc.arg(chirp_id)
+    UNION ALL
+    SELECT chirps.id, chirps.created_at, descendants.depth + 1
+    FROM chirps
+    JOIN descendants ON chirps.reply_to_id = descendants.id
+    WHERE descendants.depth < CAST(sqlc.arg(max_depth) AS INTEGER)
+)
+SELECT chirps.id, chirps.created_at, CAST(descendants.depth AS INTEGER) AS depth
+FROM descendants
+JOIN chirps ON chirps.id = descendants.id
+WHERE (chirps.created_at, chirps.id) > (sqlc.arg(after_created_at), CAST(sqlc.arg(after_id) AS TEXT))
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg(page_size);
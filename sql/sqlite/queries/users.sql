@@ -0,0 +1,107 @@
+-- name: CreateUser :one
+INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    ?,
+    ?
+)
+RETURNING *;
+
+-- name: ResetUsers :exec
+DELETE FROM users;
+
+-- name: GetUserByEmail :one
+SELECT *
+FROM users
+WHERE email = ?;
+
+-- name: UpdateUserEmailAndPassword :one
+UPDATE users
+SET email = sqlc.arg(email), hashed_password = sqlc.arg(hashed_password)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpgradeByID :exec
+UPDATE users
+SET is_chirpy_red = true
+WHERE id = ?;
+
+-- name: GetUserByID :one
+SELECT *
+FROM users
+WHERE id = ?;
+
+-- name: SetTOTPSecret :exec
+UPDATE users
+SET totp_secret = sqlc.narg(totp_secret), totp_enabled = false, updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: EnableTOTP :exec
+UPDATE users
+SET totp_enabled = true, updated_at = NOW()
+WHERE id = ?;
+
+-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled = false, updated_at = NOW()
+WHERE id = ?;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = sqlc.arg(totp_last_step)
+WHERE id = sqlc.arg(id) AND (totp_last_step IS NULL OR totp_last_step < sqlc.arg(totp_last_step));
+
+-- name: SoftDeleteUser :exec
+UPDATE users
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = ?;
+
+-- name: RestoreUser :exec
+UPDATE users
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = ?;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at IS NOT NULL AND deleted_at < ?;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    handle = sqlc.narg(handle),
+    display_name = sqlc.arg(display_name),
+    bio = sqlc.arg(bio),
+    avatar_url = sqlc.arg(avatar_url),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetUserByHandle :one
+SELECT *
+FROM users
+WHERE handle = ? AND deleted_at IS NULL;
+
+-- name: GetUsersByIDs :many
+SELECT *
+FROM users
+WHERE id IN (sqlc.slice(ids));
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE handle IN (sqlc.slice(handles)) AND deleted_at IS NULL;
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at
+LIMIT ? OFFSET ?;
+
+-- name: SetChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = sqlc.arg(is_chirpy_red), updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id = ?;
@@ -0,0 +1,387 @@
+-- The Postgres schema in sql/schema as of its migration 018, for SQLite.
+-- UUIDs are stored as text and timestamps as UTC text, which sorts in time
+-- order. like_count, rechirp_count and the search index are kept up to date
+-- by triggers rather than by the queries.
+
+-- +goose Up
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    hashed_password TEXT NOT NULL DEFAULT 'unset',
+    is_chirpy_red BOOLEAN NOT NULL DEFAULT false,
+    totp_secret TEXT,
+    totp_enabled BOOLEAN NOT NULL DEFAULT false,
+    deleted_at TIMESTAMP,
+    handle TEXT UNIQUE,
+    display_name TEXT NOT NULL DEFAULT '',
+    bio TEXT NOT NULL DEFAULT '',
+    avatar_url TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX users_handle_prefix_idx ON users (handle);
+CREATE INDEX users_email_prefix_idx ON users (lower(email));
+
+CREATE TABLE chirps (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    body TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    like_count INTEGER NOT NULL DEFAULT 0,
+    reply_to_id UUID,
+    deleted_at TIMESTAMP,
+    rechirp_of_id UUID,
+    quote_of_id UUID,
+    rechirp_count INTEGER NOT NULL DEFAULT 0,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_reply_to
+    FOREIGN KEY (reply_to_id)
+    REFERENCES chirps(id)
+    ON DELETE SET NULL,
+    CONSTRAINT fk_rechirp_of
+    FOREIGN KEY (rechirp_of_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_quote_of
+    FOREIGN KEY (quote_of_id)
+    REFERENCES chirps(id)
+    ON DELETE SET NULL
+);
+
+CREATE INDEX chirps_user_created_idx ON chirps (user_id, created_at DESC, id DESC);
+CREATE INDEX chirps_popularity_idx ON chirps (like_count DESC, created_at DESC);
+CREATE INDEX chirps_reply_to_idx ON chirps (reply_to_id, created_at, id);
+CREATE UNIQUE INDEX chirps_one_rechirp_idx ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL;
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_rechirp_count_insert AFTER INSERT ON chirps
+WHEN NEW.rechirp_of_id IS NOT NULL
+BEGIN
+    UPDATE chirps SET rechirp_count = rechirp_count + 1 WHERE id = NEW.rechirp_of_id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_rechirp_count_delete AFTER DELETE ON chirps
+WHEN OLD.rechirp_of_id IS NOT NULL
+BEGIN
+    UPDATE chirps SET rechirp_count = rechirp_count - 1 WHERE id = OLD.rechirp_of_id;
+END;
+-- +goose StatementEnd
+
+-- Stands in for the tsvector column and its GIN index. chirp_id isn't
+-- indexed, so keeping the table in step scans it; fine at SQLite sizes.
+CREATE VIRTUAL TABLE chirps_search USING fts5 (
+    chirp_id UNINDEXED,
+    body,
+    tokenize = 'porter unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_search_insert AFTER INSERT ON chirps
+BEGIN
+    INSERT INTO chirps_search (chirp_id, body) VALUES (NEW.id, NEW.body);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_search_update AFTER UPDATE OF body ON chirps
+BEGIN
+    UPDATE chirps_search SET body = NEW.body WHERE chirp_id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_search_delete AFTER DELETE ON chirps
+BEGIN
+    DELETE FROM chirps_search WHERE chirp_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+CREATE TABLE oauth_clients (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    owner_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    secret_hash TEXT,
+    redirect_uris TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    CONSTRAINT fk_users
+    FOREIGN KEY (owner_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE oauth_authorization_codes (
+    code TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    client_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    code_challenge TEXT NOT NULL,
+    code_challenge_method TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    CONSTRAINT fk_oauth_clients
+    FOREIGN KEY (client_id)
+    REFERENCES oauth_clients(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE refresh_tokens (
+    token TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    client_id UUID,
+    scope TEXT,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_oauth_clients
+    FOREIGN KEY (client_id)
+    REFERENCES oauth_clients(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE two_factor_challenges (
+    token TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT UNIQUE NOT NULL,
+    key_hash TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE follows (
+    follower_id UUID NOT NULL,
+    followee_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CONSTRAINT no_self_follow CHECK (follower_id <> followee_id),
+    CONSTRAINT fk_follower
+    FOREIGN KEY (follower_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_followee
+    FOREIGN KEY (followee_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX follows_followee_idx ON follows (followee_id, created_at DESC);
+
+CREATE TABLE timeline_entries (
+    user_id UUID NOT NULL,
+    chirp_id UUID NOT NULL,
+    chirp_created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id),
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX timeline_entries_page_idx ON timeline_entries (user_id, chirp_created_at DESC, chirp_id DESC);
+
+CREATE TABLE chirp_likes (
+    user_id UUID NOT NULL,
+    chirp_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id),
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_likes_chirp_idx ON chirp_likes (chirp_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER chirp_likes_count_insert AFTER INSERT ON chirp_likes
+BEGIN
+    UPDATE chirps SET like_count = like_count + 1 WHERE id = NEW.chirp_id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirp_likes_count_delete AFTER DELETE ON chirp_likes
+BEGIN
+    UPDATE chirps SET like_count = like_count - 1 WHERE id = OLD.chirp_id;
+END;
+-- +goose StatementEnd
+
+CREATE TABLE chirp_entities (
+    chirp_id UUID NOT NULL,
+    kind TEXT NOT NULL,
+    value TEXT NOT NULL,
+    user_id UUID,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset),
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_entities_value_idx ON chirp_entities (kind, value, created_at DESC);
+CREATE INDEX chirp_entities_created_at_idx ON chirp_entities (kind, created_at);
+CREATE INDEX chirp_entities_user_idx ON chirp_entities (user_id) WHERE user_id IS NOT NULL;
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    actor_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    chirp_id UUID,
+    read_at TIMESTAMP,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_actors
+    FOREIGN KEY (actor_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE
+);
+
+-- Liking, unliking and liking again only notifies once.
+CREATE UNIQUE INDEX notifications_once_idx ON notifications (
+    user_id,
+    actor_id,
+    type,
+    COALESCE(chirp_id, '00000000-0000-0000-0000-000000000000')
+);
+CREATE INDEX notifications_page_idx ON notifications (user_id, created_at DESC, id DESC);
+CREATE INDEX notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE media_files (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    blob_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE chirp_attachments (
+    chirp_id UUID NOT NULL,
+    media_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, position),
+    CONSTRAINT max_four_attachments CHECK (position BETWEEN 0 AND 3),
+    CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_media_files
+    FOREIGN KEY (media_id)
+    REFERENCES media_files(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_attachments_media_idx ON chirp_attachments (media_id);
+
+-- +goose Down
+DROP TABLE chirp_attachments;
+DROP TABLE media_files;
+DROP TABLE notification_preferences;
+DROP TABLE notifications;
+DROP TABLE chirp_entities;
+DROP TABLE chirp_likes;
+DROP TABLE timeline_entries;
+DROP TABLE follows;
+DROP TABLE api_keys;
+DROP TABLE two_factor_challenges;
+DROP TABLE recovery_codes;
+DROP TABLE refresh_tokens;
+DROP TABLE oauth_authorization_codes;
+DROP TABLE oauth_clients;
+DROP TABLE chirps_search;
+DROP TABLE chirps;
+DROP TABLE users;
@@ -0,0 +1,8 @@
+// Package schema embeds the SQLite migrations, the counterpart of the
+// Postgres ones in sql/schema.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
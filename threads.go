@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxThreadDepth = 100
+
+type threadReply struct {
+	chirpResponse
+	Depth int32 `json:"depth"`
+}
+
+type threadResponse struct {
+	Ancestors  []chirpResponse `json:"ancestors"`
+	Chirp      chirpResponse   `json:"chirp"`
+	Replies    []threadReply   `json:"replies"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) getThreadHandler(writer http.ResponseWriter, request *http.Request) {
+	chirpID, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		handleError("Bad UUID", err, 400, writer)
+		return
+	}
+
+	pageSize, err := getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+	afterCreatedAt, afterID, err := getAfterCursor(request)
+	if err != nil {
+		handleError("Bad cursor", err, 400, writer)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(request.Context(), chirpID)
+	if err != nil {
+		handleError("Couldn't find chirp", err, 404, writer)
+		return
+	}
+
+	ancestors, err := cfg.db.GetThreadAncestorIDs(request.Context(), database.GetThreadAncestorIDsParams{
+		ChirpID:  chirp.ID,
+		MaxDepth: maxThreadDepth,
+	})
+	if err != nil {
+		handleError("Failed to get thread ancestors", err, 500, writer)
+		return
+	}
+
+	descendants, err := cfg.db.GetThreadDescendantIDs(request.Context(), database.GetThreadDescendantIDsParams{
+		ChirpID:        chirp.ID,
+		MaxDepth:       maxThreadDepth,
+		AfterCreatedAt: afterCreatedAt,
+		AfterID:        afterID,
+		PageSize:       pageSize,
+	})
+	if err != nil {
+		handleError("Failed to get thread replies", err, 500, writer)
+		return
+	}
+
+	ids := []uuid.UUID{}
+	for _, ancestor := range ancestors {
+		ids = append(ids, ancestor.ID)
+	}
+	for _, descendant := range descendants {
+		ids = append(ids, descendant.ID)
+	}
+	threadChirps, err := cfg.db.GetChirpsByIDs(request.Context(), ids)
+	if err != nil {
+		handleError("Failed to get thread chirps", err, 500, writer)
+		return
+	}
+
+	responses, err := cfg.makeChirpResponses(append(threadChirps, chirp), request)
+	if err != nil {
+		handleError("Failed to make chirp responses", err, 500, writer)
+		return
+	}
+	byID := map[uuid.UUID]chirpResponse{}
+	for _, response := range responses {
+		byID[response.Id] = response
+	}
+
+	thread := threadResponse{
+		Ancestors: []chirpResponse{},
+		Chirp:     byID[chirp.ID],
+		Replies:   []threadReply{},
+	}
+	for _, ancestor := range ancestors {
+		if response, ok := byID[ancestor.ID]; ok {
+			thread.Ancestors = append(thread.Ancestors, response)
+		}
+	}
+	for _, descendant := range descendants {
+		if response, ok := byID[descendant.ID]; ok {
+			thread.Replies = append(thread.Replies, threadReply{chirpResponse: response, Depth: descendant.Depth})
+		}
+	}
+	if len(descendants) == int(pageSize) {
+		last := descendants[len(descendants)-1]
+		thread.NextCursor = makeCursor(last.CreatedAt, last.ID)
+	}
+
+	responseJSON, err := json.Marshal(thread)
+	if err != nil {
+		handleError("Failed to marshal thread into json", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+)
+
+// The join strategy reads follows and chirps at request time. The fan-out
+// strategy copies each new chirp into its author's followers' timeline_entries
+// so reads are a single index scan, at the cost of extra writes.
+const (
+	timelineJoin   = "join"
+	timelineFanOut = "fanout"
+)
+
+type timelineResponse struct {
+	Chirps     []chirpResponse `json:"chirps"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) getTimelineHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, auth.ScopeChirpsRead)
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	pageSize, err := getPageSize(request)
+	if err != nil {
+		handleError("Bad limit", err, 400, writer)
+		return
+	}
+	beforeCreatedAt, beforeID, err := getCursor(request)
+	if err != nil {
+		handleError("Bad cursor", err, 400, writer)
+		return
+	}
+
+	var chirps []database.Chirp
+	if cfg.timelineStrategy == timelineFanOut {
+		chirps, err = cfg.db.GetTimelineByFanOut(request.Context(), database.GetTimelineByFanOutParams{
+			UserID:          userID,
+			BeforeCreatedAt: beforeCreatedAt,
+			BeforeID:        beforeID,
+			PageSize:        pageSize,
+		})
+	} else {
+		chirps, err = cfg.db.GetTimelineByJoin(request.Context(), database.GetTimelineByJoinParams{
+			UserID:          userID,
+			BeforeCreatedAt: beforeCreatedAt,
+			BeforeID:        beforeID,
+			PageSize:        pageSize,
+		})
+	}
+	if err != nil {
+		handleError("Failed to get timeline", err, 500, writer)
+		return
+	}
+
+	responses, err := cfg.makeChirpResponses(chirps, request)
+	if err != nil {
+		handleError("Failed to make chirp responses", err, 500, writer)
+		return
+	}
+
+	response := timelineResponse{Chirps: responses}
+	if len(chirps) == int(pageSize) {
+		last := chirps[len(chirps)-1]
+		response.NextCursor = makeCursor(last.CreatedAt, last.ID)
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		handleError("Failed to marshal timeline into json", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(responseJSON)
+}
+
+func (cfg *apiConfig) fanOutChirp(ctx context.Context, chirp database.Chirp) error {
+	if cfg.timelineStrategy != timelineFanOut {
+		return nil
+	}
+	return cfg.db.FanOutChirp(ctx, database.FanOutChirpParams{
+		ChirpID:        chirp.ID,
+		ChirpCreatedAt: chirp.CreatedAt,
+		AuthorID:       chirp.UserID,
+	})
+}
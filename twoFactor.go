@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/auth"
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+const totpIssuer = "Chirpy"
+const recoveryCodeCount = 10
+const twoFactorChallengeLifetime = 5 * time.Minute
+
+// Attempts are counted before the code is checked, so guesses sent at the
+// same time can't get past the limit either.
+const twoFactorMaxAttempts = 5
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type twoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type twoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+func (cfg *apiConfig) enrollTwoFactorHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(request.Context(), userID)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	if user.TotpEnabled {
+		handleError("Two-factor authentication already enabled", nil, 409, writer)
+		return
+	}
+
+	secret, err := auth.MakeTOTPSecret()
+	if err != nil {
+		handleError("Could not make secret", err, 500, writer)
+		return
+	}
+
+	secretParams := database.SetTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	}
+	if err := cfg.db.SetTOTPSecret(request.Context(), secretParams); err != nil {
+		handleError("Could not save secret", err, 500, writer)
+		return
+	}
+
+	response, err := json.Marshal(twoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(secret, user.Email, totpIssuer),
+	})
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) confirmTwoFactorHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	codeRequest := twoFactorCodeRequest{}
+	if !decodeRequest(writer, request, &codeRequest) {
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(request.Context(), userID)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	if user.TotpEnabled {
+		handleError("Two-factor authentication already enabled", nil, 409, writer)
+		return
+	}
+
+	if !user.TotpSecret.Valid {
+		handleError("Two-factor enrollment not started", nil, 400, writer)
+		return
+	}
+
+	step, err := auth.ValidateTOTP(codeRequest.Code, user.TotpSecret.String, time.Now())
+	if err != nil {
+		handleError("Incorrect code", err, 401, writer)
+		return
+	}
+	if err := cfg.useTOTPStep(request.Context(), user.ID, step); err != nil {
+		handleError("Incorrect code", err, 401, writer)
+		return
+	}
+
+	codes, err := cfg.replaceRecoveryCodes(request.Context(), user)
+	if err != nil {
+		handleError("Could not make recovery codes", err, 500, writer)
+		return
+	}
+
+	if err := cfg.db.EnableTOTP(request.Context(), user.ID); err != nil {
+		handleError("Could not enable two-factor authentication", err, 500, writer)
+		return
+	}
+
+	response, err := json.Marshal(recoveryCodesResponse{RecoveryCodes: codes})
+	if err != nil {
+		handleError("Could not make response - two-factor enabled", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) disableTwoFactorHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	codeRequest := twoFactorCodeRequest{}
+	if !decodeRequest(writer, request, &codeRequest) {
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(request.Context(), userID)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	if !user.TotpEnabled {
+		handleError("Two-factor authentication not enabled", nil, 400, writer)
+		return
+	}
+
+	if err := cfg.checkSecondFactor(request.Context(), user, codeRequest.Code); err != nil {
+		handleError("Incorrect code", err, 401, writer)
+		return
+	}
+
+	if err := cfg.db.DisableTOTP(request.Context(), user.ID); err != nil {
+		handleError("Could not disable two-factor authentication", err, 500, writer)
+		return
+	}
+
+	if err := cfg.db.DeleteRecoveryCodes(request.Context(), user.ID); err != nil {
+		handleError("Could not delete recovery codes", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) twoFactorLoginHandler(writer http.ResponseWriter, request *http.Request) {
+	loginRequest := twoFactorLoginRequest{}
+	if !decodeRequest(writer, request, &loginRequest) {
+		return
+	}
+
+	challenge, err := cfg.db.GetTwoFactorChallenge(request.Context(), loginRequest.ChallengeToken)
+	if err != nil {
+		handleError("Challenge does not exist", err, 401, writer)
+		return
+	}
+	if err := checkChallengeValid(challenge); err != nil {
+		handleError("Challenge invalid", err, 401, writer)
+		return
+	}
+
+	attemptParams := database.AttemptTwoFactorChallengeParams{
+		Token:       challenge.Token,
+		MaxAttempts: twoFactorMaxAttempts,
+	}
+	attempted, err := cfg.db.AttemptTwoFactorChallenge(request.Context(), attemptParams)
+	if err != nil {
+		handleError("Could not use challenge", err, 500, writer)
+		return
+	}
+	if attempted == 0 {
+		handleError("Challenge invalid", fmt.Errorf("Challenge Used or Out of Attempts"), 401, writer)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(request.Context(), challenge.UserID)
+	if err != nil {
+		handleError("Could not find user", err, 401, writer)
+		return
+	}
+
+	if err := cfg.checkSecondFactor(request.Context(), user, loginRequest.Code); err != nil {
+		handleError("Incorrect code", err, 401, writer)
+		return
+	}
+
+	used, err := cfg.db.UseTwoFactorChallenge(request.Context(), challenge.Token)
+	if err != nil {
+		handleError("Could not use challenge", err, 500, writer)
+		return
+	}
+	if used == 0 {
+		handleError("Challenge invalid", fmt.Errorf("Challenge Used"), 401, writer)
+		return
+	}
+
+	cfg.writeLoginTokens(user, writer, request)
+}
+
+func (cfg *apiConfig) startTwoFactorChallenge(user database.User, writer http.ResponseWriter, request *http.Request) {
+	challengeToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		handleError("Could not make challenge token", err, 500, writer)
+		return
+	}
+
+	challengeParams := database.AddTwoFactorChallengeParams{
+		Token:     challengeToken,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(twoFactorChallengeLifetime),
+	}
+	if err := cfg.db.AddTwoFactorChallenge(request.Context(), challengeParams); err != nil {
+		handleError("Could not make challenge token", err, 500, writer)
+		return
+	}
+
+	response, err := json.Marshal(twoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challengeToken,
+	})
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(response)
+}
+
+func (cfg *apiConfig) checkSecondFactor(ctx context.Context, user database.User, code string) error {
+	if !user.TotpSecret.Valid {
+		return fmt.Errorf("Two-factor authentication not set up")
+	}
+	if step, err := auth.ValidateTOTP(code, user.TotpSecret.String, time.Now()); err == nil {
+		return cfg.useTOTPStep(ctx, user.ID, step)
+	}
+	recoveryParams := database.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: auth.HashRecoveryCode(code),
+	}
+	used, err := cfg.db.UseRecoveryCode(ctx, recoveryParams)
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return fmt.Errorf("Invalid code")
+	}
+	return nil
+}
+
+// A code stays valid for a few steps, so each step is only accepted once.
+func (cfg *apiConfig) useTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	stepParams := database.UseTOTPStepParams{
+		ID:           userID,
+		TotpLastStep: sql.NullInt64{Int64: step, Valid: true},
+	}
+	used, err := cfg.db.UseTOTPStep(ctx, stepParams)
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return fmt.Errorf("Code already used")
+	}
+	return nil
+}
+
+func (cfg *apiConfig) replaceRecoveryCodes(ctx context.Context, user database.User) ([]string, error) {
+	codes, err := auth.MakeRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.db.DeleteRecoveryCodes(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		codeParams := database.AddRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: auth.HashRecoveryCode(code),
+		}
+		if err := cfg.db.AddRecoveryCode(ctx, codeParams); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+func checkChallengeValid(challenge database.TwoFactorChallenge) error {
+	if time.Now().After(challenge.ExpiresAt) {
+		return fmt.Errorf("Challenge Expired")
+	}
+	if challenge.UsedAt.Valid {
+		return fmt.Errorf("Challenge Used")
+	}
+	return nil
+}
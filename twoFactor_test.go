@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// totpCode is the code an authenticator app would show for secret, steps
+// periods from now. Each step is only accepted once, so later codes in a
+// test use later steps; the server allows one step of clock skew.
+func totpCode(t *testing.T, secret string, steps int64) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal("Could not decode secret:", err)
+	}
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(time.Now().Unix()/30+steps))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+
+	server.do("POST", "/api/v1/users/2fa/confirm", user.Token, twoFactorCodeRequest{Code: "000000"}, 400, nil)
+	enrolled := twoFactorEnrollResponse{}
+	server.do("POST", "/api/v1/users/2fa/enroll", user.Token, nil, 200, &enrolled)
+	if enrolled.Secret == "" || enrolled.ProvisioningURI == "" {
+		t.Fatalf("enroll = %+v", enrolled)
+	}
+	recovery := recoveryCodesResponse{}
+	server.do("POST", "/api/v1/users/2fa/confirm", user.Token, twoFactorCodeRequest{Code: totpCode(t, enrolled.Secret, 0)}, 200, &recovery)
+	if len(recovery.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes", len(recovery.RecoveryCodes))
+	}
+	server.do("POST", "/api/v1/users/2fa/enroll", user.Token, nil, 409, nil)
+
+	login := func() string {
+		challenge := twoFactorChallengeResponse{}
+		server.do("POST", "/api/v1/login", "", userRequest{Email: "a@example.com", Password: testPassword}, 200, &challenge)
+		if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+			t.Fatalf("login = %+v", challenge)
+		}
+		return challenge.ChallengeToken
+	}
+
+	challenge := login()
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: challenge, Code: "not a code"}, 401, nil)
+	loggedIn := newUserResponse{}
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: challenge, Code: totpCode(t, enrolled.Secret, 1)}, 200, &loggedIn)
+	if loggedIn.Id != user.Id || loggedIn.Token == "" {
+		t.Fatalf("2fa login = %+v", loggedIn)
+	}
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: challenge, Code: totpCode(t, enrolled.Secret, 1)}, 401, nil)
+
+	// Recovery codes work once each.
+	recoveryCode := recovery.RecoveryCodes[0]
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: login(), Code: recoveryCode}, 200, nil)
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: login(), Code: recoveryCode}, 401, nil)
+
+	server.do("POST", "/api/v1/users/2fa/disable", user.Token, twoFactorCodeRequest{Code: recovery.RecoveryCodes[1]}, 204, nil)
+	server.do("POST", "/api/v1/login", "", userRequest{Email: "a@example.com", Password: testPassword}, 200, &loggedIn)
+	if loggedIn.Token == "" {
+		t.Fatal("login still asked for a second factor")
+	}
+}
+
+// The password alone mustn't cancel a pending deletion when there's a second
+// factor to pass.
+func TestTwoFactorLoginRestoresDeletedAccount(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	enrolled := twoFactorEnrollResponse{}
+	server.do("POST", "/api/v1/users/2fa/enroll", user.Token, nil, 200, &enrolled)
+	server.do("POST", "/api/v1/users/2fa/confirm", user.Token, twoFactorCodeRequest{Code: totpCode(t, enrolled.Secret, 0)}, 200, nil)
+	server.do("DELETE", "/api/v1/users", user.Token, deleteUserRequest{Password: testPassword}, 202, nil)
+
+	isDeleted := func() bool {
+		t.Helper()
+		stored, err := server.cfg.db.GetUserByID(context.Background(), user.Id)
+		if err != nil {
+			t.Fatal("Could not get user:", err)
+		}
+		return stored.DeletedAt.Valid
+	}
+
+	challenge := twoFactorChallengeResponse{}
+	server.do("POST", "/api/v1/login", "", userRequest{Email: "a@example.com", Password: testPassword}, 200, &challenge)
+	if !challenge.TwoFactorRequired || !isDeleted() {
+		t.Fatal("the password alone cancelled the deletion")
+	}
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "not a code"}, 401, nil)
+	if !isDeleted() {
+		t.Fatal("a wrong code cancelled the deletion")
+	}
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: totpCode(t, enrolled.Secret, 1)}, 200, nil)
+	if isDeleted() {
+		t.Fatal("logging in did not cancel the deletion")
+	}
+}
+
+func TestTwoFactorLimits(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	enrolled := twoFactorEnrollResponse{}
+	server.do("POST", "/api/v1/users/2fa/enroll", user.Token, nil, 200, &enrolled)
+	recovery := recoveryCodesResponse{}
+	confirmCode := totpCode(t, enrolled.Secret, 0)
+	server.do("POST", "/api/v1/users/2fa/confirm", user.Token, twoFactorCodeRequest{Code: confirmCode}, 200, &recovery)
+
+	login := func() string {
+		challenge := twoFactorChallengeResponse{}
+		server.do("POST", "/api/v1/login", "", userRequest{Email: "a@example.com", Password: testPassword}, 200, &challenge)
+		return challenge.ChallengeToken
+	}
+
+	// A code can't be used again, even while it's still current.
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: login(), Code: confirmCode}, 401, nil)
+
+	// Wrong guesses use up the challenge, after which even a good code fails.
+	challenge := login()
+	for range twoFactorMaxAttempts {
+		server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: challenge, Code: "000000"}, 401, nil)
+	}
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: challenge, Code: recovery.RecoveryCodes[0]}, 401, nil)
+	server.do("POST", "/api/v1/login/2fa", "", twoFactorLoginRequest{ChallengeToken: login(), Code: recovery.RecoveryCodes[0]}, 200, nil)
+}
@@ -1,8 +1,8 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,12 +10,13 @@ import (
 
 	"github.com/FFB6C1/bootdev_webservers/internal/auth"
 	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/FFB6C1/bootdev_webservers/internal/service"
 	"github.com/google/uuid"
 )
 
 type userRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
 }
 
 type newUserResponse struct {
@@ -26,28 +27,19 @@ type newUserResponse struct {
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refresh_token"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Handle       string    `json:"handle,omitempty"`
+	DisplayName  string    `json:"display_name,omitempty"`
+	Bio          string    `json:"bio,omitempty"`
+	AvatarURL    string    `json:"avatar_url,omitempty"`
 }
 
 func (cfg *apiConfig) newUserHandler(writer http.ResponseWriter, request *http.Request) {
 	userRequest := userRequest{}
-	decoder := json.NewDecoder(request.Body)
-	if err := decoder.Decode(&userRequest); err != nil {
-		log.Printf("Error decoding user request: %s", err)
-		handleError("Could not decode user request", err, 400, writer)
+	if !decodeRequest(writer, request, &userRequest) {
 		return
 	}
 
-	hashed, err := auth.HashPassword(userRequest.Password)
-	if err != nil {
-		handleError("Could not hash password", err, 500, writer)
-	}
-
-	userParams := database.CreateUserParams{
-		Email:          userRequest.Email,
-		HashedPassword: hashed,
-	}
-
-	newUser, err := cfg.db.CreateUser(request.Context(), userParams)
+	newUser, err := cfg.users.Register(request.Context(), userRequest.Email, userRequest.Password)
 	if err != nil {
 		log.Printf("Error creating new user record: %s", err)
 		handleError("Could not create new user record", err, 500, writer)
@@ -67,49 +59,36 @@ func (cfg *apiConfig) newUserHandler(writer http.ResponseWriter, request *http.R
 
 func (cfg *apiConfig) loginHandler(writer http.ResponseWriter, request *http.Request) {
 	userRequest := userRequest{}
-	decoder := json.NewDecoder(request.Body)
-	if err := decoder.Decode(&userRequest); err != nil {
-		log.Printf("Error decoding user request: %s", err)
-		handleError("Could not decode user request", err, 400, writer)
+	if !decodeRequest(writer, request, &userRequest) {
 		return
 	}
 
-	user, err := cfg.db.GetUserByEmail(context.Background(), userRequest.Email)
+	user, err := cfg.users.Authenticate(request.Context(), userRequest.Email, userRequest.Password)
 	if err != nil {
-		handleError("incorrect email or password", err, 401, writer)
+		code := 500
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			code = 401
+		}
+		handleError("incorrect email or password", err, code, writer)
 		return
 	}
 
-	if err := auth.CheckPasswordHash(userRequest.Password, user.HashedPassword); err != nil {
-		handleError("incorrect email or password", err, 401, writer)
+	if user.TotpEnabled {
+		cfg.startTwoFactorChallenge(user, writer, request)
 		return
 	}
 
-	expirationTime := time.Duration(1) * time.Hour
+	cfg.writeLoginTokens(user, writer, request)
+}
 
-	token, err := auth.MakeJWT(user.ID, cfg.secret, expirationTime)
+func (cfg *apiConfig) writeLoginTokens(user database.User, writer http.ResponseWriter, request *http.Request) {
+	tokens, err := cfg.users.IssueTokens(request.Context(), user)
 	if err != nil {
 		handleError("Could not make auth token", err, 500, writer)
 		return
 	}
 
-	refreshToken, err := auth.MakeRefreshToken()
-	if err != nil {
-		handleError("Could not make auth token", err, 500, writer)
-		return
-	}
-
-	refreshTokenParams := database.AddRefreshTokenParams{
-		Token:     refreshToken,
-		UserID:    user.ID,
-		ExpiresAt: time.Now().AddDate(0, 0, 60),
-	}
-	if err := cfg.db.AddRefreshToken(request.Context(), refreshTokenParams); err != nil {
-		handleError("could not make refresh token", err, 500, writer)
-		return
-	}
-
-	responseJSON, err := makeUserResponseWithToken(user, token, refreshToken)
+	responseJSON, err := makeUserResponseWithToken(user, tokens.Access, tokens.Refresh)
 	if err != nil {
 		handleError("could not make response", err, 500, writer)
 		return
@@ -134,6 +113,10 @@ func (cfg *apiConfig) refreshHandler(writer http.ResponseWriter, request *http.R
 		handleError("Token invalid", err, 401, writer)
 		return
 	}
+	if tokenFull.ClientID.Valid {
+		handleError("Token invalid", fmt.Errorf("OAuth tokens must be refreshed at %s/oauth/token", apiPrefix), 401, writer)
+		return
+	}
 
 	newToken, err := auth.MakeJWT(tokenFull.UserID, cfg.secret, time.Hour)
 	if err != nil {
@@ -162,22 +145,14 @@ func (cfg *apiConfig) revokeHandler(writer http.ResponseWriter, request *http.Re
 }
 
 func (cfg *apiConfig) updateEmailPasswordHandler(writer http.ResponseWriter, request *http.Request) {
-	token, err := auth.GetBearerToken(request.Header)
+	userID, code, err := cfg.authorizeRequest(request, "")
 	if err != nil {
-		handleError("Could not get token from header", err, 401, writer)
-		return
-	}
-
-	userID, err := auth.ValidateJWT(token, cfg.secret)
-	if err != nil {
-		handleError("Could not validate token", err, 401, writer)
+		handleError("Could not validate token", err, code, writer)
 		return
 	}
 
 	userParams := userRequest{}
-	decoder := json.NewDecoder(request.Body)
-	if err := decoder.Decode(&userParams); err != nil {
-		handleError("Could not read request", err, 400, writer)
+	if !decodeRequest(writer, request, &userParams) {
 		return
 	}
 
@@ -209,14 +184,45 @@ func (cfg *apiConfig) updateEmailPasswordHandler(writer http.ResponseWriter, req
 	writer.Write(userJson)
 }
 
-func makeUserResponse(user database.User) ([]byte, error) {
-	responseStruct := newUserResponse{
+func (cfg *apiConfig) getCurrentUserHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, code, err := cfg.authorizeRequest(request, auth.ScopeProfile)
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(request.Context(), userID)
+	if err != nil {
+		handleError("Could not find user", err, 404, writer)
+		return
+	}
+
+	userJson, err := makeUserResponse(user)
+	if err != nil {
+		handleError("Could not make response", err, 500, writer)
+		return
+	}
+
+	writer.WriteHeader(200)
+	writer.Write(userJson)
+}
+
+func makeUserProfile(user database.User) newUserResponse {
+	return newUserResponse{
 		Id:          user.ID,
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
 		Email:       user.Email,
 		IsChirpyRed: user.IsChirpyRed,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarUrl,
 	}
+}
+
+func makeUserResponse(user database.User) ([]byte, error) {
+	responseStruct := makeUserProfile(user)
 	responseJson, err := json.Marshal(responseStruct)
 	if err != nil {
 		return []byte(""), err
@@ -225,15 +231,9 @@ func makeUserResponse(user database.User) ([]byte, error) {
 }
 
 func makeUserResponseWithToken(user database.User, token string, refreshToken string) ([]byte, error) {
-	responseStruct := newUserResponse{
-		Id:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
-		Token:        token,
-		RefreshToken: refreshToken,
-		IsChirpyRed:  user.IsChirpyRed,
-	}
+	responseStruct := makeUserProfile(user)
+	responseStruct.Token = token
+	responseStruct.RefreshToken = refreshToken
 	responseJson, err := json.Marshal(responseStruct)
 	if err != nil {
 		return []byte(""), err
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	server := newTestServer(t)
+	created := newUserResponse{}
+	server.do("POST", "/api/v1/users", "", userRequest{Email: "a@example.com", Password: testPassword}, 201, &created)
+	if created.Email != "a@example.com" || created.Token != "" {
+		t.Fatalf("created user = %+v", created)
+	}
+	server.do("POST", "/api/v1/users", "", userRequest{Email: "a@example.com", Password: testPassword}, 500, nil)
+
+	server.do("POST", "/api/v1/login", "", userRequest{Email: "a@example.com", Password: "wrong"}, 401, nil)
+	server.do("POST", "/api/v1/login", "", userRequest{Email: "b@example.com", Password: testPassword}, 401, nil)
+	user := newUserResponse{}
+	server.do("POST", "/api/v1/login", "", userRequest{Email: "a@example.com", Password: testPassword}, 200, &user)
+	if user.Id != created.Id || user.Token == "" || user.RefreshToken == "" {
+		t.Fatalf("logged in user = %+v", user)
+	}
+
+	me := newUserResponse{}
+	server.do("GET", "/api/v1/users/me", user.Token, nil, 200, &me)
+	if me.Id != created.Id {
+		t.Fatalf("me = %+v", me)
+	}
+	server.do("GET", "/api/v1/users/me", "", nil, 401, nil)
+}
+
+func TestRefreshAndRevoke(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+
+	refreshed := newUserResponse{}
+	server.do("POST", "/api/v1/refresh", user.RefreshToken, nil, 200, &refreshed)
+	server.do("GET", "/api/v1/users/me", refreshed.Token, nil, 200, nil)
+
+	server.do("POST", "/api/v1/revoke", user.RefreshToken, nil, 204, nil)
+	server.do("POST", "/api/v1/refresh", user.RefreshToken, nil, 401, nil)
+	server.do("POST", "/api/v1/refresh", "not-a-token", nil, 401, nil)
+}
+
+func TestUpdateEmailAndPassword(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	server.signUp("taken@example.com", "")
+
+	updated := newUserResponse{}
+	server.do("PUT", "/api/v1/users", user.Token, userRequest{Email: "new@example.com", Password: "new password"}, 200, &updated)
+	if updated.Email != "new@example.com" {
+		t.Fatalf("updated user = %+v", updated)
+	}
+	server.do("POST", "/api/v1/login", "", userRequest{Email: "new@example.com", Password: "new password"}, 200, nil)
+	server.do("PUT", "/api/v1/users", user.Token, userRequest{Email: "taken@example.com", Password: "x"}, 500, nil)
+	server.do("PUT", "/api/v1/users", "", userRequest{Email: "x@example.com", Password: "x"}, 401, nil)
+}
+
+func TestPolkaWebhook(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	upgrade := polkaRequest{Event: "user.upgraded", Data: polkaData{UserId: user.Id.String()}}
+
+	server.do("POST", "/api/v1/polka/webhooks", "ApiKey "+testPolkaKey, polkaRequest{Event: "user.downgraded"}, 204, nil)
+	server.do("POST", "/api/v1/polka/webhooks", "ApiKey "+testPolkaKey, upgrade, 204, nil)
+
+	me := newUserResponse{}
+	server.do("GET", "/api/v1/users/me", user.Token, nil, 200, &me)
+	if !me.IsChirpyRed {
+		t.Fatal("user not upgraded")
+	}
+}
+
+// The handler used to carry on after refusing a bad key, so anyone could
+// upgrade any user.
+func TestPolkaWebhookNeedsTheKey(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	upgrade := polkaRequest{Event: "user.upgraded", Data: polkaData{UserId: user.Id.String()}}
+
+	server.do("POST", "/api/v1/polka/webhooks", "", upgrade, 401, nil)
+	server.do("POST", "/api/v1/polka/webhooks", "ApiKey wrong", upgrade, 401, nil)
+	server.do("POST", "/api/v1/polka/webhooks", user.Token, upgrade, 401, nil)
+	me := newUserResponse{}
+	server.do("GET", "/api/v1/users/me", user.Token, nil, 200, &me)
+	if me.IsChirpyRed {
+		t.Fatal("webhook without the Polka key upgraded the user")
+	}
+}
+
+func TestDeleteAndExportAccount(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	server.postChirp(user.Token, chirp{Body: "my only chirp"})
+
+	export := userExport{}
+	response := server.do("GET", "/api/v1/users/export", user.Token, nil, 200, &export)
+	if response.Header.Get("Content-Disposition") == "" {
+		t.Fatal("export is not an attachment")
+	}
+	if export.Profile.Id != user.Id || len(export.Chirps) != 1 || len(export.Sessions) != 1 {
+		t.Fatalf("export = %+v", export)
+	}
+
+	server.do("DELETE", "/api/v1/users", user.Token, deleteUserRequest{Password: "wrong"}, 401, nil)
+	deleted := deleteUserResponse{}
+	server.do("DELETE", "/api/v1/users", user.Token, deleteUserRequest{Password: testPassword}, 202, &deleted)
+	if !deleted.PurgesAfter.After(deleted.DeletedAt) {
+		t.Fatalf("deletion = %+v", deleted)
+	}
+	server.do("POST", "/api/v1/refresh", user.RefreshToken, nil, 401, nil)
+	server.do("GET", "/api/v1/users/me", user.Token, nil, 401, nil)
+
+	// Logging back in during the grace period restores the account.
+	server.do("POST", "/api/v1/login", "", userRequest{Email: "a@example.com", Password: testPassword}, 200, nil)
+}
+
+// Purged likes and rechirps come off the counts of the chirps they were for,
+// on every backend.
+func TestPurgeLowersCounts(t *testing.T) {
+	server := newTestServer(t)
+	author := server.signUp("author@example.com", "")
+	posted := server.postChirp(author.Token, chirp{Body: "like and share me"})
+	chirpPath := "/api/v1/chirps/" + posted.Id.String()
+
+	fans := []newUserResponse{server.signUp("a@example.com", ""), server.signUp("b@example.com", "")}
+	for _, fan := range fans {
+		server.do("POST", chirpPath+"/like", fan.Token, nil, 200, nil)
+		server.do("POST", chirpPath+"/rechirp", fan.Token, nil, 201, nil)
+	}
+	counts := func(want int32) {
+		t.Helper()
+		got := chirpResponse{}
+		server.do("GET", chirpPath, "", nil, 200, &got)
+		if got.LikeCount != want || got.RechirpCount != want {
+			t.Fatalf("like_count = %d, rechirp_count = %d, want %d", got.LikeCount, got.RechirpCount, want)
+		}
+	}
+	counts(2)
+
+	server.do("DELETE", "/api/v1/users", fans[0].Token, deleteUserRequest{Password: testPassword}, 202, nil)
+	server.cfg.deletionGracePeriod = -time.Hour
+	server.cfg.purgeOnce(context.Background())
+	counts(1)
+
+	if _, err := server.cfg.db.DeleteUser(context.Background(), fans[1].Id); err != nil {
+		t.Fatal(err)
+	}
+	counts(0)
+}
+
+// failingKeysStore runs transactions whose RevokeUserAPIKeys fails.
+type failingKeysStore struct {
+	database.Store
+}
+
+type failingKeysQueries struct {
+	database.Querier
+}
+
+func (failingKeysQueries) RevokeUserAPIKeys(context.Context, uuid.UUID) error {
+	return errors.New("failed")
+}
+
+func (s failingKeysStore) WithTx(ctx context.Context, fn func(ctx context.Context, queries database.Querier) error) error {
+	return s.Store.WithTx(ctx, func(ctx context.Context, queries database.Querier) error {
+		return fn(ctx, failingKeysQueries{queries})
+	})
+}
+
+func TestDeleteUserIsAllOrNothing(t *testing.T) {
+	server := newTestServer(t)
+	user := server.signUp("a@example.com", "")
+	server.cfg.store = failingKeysStore{server.cfg.store}
+
+	server.do("DELETE", "/api/v1/users", user.Token, deleteUserRequest{Password: testPassword}, 500, nil)
+	server.do("GET", "/api/v1/users/me", user.Token, nil, 200, nil)
+	server.do("POST", "/api/v1/refresh", user.RefreshToken, nil, 200, nil)
+}
@@ -0,0 +1,84 @@
+// Package web holds the Chirpy frontend. The files are compiled into the binary
+// so the server can start from any working directory.
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/static"
+)
+
+//go:embed index.html assets
+var embedded embed.FS
+
+// Runtime settings handed to the frontend as window.CHIRPY_CONFIG.
+type Config struct {
+	APIBaseURL string `json:"apiBaseUrl"`
+}
+
+// Returns the embedded files, or the files in dir when it's set so the
+// frontend can be worked on without rebuilding.
+func Files(dir string) fs.FS {
+	if dir == "" {
+		return embedded
+	}
+	return os.DirFS(dir)
+}
+
+// Serves files with index.html rendered as a template against config. Files
+// read from disk are re-rendered on every request; embedded ones only once.
+func Handler(files fs.FS, config Config, reload bool) (http.Handler, error) {
+	page, err := renderIndex(files, config)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := static.New(files)
+	handler.Index = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		current := page
+		if reload {
+			rendered, err := renderIndex(files, config)
+			if err != nil {
+				log.Printf("Could not render index.html: %s", err)
+				http.Error(writer, "Could not render page", http.StatusInternalServerError)
+				return
+			}
+			current = rendered
+		}
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.Header().Set("Cache-Control", "no-cache")
+		writer.Header().Set("ETag", current.etag)
+		http.ServeContent(writer, request, "index.html", time.Time{}, bytes.NewReader(current.body))
+	})
+	return handler, nil
+}
+
+type renderedPage struct {
+	body []byte
+	etag string
+}
+
+func renderIndex(files fs.FS, config Config) (renderedPage, error) {
+	tmpl, err := template.ParseFS(files, "index.html")
+	if err != nil {
+		return renderedPage{}, err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, config); err != nil {
+		return renderedPage{}, err
+	}
+	hash := sha256.Sum256(body.Bytes())
+	return renderedPage{
+		body: body.Bytes(),
+		etag: `"` + hex.EncodeToString(hash[:16]) + `"`,
+	}, nil
+}
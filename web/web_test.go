@@ -0,0 +1,63 @@
+package web
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedHandler(t *testing.T) {
+	handler, err := Handler(Files(""), Config{APIBaseURL: "https://api.example.com"}, false)
+	if err != nil {
+		t.Fatal("Could not make handler:", err)
+	}
+
+	for _, target := range []string{"/", "/chirps/some-id"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", target, nil))
+		if recorder.Code != 200 {
+			t.Fatalf("%s: expected 200, got %d", target, recorder.Code)
+		}
+		if !strings.Contains(recorder.Body.String(), `"apiBaseUrl":"https://api.example.com"`) {
+			t.Fatalf("%s: config not rendered: %s", target, recorder.Body.String())
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/assets/logo.png", nil))
+	if recorder.Code != 200 || recorder.Header().Get("Content-Type") != "image/png" {
+		t.Fatal("Embedded logo not served:", recorder.Code)
+	}
+}
+
+// Run with -race: requests render the page concurrently.
+func TestReloadingHandler(t *testing.T) {
+	files := fstest.MapFS{"index.html": {Data: []byte(`<p>{{.APIBaseURL}}</p>`)}}
+	handler, err := Handler(files, Config{APIBaseURL: "/api"}, true)
+	if err != nil {
+		t.Fatal("Could not make handler:", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+			if recorder.Code != 200 || recorder.Body.String() != "<p>/api</p>" {
+				t.Errorf("got %d: %s", recorder.Code, recorder.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	files["index.html"] = &fstest.MapFile{Data: []byte(`<h1>{{.APIBaseURL}}</h1>`)}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if recorder.Body.String() != "<h1>/api</h1>" {
+		t.Fatal("index.html not reloaded:", recorder.Body.String())
+	}
+}
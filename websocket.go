@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FFB6C1/bootdev_webservers/internal/entities"
+	"github.com/FFB6C1/bootdev_webservers/internal/pubsub"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Topics a connection can subscribe to. user, hashtag and thread take an
+// argument after a colon, e.g. hashtag:golang or thread:<chirp id>.
+const (
+	topicFeed    = "feed"
+	topicUser    = "user"
+	topicHashtag = "hashtag"
+	topicThread  = "thread"
+)
+
+const (
+	eventTyping   = "typing"
+	eventPresence = "presence"
+)
+
+const (
+	presenceOnline  = "online"
+	presenceAway    = "away"
+	presenceOffline = "offline"
+)
+
+const maxWebSocketTopics = 25
+const maxWebSocketMessageSize = 4096
+const websocketWriteWait = 10 * time.Second
+const websocketPongWait = 60 * time.Second
+const websocketPingInterval = 30 * time.Second
+const typingInterval = 3 * time.Second
+
+var websocketUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type websocketClientMessage struct {
+	Type   string `json:"type"`
+	Topic  string `json:"topic"`
+	Status string `json:"status"`
+}
+
+type websocketServerMessage struct {
+	Type   string   `json:"type"`
+	Topics []string `json:"topics,omitempty"`
+	Data   any      `json:"data,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type typingEvent struct {
+	UserId uuid.UUID `json:"user_id"`
+	Topic  string    `json:"topic"`
+}
+
+type presenceEvent struct {
+	UserId uuid.UUID `json:"user_id"`
+	Status string    `json:"status"`
+}
+
+type websocketSession struct {
+	userID     uuid.UUID
+	topics     map[string]bool
+	threads    map[uuid.UUID]map[uuid.UUID]bool
+	lastTyping map[string]time.Time
+}
+
+// Only our own login may connect, as it gets the user's notifications.
+// Browsers can't set headers on a WebSocket handshake, so the token may also
+// be passed as ?access_token=.
+func (cfg *apiConfig) websocketHandler(writer http.ResponseWriter, request *http.Request) {
+	if token := request.URL.Query().Get("access_token"); token != "" && request.Header.Get("Authorization") == "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	userID, code, err := cfg.authorizeRequest(request, "")
+	if err != nil {
+		handleError("Could not validate token", err, code, writer)
+		return
+	}
+
+	conn, err := websocketUpgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		log.Printf("Could not upgrade to websocket: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	cfg.websockets.Add(1)
+	defer cfg.websockets.Done()
+
+	subscription, _, _ := cfg.hub.Subscribe(userID, "")
+	defer cfg.hub.Unsubscribe(subscription)
+
+	session := &websocketSession{
+		userID:     userID,
+		topics:     map[string]bool{},
+		threads:    map[uuid.UUID]map[uuid.UUID]bool{},
+		lastTyping: map[string]time.Time{},
+	}
+
+	cfg.publishPresence(request.Context(), userID, presenceOnline)
+	// The request's context may be gone by the time we disconnect.
+	defer cfg.publishPresence(context.Background(), userID, presenceOffline)
+
+	// Only this goroutine writes to conn; the reader hands messages over.
+	incoming := make(chan websocketClientMessage)
+	readDone := make(chan struct{})
+	go readWebsocket(conn, incoming, readDone)
+
+	ping := time.NewTicker(websocketPingInterval)
+	defer ping.Stop()
+
+	for {
+		var reply *websocketServerMessage
+		select {
+		case <-cfg.shuttingDown:
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(websocketWriteWait))
+			return
+		case <-readDone:
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteWait)); err != nil {
+				return
+			}
+		case message := <-incoming:
+			reply = cfg.handleWebsocketMessage(request, session, message)
+		case event, open := <-subscription.Events():
+			if !open {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"),
+					time.Now().Add(websocketWriteWait))
+				return
+			}
+			reply = session.route(event)
+		}
+
+		if reply == nil {
+			continue
+		}
+		conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+		if err := conn.WriteJSON(reply); err != nil {
+			return
+		}
+	}
+}
+
+func readWebsocket(conn *websocket.Conn, incoming chan<- websocketClientMessage, done chan<- struct{}) {
+	defer close(done)
+
+	conn.SetReadLimit(maxWebSocketMessageSize)
+	conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	})
+
+	for {
+		message := websocketClientMessage{}
+		if err := conn.ReadJSON(&message); err != nil {
+			return
+		}
+		select {
+		case incoming <- message:
+		case <-time.After(websocketWriteWait):
+			return
+		}
+	}
+}
+
+func (cfg *apiConfig) handleWebsocketMessage(request *http.Request, session *websocketSession, message websocketClientMessage) *websocketServerMessage {
+	switch message.Type {
+	case "subscribe":
+		topic, err := normaliseTopic(message.Topic)
+		if err != nil {
+			return websocketError(err)
+		}
+		if !session.topics[topic] && len(session.topics) >= maxWebSocketTopics {
+			return websocketError(fmt.Errorf("At most %d topics per connection", maxWebSocketTopics))
+		}
+		session.topics[topic] = true
+		if kind, argument, _ := strings.Cut(topic, ":"); kind == topicThread {
+			root := uuid.MustParse(argument)
+			session.threads[root] = map[uuid.UUID]bool{root: true}
+		}
+		return &websocketServerMessage{Type: "subscribed", Topics: []string{topic}}
+
+	case "unsubscribe":
+		topic, err := normaliseTopic(message.Topic)
+		if err != nil {
+			return websocketError(err)
+		}
+		delete(session.topics, topic)
+		if kind, argument, _ := strings.Cut(topic, ":"); kind == topicThread {
+			delete(session.threads, uuid.MustParse(argument))
+		}
+		return &websocketServerMessage{Type: "unsubscribed", Topics: []string{topic}}
+
+	case eventTyping:
+		topic, err := normaliseTopic(message.Topic)
+		if err != nil {
+			return websocketError(err)
+		}
+		if time.Since(session.lastTyping[topic]) < typingInterval {
+			return nil
+		}
+		session.lastTyping[topic] = time.Now()
+		cfg.publishEphemeral(request.Context(), eventTyping, typingEvent{UserId: session.userID, Topic: topic})
+		return nil
+
+	case eventPresence:
+		if message.Status != presenceOnline && message.Status != presenceAway {
+			return websocketError(fmt.Errorf("status must be %s or %s", presenceOnline, presenceAway))
+		}
+		cfg.publishPresence(request.Context(), session.userID, message.Status)
+		return nil
+	}
+	return websocketError(fmt.Errorf("Unknown message type %q", message.Type))
+}
+
+// route decides whether an event from the hub is of interest to this
+// connection, and which of its topics it matched.
+func (session *websocketSession) route(event pubsub.Event) *websocketServerMessage {
+	switch event.Type {
+	case eventChirp:
+		chirp := chirpResponse{}
+		if err := json.Unmarshal(event.Data, &chirp); err != nil {
+			return nil
+		}
+		topics := session.matchChirp(chirp)
+		if len(topics) == 0 {
+			return nil
+		}
+		return &websocketServerMessage{Type: eventChirp, Topics: topics, Data: event.Data}
+
+	case eventChirpDeleted, eventNotification:
+		return &websocketServerMessage{Type: event.Type, Data: event.Data}
+
+	case eventTyping:
+		typing := typingEvent{}
+		if err := json.Unmarshal(event.Data, &typing); err != nil {
+			return nil
+		}
+		if typing.UserId == session.userID || !session.topics[typing.Topic] {
+			return nil
+		}
+		return &websocketServerMessage{Type: eventTyping, Topics: []string{typing.Topic}, Data: event.Data}
+
+	case eventPresence:
+		presence := presenceEvent{}
+		if err := json.Unmarshal(event.Data, &presence); err != nil {
+			return nil
+		}
+		topic := topicUser + ":" + presence.UserId.String()
+		if presence.UserId == session.userID || !session.topics[topic] {
+			return nil
+		}
+		return &websocketServerMessage{Type: eventPresence, Topics: []string{topic}, Data: event.Data}
+	}
+	return nil
+}
+
+// A thread subscription only knows the chirps it has seen, so replies are
+// followed for as long as the connection stays open.
+func (session *websocketSession) matchChirp(chirp chirpResponse) []string {
+	topics := []string{}
+	for topic := range session.topics {
+		kind, argument, _ := strings.Cut(topic, ":")
+		switch kind {
+		case topicFeed:
+			topics = append(topics, topic)
+		case topicUser:
+			if argument == chirp.UserId.String() {
+				topics = append(topics, topic)
+			}
+		case topicHashtag:
+			for _, hashtag := range chirp.Entities.Hashtags {
+				if hashtag.Tag == argument {
+					topics = append(topics, topic)
+					break
+				}
+			}
+		case topicThread:
+			known := session.threads[uuid.MustParse(argument)]
+			if chirp.ReplyToId != nil && known[*chirp.ReplyToId] {
+				known[chirp.Id] = true
+				topics = append(topics, topic)
+			}
+		}
+	}
+	return topics
+}
+
+func (cfg *apiConfig) publishPresence(ctx context.Context, userID uuid.UUID, status string) {
+	cfg.publishEphemeral(ctx, eventPresence, presenceEvent{UserId: userID, Status: status})
+}
+
+func (cfg *apiConfig) publishEphemeral(ctx context.Context, eventType string, data any) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Could not marshal %s event: %s", eventType, err)
+		return
+	}
+	event := pubsub.Event{
+		ID:        pubsub.NewEventID(),
+		Type:      eventType,
+		Data:      dataJSON,
+		Ephemeral: true,
+	}
+	if err := cfg.events.publish(ctx, event); err != nil {
+		log.Printf("Could not publish %s event: %s", eventType, err)
+	}
+}
+
+func normaliseTopic(topic string) (string, error) {
+	kind, argument, _ := strings.Cut(strings.TrimSpace(topic), ":")
+	switch kind {
+	case topicFeed:
+		return topicFeed, nil
+	case topicUser, topicThread:
+		id, err := uuid.Parse(argument)
+		if err != nil {
+			return "", fmt.Errorf("%s topics take an ID", kind)
+		}
+		return kind + ":" + id.String(), nil
+	case topicHashtag:
+		tag, err := entities.NormaliseHashtag(argument)
+		if err != nil {
+			return "", err
+		}
+		return topicHashtag + ":" + tag, nil
+	}
+	return "", fmt.Errorf("Unknown topic %q", topic)
+}
+
+func websocketError(err error) *websocketServerMessage {
+	return &websocketServerMessage{Type: "error", Error: err.Error()}
+}